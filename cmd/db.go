@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time-tracker/configs"
+	"time-tracker/internal/storage"
+)
+
+// connectDatabase opens the configured database for a subcommand and
+// exits when it cannot.
+func connectDatabase() storage.Database {
+	cfg, err := configs.Load()
+	if err != nil {
+		log.Fatalln("cant load config, error: ", err)
+	}
+	db, err := storage.New(cfg.DatabaseConfig)
+	if err != nil {
+		log.Fatalln("cant create db, error: ", err)
+	}
+	if err := db.Connect(); err != nil {
+		log.Fatalln("cant connect to db, error: ", err)
+	}
+	return db
+}
+
+// userContext scopes the storage calls of a subcommand to the
+// organization of the user and exits when there is no such user.
+func userContext(db storage.Database, userID int) context.Context {
+	p, err := db.GetPrincipal(context.Background(), userID)
+	if err == sql.ErrNoRows {
+		db.Disconnect()
+		log.Fatalf("user %d not found", userID)
+	}
+	if err != nil {
+		db.Disconnect()
+		log.Fatalln("cant get user, error: ", err)
+	}
+	return storage.WithOrg(context.Background(), p.OrgID)
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"strings"
+	"time"
+	"time-tracker/internal/importer"
+	"time-tracker/internal/models"
+	"time-tracker/internal/storage"
+)
+
+// userFlags collects the repeated -user flags.
+type userFlags []string
+
+func (u *userFlags) String() string {
+	return strings.Join(*u, ",")
+}
+
+func (u *userFlags) Set(value string) error {
+	*u = append(*u, value)
+	return nil
+}
+
+// Import runs the import subcommand: it imports a Toggl or Clockify CSV
+// export into the configured database and prints the report as JSON.
+// The exit code is 1 when the import was refused.
+func Import(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	source := fs.String("source", "", "export format, toggl or clockify")
+	tz := fs.String("tz", "", "time zone of the export, UTC by default")
+	dryRun := fs.Bool("dry-run", false, "report what would be imported without importing")
+	org := fs.Int("org", models.DefaultOrganizationID, "id of the organization to import into")
+	var users userFlags
+	fs.Var(&users, "user", "email:passport mapping of a user of the export, repeatable")
+	fs.Usage = func() {
+		fs.Output().Write([]byte("usage: tracker import -source toggl|clockify [flags] export.csv\n"))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 || *org <= 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	loc, err := time.LoadLocation(*tz)
+	if err != nil {
+		log.Fatalln("unknown time zone: ", err)
+	}
+	userMap, err := importer.ParseUsers(users)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer f.Close()
+
+	db := connectDatabase()
+	defer db.Disconnect()
+
+	ctx := storage.WithOrg(context.Background(), *org)
+	report, err := importer.Import(ctx, db, f, importer.Options{
+		Source:   *source,
+		Location: loc,
+		Users:    userMap,
+		DryRun:   *dryRun,
+	})
+	if err != nil {
+		log.Fatalln("import failed: ", err)
+	}
+	out := json.NewEncoder(os.Stdout)
+	out.SetIndent("", "  ")
+	out.Encode(report)
+	if !report.DryRun && !report.Imported {
+		db.Disconnect()
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time-tracker/internal/models"
+	"time-tracker/internal/storage"
+	"time-tracker/internal/utils"
+)
+
+// Org runs the org subcommand: without flags it lists the organizations,
+// with -name it creates one together with its first admin, who then gets
+// a password with the passwd subcommand.
+func Org(args []string) {
+	fs := flag.NewFlagSet("org", flag.ExitOnError)
+	name := fs.String("name", "", "name of the new organization")
+	admin := fs.String("admin", "", `passport of its first admin, "1234 567890"`)
+	fs.Usage = func() {
+		fs.Output().Write([]byte("usage: tracker org [-name NAME -admin PASSPORT]\n"))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	serie, number, ok := models.ParsePassport(*admin)
+	if fs.NArg() != 0 || (*name == "") != (*admin == "") || (*name != "" && !ok) {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	db := connectDatabase()
+	defer db.Disconnect()
+
+	if *name == "" {
+		orgs, err := db.GetOrganizations(context.Background())
+		if err != nil {
+			db.Disconnect()
+			log.Fatalln("cant list organizations, error: ", err)
+		}
+		for _, o := range orgs {
+			fmt.Printf("%d\t%s\n", o.ID, o.Name)
+		}
+		return
+	}
+
+	org := &models.Organization{Name: *name}
+	err := db.AddOrganization(context.Background(), org)
+	if errors.Is(err, utils.ErrAlreadyExists) {
+		db.Disconnect()
+		log.Fatalf("organization %q already exists", *name)
+	}
+	if err != nil {
+		db.Disconnect()
+		log.Fatalln("cant add organization, error: ", err)
+	}
+	ctx := storage.WithOrg(context.Background(), org.ID)
+	u, err := db.AddUser(ctx, &models.User{PassSerie: serie, PassNumber: number})
+	if err == nil {
+		err = db.SetUserRole(ctx, u.Id, models.RoleAdmin, nil)
+	}
+	if err != nil {
+		db.Disconnect()
+		log.Fatalf("organization %d added, cant add its admin, error: %v", org.ID, err)
+	}
+	fmt.Printf("organization %d added, user %d is its admin\n", org.ID, u.Id)
+}
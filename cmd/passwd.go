@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"bufio"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time-tracker/internal/auth"
+)
+
+// Passwd runs the passwd subcommand: it sets the password of a user to
+// the first line read from stdin. It is how the first accounts get a
+// password before anyone can log in.
+func Passwd(args []string) {
+	fs := flag.NewFlagSet("passwd", flag.ExitOnError)
+	userID := fs.Int("user", 0, "id of the user")
+	fs.Usage = func() {
+		fs.Output().Write([]byte("usage: tracker passwd -user ID < password\n"))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *userID <= 0 || fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		log.Fatalln("cant read password: ", err)
+	}
+	hash, err := auth.HashPassword(strings.TrimRight(line, "\r\n"))
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	db := connectDatabase()
+	defer db.Disconnect()
+
+	err = db.SetUserPassword(userContext(db, *userID), *userID, hash)
+	if err == sql.ErrNoRows {
+		db.Disconnect()
+		log.Fatalf("user %d not found", *userID)
+	}
+	if err != nil {
+		db.Disconnect()
+		log.Fatalln("cant set password, error: ", err)
+	}
+	fmt.Printf("password of user %d changed\n", *userID)
+}
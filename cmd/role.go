@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time-tracker/internal/models"
+)
+
+// Role runs the role subcommand: it assigns the role and manager of a
+// user. It is how the first admin is made.
+func Role(args []string) {
+	fs := flag.NewFlagSet("role", flag.ExitOnError)
+	userID := fs.Int("user", 0, "id of the user")
+	role := fs.String("role", "", "admin, manager or employee")
+	managerID := fs.Int("manager", 0, "id of the user's manager, none by default")
+	fs.Usage = func() {
+		fs.Output().Write([]byte("usage: tracker role -user ID -role admin|manager|employee [-manager ID]\n"))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *userID <= 0 || !models.Role(*role).Valid() || fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	var manager *int
+	if *managerID > 0 {
+		manager = managerID
+	}
+
+	db := connectDatabase()
+	defer db.Disconnect()
+
+	err := db.SetUserRole(userContext(db, *userID), *userID, models.Role(*role), manager)
+	if err == sql.ErrNoRows {
+		db.Disconnect()
+		log.Fatalf("user %d not found", *userID)
+	}
+	if err != nil {
+		db.Disconnect()
+		log.Fatalln("cant set role, error: ", err)
+	}
+	fmt.Printf("user %d is now %s\n", *userID, *role)
+}
@@ -1,7 +1,12 @@
 package cmd
 
 import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
 	"time-tracker/configs"
+	"time-tracker/internal/enrich"
 	"time-tracker/internal/server"
 	"time-tracker/internal/storage"
 
@@ -34,7 +39,11 @@ func Run() {
 	sugar.Infow("config loaded",
 		"config", cfg,
 	)
-	db := storage.New(cfg.DatabaseConfig)
+	db, err := storage.New(cfg.DatabaseConfig)
+	if err != nil {
+		sugar.Fatalln("cant create db, error: ", err)
+		return
+	}
 
 	err = db.Connect()
 	if err != nil {
@@ -42,13 +51,59 @@ func Run() {
 		return
 	}
 	sugar.Infoln("successfully connected to db")
-	s := server.New(cfg.ServerConfig, db, sugar)
-	sugar.Infoln("starting the server")
-	err = s.Start()
+	enricher, err := enrich.New(cfg.EnricherConfig)
 	if err != nil {
-		sugar.Fatalw("cant start the server",
+		sugar.Fatalln("cant create enricher, error: ", err)
+		return
+	}
+	s := server.New(cfg.ServerConfig, db, enricher, sugar)
+	if err := s.BootstrapAdmin(context.Background()); err != nil {
+		sugar.Fatalln("cant bootstrap admin, error: ", err)
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// the queue is worked off in both modes, users queued before a switch
+	// to sync still get their people info
+	workerCtx, stopWorker := context.WithCancel(context.Background())
+	workerDone := make(chan struct{})
+	go func() {
+		defer close(workerDone)
+		enrich.NewWorker(cfg.EnricherConfig, db, enricher, sugar).Run(workerCtx)
+	}()
+
+	errCh := make(chan error, 1)
+	go func() {
+		sugar.Infoln("starting the server")
+		errCh <- s.Start()
+	}()
+
+	select {
+	case err = <-errCh:
+		if err != nil {
+			sugar.Errorw("cant start the server",
+				"error", err,
+			)
+		}
+	case <-ctx.Done():
+		sugar.Infoln("shutdown signal received, draining requests")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := s.Shutdown(shutdownCtx); err != nil {
+		sugar.Errorw("cant gracefully stop the server",
+			"error", err,
+		)
+	}
+	stopWorker()
+	<-workerDone
+	if err := db.Disconnect(); err != nil {
+		sugar.Errorw("cant disconnect from db",
 			"error", err,
 		)
-		return
 	}
+	sugar.Infoln("server stopped")
 }
@@ -1,9 +1,29 @@
 package main
 
 import (
+	"os"
+	// report time zones must resolve on hosts without tzdata
+	_ "time/tzdata"
+
 	"time-tracker/cmd"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "import":
+			cmd.Import(os.Args[2:])
+			return
+		case "passwd":
+			cmd.Passwd(os.Args[2:])
+			return
+		case "role":
+			cmd.Role(os.Args[2:])
+			return
+		case "org":
+			cmd.Org(os.Args[2:])
+			return
+		}
+	}
 	cmd.Run()
 }
@@ -1,7 +1,10 @@
 package configs
 
 import (
+	"fmt"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -13,16 +16,91 @@ type DatabaseConfig struct {
 	Port     string
 	Pass     string
 	Driver   string
+	// QueryTimeout limits every single query, zero disables the limit
+	QueryTimeout time.Duration
 }
 
+// What POST /tasks/start does when the user already has a running task.
+const (
+	TaskStartReject      = "reject"
+	TaskStartStopRunning = "stop_running"
+)
+
+// How POST /create fills in the people info of a new user.
+const (
+	// UserEnrichmentSync asks the service before the user is created
+	UserEnrichmentSync = "sync"
+	// UserEnrichmentAsync creates the user right away and queues the lookup
+	UserEnrichmentAsync = "async"
+)
+
 type ServerConfig struct {
 	Host string
 	Port string
+	// ShutdownTimeout is how long in-flight requests may drain on shutdown
+	ShutdownTimeout time.Duration
+	// TaskStartPolicy is TaskStartReject or TaskStartStopRunning
+	TaskStartPolicy string
+	// UserEnrichment is UserEnrichmentSync or UserEnrichmentAsync
+	UserEnrichment string
+	// JWTSecret is the HMAC key signing access tokens, it is never logged
+	JWTSecret string `json:"-"`
+	// TokenTTL is how long an access token is valid
+	TokenTTL time.Duration
+	// AdminPassport and AdminPassword create the first admin at startup,
+	// both are empty when there is none to create
+	AdminPassport string
+	AdminPassword string `json:"-"`
 }
 
+// Values of ENRICH_DRIVER.
+const (
+	EnricherHTTP = "http"
+	EnricherStub = "stub"
+)
+
+// EnricherConfig configures the people info service that fills in the
+// names and address of a new user from their passport.
+type EnricherConfig struct {
+	// Driver is EnricherHTTP or EnricherStub, the stub answers without a service
+	Driver string
+	// URL is the base URL of the service, /info is appended
+	URL string
+	// Timeout limits a single call of the service
+	Timeout time.Duration
+	// Retries is how many times a failed call is repeated
+	Retries int
+	// RetryBackoff is the pause before the first retry, it doubles after each
+	RetryBackoff time.Duration
+	// BreakerThreshold consecutive failures stop the calls for BreakerCooldown,
+	// zero disables the breaker
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+	// CacheTTL is how long an answer is reused, zero disables the cache
+	CacheTTL time.Duration
+	// CacheNegativeTTL is how long an unknown passport stays unknown
+	CacheNegativeTTL time.Duration
+	// CacheSize is the most passports kept, the least recently used go first
+	CacheSize int
+	// QueueInterval is how often the enrichment queue is polled
+	QueueInterval time.Duration
+	// QueueBackoff is the pause before a queued lookup is tried again, it
+	// doubles after each attempt up to QueueMaxBackoff
+	QueueBackoff    time.Duration
+	QueueMaxBackoff time.Duration
+	// QueueMaxAttempts marks a user failed after that many lookups, zero
+	// retries until the service answers
+	QueueMaxAttempts int
+}
+
+// minJWTSecretLen is the shortest accepted AUTH_JWT_SECRET, HS256 wants
+// a key at least as long as its 256 bit hash.
+const minJWTSecretLen = 32
+
 type Config struct {
 	DatabaseConfig
 	ServerConfig
+	EnricherConfig
 }
 
 func Load() (*Config, error) {
@@ -31,18 +109,162 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
+	queryTimeout, err := durationEnv("DB_QUERY_TIMEOUT", 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	shutdownTimeout, err := durationEnv("S_SHUTDOWN_TIMEOUT", 15*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	taskStartPolicy := os.Getenv("TASK_START_POLICY")
+	switch taskStartPolicy {
+	case "":
+		taskStartPolicy = TaskStartReject
+	case TaskStartReject, TaskStartStopRunning:
+	default:
+		return nil, fmt.Errorf("invalid TASK_START_POLICY: %q", taskStartPolicy)
+	}
+
+	userEnrichment := os.Getenv("USER_ENRICHMENT")
+	switch userEnrichment {
+	case "":
+		userEnrichment = UserEnrichmentSync
+	case UserEnrichmentSync, UserEnrichmentAsync:
+	default:
+		return nil, fmt.Errorf("invalid USER_ENRICHMENT: %q", userEnrichment)
+	}
+
+	jwtSecret := os.Getenv("AUTH_JWT_SECRET")
+	if len(jwtSecret) < minJWTSecretLen {
+		return nil, fmt.Errorf("AUTH_JWT_SECRET must be at least %d characters", minJWTSecretLen)
+	}
+
+	tokenTTL, err := durationEnv("AUTH_TOKEN_TTL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	adminPassport := os.Getenv("ADMIN_BOOTSTRAP_PASSPORT")
+	adminPassword := os.Getenv("ADMIN_BOOTSTRAP_PASSWORD")
+	if (adminPassport == "") != (adminPassword == "") {
+		return nil, fmt.Errorf("ADMIN_BOOTSTRAP_PASSPORT and ADMIN_BOOTSTRAP_PASSWORD must be set together")
+	}
+
+	enricher, err := loadEnricher()
+	if err != nil {
+		return nil, err
+	}
+
 	return &Config{
 		DatabaseConfig{
-			Username: os.Getenv("DB_USER"),
-			Pass:     os.Getenv("DB_PASS"),
-			Name:     os.Getenv("DB_NAME"),
-			Host:     os.Getenv("DB_HOST"),
-			Port:     os.Getenv("DB_PORT"),
-			Driver:   os.Getenv("DB_DRIVER"),
+			Username:     os.Getenv("DB_USER"),
+			Pass:         os.Getenv("DB_PASS"),
+			Name:         os.Getenv("DB_NAME"),
+			Host:         os.Getenv("DB_HOST"),
+			Port:         os.Getenv("DB_PORT"),
+			Driver:       os.Getenv("DB_DRIVER"),
+			QueryTimeout: queryTimeout,
 		},
 		ServerConfig{
-			Host: os.Getenv("S_HOST"),
-			Port: os.Getenv("S_PORT"),
+			Host:            os.Getenv("S_HOST"),
+			Port:            os.Getenv("S_PORT"),
+			ShutdownTimeout: shutdownTimeout,
+			TaskStartPolicy: taskStartPolicy,
+			UserEnrichment:  userEnrichment,
+			JWTSecret:       jwtSecret,
+			TokenTTL:        tokenTTL,
+			AdminPassport:   adminPassport,
+			AdminPassword:   adminPassword,
 		},
+		*enricher,
 	}, nil
 }
+
+func loadEnricher() (*EnricherConfig, error) {
+	cfg := EnricherConfig{
+		Driver: os.Getenv("ENRICH_DRIVER"),
+		URL:    os.Getenv("ENRICH_URL"),
+	}
+	switch cfg.Driver {
+	case "":
+		cfg.Driver = EnricherHTTP
+	case EnricherHTTP, EnricherStub:
+	default:
+		return nil, fmt.Errorf("invalid ENRICH_DRIVER: %q", cfg.Driver)
+	}
+	if cfg.URL == "" {
+		cfg.URL = "http://localhost:8081"
+	}
+	var err error
+	if cfg.Timeout, err = durationEnv("ENRICH_TIMEOUT", 3*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.Retries, err = intEnv("ENRICH_RETRIES", 2); err != nil {
+		return nil, err
+	}
+	if cfg.RetryBackoff, err = durationEnv("ENRICH_RETRY_BACKOFF", 200*time.Millisecond); err != nil {
+		return nil, err
+	}
+	if cfg.BreakerThreshold, err = intEnv("ENRICH_BREAKER_THRESHOLD", 5); err != nil {
+		return nil, err
+	}
+	if cfg.BreakerCooldown, err = durationEnv("ENRICH_BREAKER_COOLDOWN", 30*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.CacheTTL, err = durationEnv("ENRICH_CACHE_TTL", time.Hour); err != nil {
+		return nil, err
+	}
+	if cfg.CacheNegativeTTL, err = durationEnv("ENRICH_CACHE_NEGATIVE_TTL", 5*time.Minute); err != nil {
+		return nil, err
+	}
+	if cfg.CacheSize, err = intEnv("ENRICH_CACHE_SIZE", 10000); err != nil {
+		return nil, err
+	}
+	if cfg.QueueInterval, err = durationEnv("ENRICH_QUEUE_INTERVAL", 2*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.QueueInterval <= 0 {
+		return nil, fmt.Errorf("ENRICH_QUEUE_INTERVAL must be positive")
+	}
+	if cfg.QueueBackoff, err = durationEnv("ENRICH_QUEUE_BACKOFF", 10*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.QueueMaxBackoff, err = durationEnv("ENRICH_QUEUE_MAX_BACKOFF", 10*time.Minute); err != nil {
+		return nil, err
+	}
+	if cfg.QueueMaxAttempts, err = intEnv("ENRICH_QUEUE_MAX_ATTEMPTS", 0); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// durationEnv parses key as a time.Duration ("500ms", "10s"),
+// falling back to def when the variable is not set.
+func durationEnv(key string, def time.Duration) (time.Duration, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return d, nil
+}
+
+// intEnv parses key as a non-negative integer, falling back to def when
+// the variable is not set.
+func intEnv(key string, def int) (int, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s: %q", key, v)
+	}
+	return n, nil
+}
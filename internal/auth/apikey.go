@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	// APIKeyPrefix starts every API key, it tells a key apart from an
+	// access token in the Authorization header.
+	APIKeyPrefix = "tt_"
+	// apiKeyShown is how much of a key is kept to recognize it by.
+	apiKeyShown = len(APIKeyPrefix) + 8
+)
+
+// NewAPIKey returns a new random API key and the prefix shown in lists.
+func NewAPIKey() (key, prefix string, err error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	key = APIKeyPrefix + hex.EncodeToString(raw)
+	return key, key[:apiKeyShown], nil
+}
+
+// IsAPIKey reports whether a bearer credential is an API key rather
+// than an access token.
+func IsAPIKey(raw string) bool {
+	return strings.HasPrefix(raw, APIKeyPrefix)
+}
+
+// HashAPIKey returns the hash stored for a key. The keys are long and
+// random, so a fast hash is enough.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,80 @@
+// Package auth hashes user passwords and issues the signed access tokens
+// the API expects in the Authorization header.
+package auth
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MinPasswordLen is the shortest accepted password.
+const MinPasswordLen = 8
+
+var (
+	ErrPasswordTooShort = errors.New("password must be at least 8 characters")
+	ErrInvalidToken     = errors.New("invalid token")
+	ErrInvalidAPIKey    = errors.New("invalid api key")
+)
+
+// HashPassword returns the bcrypt hash stored for a password.
+func HashPassword(password string) (string, error) {
+	if len(password) < MinPasswordLen {
+		return "", ErrPasswordTooShort
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// dummyHash is compared against when the user has no password, so a
+// failed login takes as long whether or not the account exists.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("time-tracker"), bcrypt.DefaultCost)
+
+// CheckPassword reports whether password matches hash. An empty hash
+// never matches.
+func CheckPassword(hash, password string) bool {
+	if hash == "" {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// NewToken signs an HS256 access token for the user valid for ttl.
+func NewToken(secret string, userID int, now time.Time, ttl time.Duration) (string, time.Time, error) {
+	expires := now.Add(ttl)
+	claims := jwt.RegisteredClaims{
+		Subject:   strconv.Itoa(userID),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(expires),
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expires, nil
+}
+
+// ParseToken validates an access token and returns the user it was
+// issued to. Tokens signed with another algorithm or without an expiry
+// are rejected.
+func ParseToken(secret, raw string) (int, error) {
+	var claims jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(raw, &claims, func(*jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return 0, ErrInvalidToken
+	}
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil || userID <= 0 {
+		return 0, ErrInvalidToken
+	}
+	return userID, nil
+}
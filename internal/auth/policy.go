@@ -0,0 +1,50 @@
+package auth
+
+import "time-tracker/internal/models"
+
+// Action is something a principal asks to do, checked by Allow.
+type Action int
+
+const (
+	// ReadUserData covers the tasks and reports of a user.
+	ReadUserData Action = iota
+	// WriteUserData covers changing the tasks, password and calendar
+	// token of a user.
+	WriteUserData
+	// ListUsers covers searching all users and teams and listing the tags
+	// of everyone's tasks.
+	ListUsers
+	// ManageUsers covers creating, updating and deleting users and teams
+	// and assigning roles.
+	ManageUsers
+	// ManageProjects covers changing clients and projects.
+	ManageProjects
+	// ImportData covers importing time entries of any user.
+	ImportData
+	// ReadTeamData covers the reports of a whole team.
+	ReadTeamData
+)
+
+// Allow reports whether p may do action. ownerID is the user whose data
+// is touched, or the team for ReadTeamData, it is ignored by the other
+// actions.
+//
+// Admins may do everything. Managers also read the data of their team and
+// the reports of the teams they lead, list users and teams and manage
+// projects. Everyone reads and writes their own data.
+func Allow(p models.Principal, action Action, ownerID int) bool {
+	if p.Role == models.RoleAdmin {
+		return true
+	}
+	switch action {
+	case ReadUserData:
+		return ownerID == p.UserID || p.Role == models.RoleManager && p.Manages(ownerID)
+	case WriteUserData:
+		return ownerID == p.UserID
+	case ReadTeamData:
+		return p.Role == models.RoleManager && p.Leads(ownerID)
+	case ListUsers, ManageProjects:
+		return p.Role == models.RoleManager
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"testing"
+	"time-tracker/internal/models"
+)
+
+func TestAllow(t *testing.T) {
+	// user 2 manages user 3 and leads team 10, user 4 is in no team
+	admin := models.Principal{UserID: 1, Role: models.RoleAdmin}
+	manager := models.Principal{UserID: 2, Role: models.RoleManager, Team: []int{3}, Teams: []int{10}}
+	employee := models.Principal{UserID: 3, Role: models.RoleEmployee}
+	// a demoted manager keeps its team until it is reassigned
+	demoted := models.Principal{UserID: 5, Role: models.RoleEmployee, Team: []int{3}, Teams: []int{10}}
+
+	tests := []struct {
+		name   string
+		p      models.Principal
+		action Action
+		owner  int
+		want   bool
+	}{
+		{"admin reads anyone", admin, ReadUserData, 4, true},
+		{"admin writes anyone", admin, WriteUserData, 4, true},
+		{"admin lists users", admin, ListUsers, 0, true},
+		{"admin manages users", admin, ManageUsers, 0, true},
+		{"admin manages projects", admin, ManageProjects, 0, true},
+		{"admin imports", admin, ImportData, 0, true},
+		{"admin reads any team", admin, ReadTeamData, 11, true},
+
+		{"manager reads own data", manager, ReadUserData, 2, true},
+		{"manager reads a report", manager, ReadUserData, 3, true},
+		{"manager reads outside the team", manager, ReadUserData, 4, false},
+		{"manager writes own data", manager, WriteUserData, 2, true},
+		{"manager writes a report", manager, WriteUserData, 3, false},
+		{"manager lists users", manager, ListUsers, 0, true},
+		{"manager manages users", manager, ManageUsers, 0, false},
+		{"manager manages projects", manager, ManageProjects, 0, true},
+		{"manager imports", manager, ImportData, 0, false},
+		{"manager reads a led team", manager, ReadTeamData, 10, true},
+		{"manager reads another team", manager, ReadTeamData, 11, false},
+
+		{"employee reads own data", employee, ReadUserData, 3, true},
+		{"employee reads another user", employee, ReadUserData, 4, false},
+		{"employee reads the manager", employee, ReadUserData, 2, false},
+		{"employee writes own data", employee, WriteUserData, 3, true},
+		{"employee writes another user", employee, WriteUserData, 4, false},
+		{"employee lists users", employee, ListUsers, 0, false},
+		{"employee manages users", employee, ManageUsers, 0, false},
+		{"employee manages projects", employee, ManageProjects, 0, false},
+		{"employee imports", employee, ImportData, 0, false},
+		{"employee reads a team", employee, ReadTeamData, 10, false},
+
+		{"demoted manager reads a former report", demoted, ReadUserData, 3, false},
+		{"demoted manager reads a former team", demoted, ReadTeamData, 10, false},
+		{"unknown action", admin, Action(100), 0, true},
+		{"unknown action of a manager", manager, Action(100), 2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Allow(tt.p, tt.action, tt.owner); got != tt.want {
+				t.Errorf("Allow(%+v, %d, %d) = %v, want %v", tt.p, tt.action, tt.owner, got, tt.want)
+			}
+		})
+	}
+}
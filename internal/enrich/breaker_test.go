@@ -0,0 +1,68 @@
+package enrich
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+	b := &breaker{threshold: 2, cooldown: 10 * time.Second}
+
+	steps := []struct {
+		name  string
+		now   int
+		allow bool
+		// ok is the outcome recorded for an allowed call
+		ok bool
+	}{
+		{"closed", 0, true, false},
+		{"one failure stays closed", 1, true, false},
+		{"threshold opens", 2, false, false},
+		{"open until cooldown", 10, false, false},
+		{"half-open probe", 11, true, false},
+		{"failed probe reopens", 12, false, false},
+		{"second probe", 21, true, true},
+		{"successful probe closes", 22, true, false},
+		{"failures count from zero", 23, true, true},
+	}
+	for _, step := range steps {
+		if got := b.allow(at(step.now)); got != step.allow {
+			t.Fatalf("%s: allow %v, want %v", step.name, got, step.allow)
+		}
+		if step.allow {
+			b.record(step.ok, at(step.now))
+		}
+	}
+}
+
+func TestBreakerProbesOnce(t *testing.T) {
+	now := time.Now()
+	b := &breaker{threshold: 1, cooldown: time.Second}
+	b.record(false, now)
+
+	later := now.Add(2 * time.Second)
+	if !b.allow(later) {
+		t.Fatal("no probe after the cooldown")
+	}
+	if b.allow(later) {
+		t.Fatal("second call let through during the probe")
+	}
+	// a probe the caller gave up on does not count
+	b.abort()
+	if !b.allow(later) {
+		t.Fatal("no probe after an aborted one")
+	}
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	b := &breaker{}
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		b.record(false, now)
+	}
+	if !b.allow(now) {
+		t.Fatal("a breaker without threshold opened")
+	}
+}
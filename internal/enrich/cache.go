@@ -0,0 +1,160 @@
+package enrich
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Cache reuses the answers of another Enricher. Found people are kept for
+// ttl and unknown passports for negativeTTL, failures are not kept. At
+// most size passports are kept, the least recently used are evicted.
+// Concurrent misses of a passport share a single lookup.
+type Cache struct {
+	next        Enricher
+	ttl         time.Duration
+	negativeTTL time.Duration
+	size        int
+	// now is replaced in checks of expiry
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// recent holds *cacheEntry, the most recently used first
+	recent *list.List
+	stats  CacheStats
+
+	lookups singleflight.Group
+}
+
+type cacheEntry struct {
+	key string
+	// info is nil for an unknown passport
+	info    *Info
+	expires time.Time
+}
+
+// CacheStats counts the lookups of a Cache since it was created.
+type CacheStats struct {
+	Hits int64 `json:"hits"`
+	// NegativeHits are the hits of unknown passports, included in Hits
+	NegativeHits int64   `json:"negative_hits"`
+	Misses       int64   `json:"misses"`
+	Evictions    int64   `json:"evictions"`
+	Size         int     `json:"size"`
+	HitRatio     float64 `json:"hit_ratio"`
+}
+
+func NewCache(next Enricher, ttl, negativeTTL time.Duration, size int) *Cache {
+	return &Cache{
+		next:        next,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		size:        size,
+		now:         time.Now,
+		entries:     make(map[string]*list.Element),
+		recent:      list.New(),
+	}
+}
+
+func (c *Cache) Enrich(ctx context.Context, serie, number string) (*Info, error) {
+	key := serie + " " + number
+	if info, err, ok := c.get(key); ok {
+		return info, err
+	}
+	done := c.lookups.DoChan(key, func() (interface{}, error) {
+		// the lookup is shared, a caller giving up must not fail the
+		// others, the client timeout still bounds it
+		info, err := c.next.Enrich(context.WithoutCancel(ctx), serie, number)
+		switch {
+		case err == nil:
+			c.put(key, info, c.ttl)
+		case errors.Is(err, ErrNotFound) && c.negativeTTL > 0:
+			c.put(key, nil, c.negativeTTL)
+		}
+		return info, err
+	})
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%w: %w", ErrUnavailable, ctx.Err())
+	case res := <-done:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		// every caller gets its own copy
+		info := *res.Val.(*Info)
+		return &info, nil
+	}
+}
+
+// Stats returns the counters and the share of lookups answered from the cache.
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Size = c.recent.Len()
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
+
+// get returns the kept answer for key, ok is false on a miss.
+func (c *Cache) get(key string) (*Info, error, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, found := c.entries[key]
+	if found && !c.now().Before(el.Value.(*cacheEntry).expires) {
+		c.remove(el)
+		found = false
+	}
+	if !found {
+		c.stats.Misses++
+		return nil, nil, false
+	}
+	c.recent.MoveToFront(el)
+	c.stats.Hits++
+	e := el.Value.(*cacheEntry)
+	if e.info == nil {
+		c.stats.NegativeHits++
+		return nil, ErrNotFound, true
+	}
+	// callers may change what they get
+	info := *e.info
+	return &info, nil, true
+}
+
+func (c *Cache) put(key string, info *Info, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var kept *Info
+	if info != nil {
+		copied := *info
+		kept = &copied
+	}
+	e := &cacheEntry{key: key, info: kept, expires: c.now().Add(ttl)}
+	if el, ok := c.entries[key]; ok {
+		el.Value = e
+		c.recent.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.recent.PushFront(e)
+	for c.recent.Len() > c.size {
+		c.remove(c.recent.Back())
+		c.stats.Evictions++
+	}
+}
+
+// remove must be called with c.mu held.
+func (c *Cache) remove(el *list.Element) {
+	c.recent.Remove(el)
+	delete(c.entries, el.Value.(*cacheEntry).key)
+}
@@ -0,0 +1,226 @@
+package enrich
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// clock is a Cache.now the test moves by hand.
+type clock struct{ now time.Time }
+
+func (c *clock) Now() time.Time { return c.now }
+
+func newTestCache(next Enricher, size int) (*Cache, *clock) {
+	c := NewCache(next, time.Hour, time.Minute, size)
+	clk := &clock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	c.now = clk.Now
+	return c, clk
+}
+
+var people = map[string]Info{
+	"1111 111111": {Surname: "Ivanov"},
+	"2222 222222": {Surname: "Petrov"},
+	"3333 333333": {Surname: "Sidorov"},
+}
+
+func TestCacheTTL(t *testing.T) {
+	stub := &Stub{People: people}
+	c, clk := newTestCache(stub, 10)
+	ctx := context.Background()
+
+	for _, step := range []struct {
+		name  string
+		after time.Duration
+		calls int
+	}{
+		{"first lookup", 0, 1},
+		{"kept", 59 * time.Minute, 1},
+		{"expired", time.Minute, 2},
+		{"kept again", 0, 2},
+	} {
+		clk.now = clk.now.Add(step.after)
+		info, err := c.Enrich(ctx, "1111", "111111")
+		if err != nil || info.Surname != "Ivanov" {
+			t.Fatalf("%s: info %+v, error %v", step.name, info, err)
+		}
+		if stub.Calls != step.calls {
+			t.Fatalf("%s: %d calls, want %d", step.name, stub.Calls, step.calls)
+		}
+	}
+}
+
+func TestCacheNegative(t *testing.T) {
+	stub := &Stub{People: people}
+	c, clk := newTestCache(stub, 10)
+	ctx := context.Background()
+
+	for _, step := range []struct {
+		name  string
+		after time.Duration
+		calls int
+	}{
+		{"unknown", 0, 1},
+		{"still unknown", 30 * time.Second, 1},
+		{"asked again after the negative ttl", 30 * time.Second, 2},
+	} {
+		clk.now = clk.now.Add(step.after)
+		if _, err := c.Enrich(ctx, "9999", "999999"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("%s: error %v, want ErrNotFound", step.name, err)
+		}
+		if stub.Calls != step.calls {
+			t.Fatalf("%s: %d calls, want %d", step.name, stub.Calls, step.calls)
+		}
+	}
+
+	// failures are not kept
+	stub.Err = ErrUnavailable
+	for i := 0; i < 2; i++ {
+		c.Enrich(ctx, "1111", "111111")
+	}
+	if stub.Calls != 4 {
+		t.Fatalf("%d calls, want 4", stub.Calls)
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	stub := &Stub{People: people}
+	c, _ := newTestCache(stub, 2)
+	ctx := context.Background()
+
+	c.Enrich(ctx, "1111", "111111")
+	c.Enrich(ctx, "2222", "222222")
+	// using 1111 makes 2222 the least recently used
+	c.Enrich(ctx, "1111", "111111")
+	c.Enrich(ctx, "3333", "333333")
+	if stub.Calls != 3 {
+		t.Fatalf("%d calls, want 3", stub.Calls)
+	}
+	c.Enrich(ctx, "1111", "111111")
+	if stub.Calls != 3 {
+		t.Fatal("the recently used passport was evicted")
+	}
+	c.Enrich(ctx, "2222", "222222")
+	if stub.Calls != 4 {
+		t.Fatal("the least recently used passport was kept")
+	}
+	if stats := c.Stats(); stats.Evictions != 2 || stats.Size != 2 {
+		t.Fatalf("stats %+v, want 2 evictions and size 2", stats)
+	}
+}
+
+func TestCacheStats(t *testing.T) {
+	c, _ := newTestCache(&Stub{People: people}, 10)
+	ctx := context.Background()
+
+	c.Enrich(ctx, "1111", "111111")
+	c.Enrich(ctx, "1111", "111111")
+	c.Enrich(ctx, "1111", "111111")
+	c.Enrich(ctx, "9999", "999999")
+	c.Enrich(ctx, "9999", "999999")
+
+	want := CacheStats{Hits: 3, NegativeHits: 1, Misses: 2, Size: 2, HitRatio: 0.6}
+	if stats := c.Stats(); stats != want {
+		t.Fatalf("stats %+v, want %+v", stats, want)
+	}
+}
+
+func TestCacheReturnsCopies(t *testing.T) {
+	c, _ := newTestCache(&Stub{People: people}, 10)
+	ctx := context.Background()
+
+	info, _ := c.Enrich(ctx, "1111", "111111")
+	info.Surname = "Changed"
+	if info, _ := c.Enrich(ctx, "1111", "111111"); info.Surname != "Ivanov" {
+		t.Fatalf("a caller changed the kept answer to %q", info.Surname)
+	}
+}
+
+// slowEnricher holds every lookup until release is closed.
+type slowEnricher struct {
+	Stub
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *slowEnricher) Enrich(ctx context.Context, serie, number string) (*Info, error) {
+	s.started <- struct{}{}
+	<-s.release
+	return s.Stub.Enrich(ctx, serie, number)
+}
+
+func (s *slowEnricher) calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Calls
+}
+
+func TestCacheCoalescesLookups(t *testing.T) {
+	slow := &slowEnricher{Stub: Stub{People: people}, started: make(chan struct{}, 10), release: make(chan struct{})}
+	c, _ := newTestCache(slow, 10)
+
+	const callers = 5
+	var wg sync.WaitGroup
+	infos := make([]*Info, callers)
+	errs := make([]error, callers)
+	ready := make(chan struct{}, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ready <- struct{}{}
+			infos[i], errs[i] = c.Enrich(context.Background(), "1111", "111111")
+		}(i)
+	}
+	<-slow.started
+	for i := 0; i < callers; i++ {
+		<-ready
+	}
+	// give the callers time to join the lookup in flight
+	time.Sleep(20 * time.Millisecond)
+	close(slow.release)
+	wg.Wait()
+
+	if slow.Calls != 1 {
+		t.Fatalf("%d lookups, want 1", slow.Calls)
+	}
+	for i := range infos {
+		if errs[i] != nil || infos[i].Surname != "Ivanov" {
+			t.Fatalf("caller %d: info %+v, error %v", i, infos[i], errs[i])
+		}
+		if i > 0 && infos[i] == infos[0] {
+			t.Fatal("callers share the answer")
+		}
+	}
+}
+
+func TestCacheCallerGivesUp(t *testing.T) {
+	slow := &slowEnricher{Stub: Stub{People: people}, started: make(chan struct{}, 10), release: make(chan struct{})}
+	c, _ := newTestCache(slow, 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error)
+	go func() {
+		_, err := c.Enrich(ctx, "1111", "111111")
+		errc <- err
+	}()
+	<-slow.started
+	cancel()
+	if err := <-errc; !errors.Is(err, ErrUnavailable) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("error %v, want ErrUnavailable of the cancellation", err)
+	}
+
+	// the lookup went on and its answer is kept
+	close(slow.release)
+	for slow.calls() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := c.Enrich(context.Background(), "1111", "111111"); err != nil {
+		t.Fatal(err)
+	}
+	if slow.calls() != 1 {
+		t.Fatalf("%d lookups, want 1", slow.calls())
+	}
+}
@@ -0,0 +1,51 @@
+// Package enrich looks up the names and address of a person by passport
+// in the people info service.
+package enrich
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time-tracker/configs"
+)
+
+var (
+	// ErrNotFound means the service knows no person with the passport.
+	ErrNotFound = errors.New("user with these passport data not found in database")
+	// ErrRejected means the service refused the request as invalid.
+	ErrRejected = errors.New("people info service rejected the passport")
+	// ErrUnavailable wraps the failures worth retrying later: timeouts,
+	// network errors, server errors and an open circuit breaker.
+	ErrUnavailable = errors.New("people info service unavailable")
+)
+
+// Info is what the service knows about a person.
+type Info struct {
+	Surname    string `json:"surname"`
+	Name       string `json:"name"`
+	Patronymic string `json:"patronymic"`
+	Address    string `json:"address"`
+}
+
+// Enricher finds the person with the passport, given as serie and number.
+type Enricher interface {
+	Enrich(ctx context.Context, serie, number string) (*Info, error)
+}
+
+// New returns the enricher selected by cfg.Driver, behind a Cache unless
+// cfg disables it.
+func New(cfg configs.EnricherConfig) (Enricher, error) {
+	var e Enricher
+	switch cfg.Driver {
+	case configs.EnricherHTTP:
+		e = NewHTTP(cfg)
+	case configs.EnricherStub:
+		e = &Stub{}
+	default:
+		return nil, fmt.Errorf("unknown enricher driver: %q", cfg.Driver)
+	}
+	if cfg.CacheTTL <= 0 || cfg.CacheSize <= 0 {
+		return e, nil
+	}
+	return NewCache(e, cfg.CacheTTL, cfg.CacheNegativeTTL, cfg.CacheSize), nil
+}
@@ -0,0 +1,102 @@
+package enrich
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"time-tracker/configs"
+)
+
+// errCircuitOpen is the ErrUnavailable of a call the breaker stopped,
+// retrying it before the cooldown ends is pointless.
+var errCircuitOpen = fmt.Errorf("%w: circuit breaker open", ErrUnavailable)
+
+// HTTP calls the people info service. Calls failing with ErrUnavailable
+// are retried with a doubling backoff, and a circuit breaker stops
+// calling a service that keeps failing.
+type HTTP struct {
+	url     string
+	client  *http.Client
+	retries int
+	backoff time.Duration
+	breaker *breaker
+}
+
+func NewHTTP(cfg configs.EnricherConfig) *HTTP {
+	return &HTTP{
+		url:     strings.TrimRight(cfg.URL, "/") + "/info",
+		client:  &http.Client{Timeout: cfg.Timeout},
+		retries: cfg.Retries,
+		backoff: cfg.RetryBackoff,
+		breaker: &breaker{threshold: cfg.BreakerThreshold, cooldown: cfg.BreakerCooldown},
+	}
+}
+
+func (h *HTTP) Enrich(ctx context.Context, serie, number string) (*Info, error) {
+	body, err := json.Marshal(map[string]string{
+		"passport_number": serie + " " + number,
+	})
+	if err != nil {
+		return nil, err
+	}
+	wait := h.backoff
+	for attempt := 0; ; attempt++ {
+		info, err := h.call(ctx, body)
+		if !errors.Is(err, ErrUnavailable) || errors.Is(err, errCircuitOpen) || attempt >= h.retries {
+			return info, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: %w", ErrUnavailable, ctx.Err())
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// call makes a single attempt past the breaker.
+func (h *HTTP) call(ctx context.Context, body []byte) (*Info, error) {
+	if !h.breaker.allow(time.Now()) {
+		return nil, errCircuitOpen
+	}
+	info, err := h.post(ctx, body)
+	if ctx.Err() != nil {
+		// the caller gave up, that says nothing about the service
+		h.breaker.abort()
+		return nil, fmt.Errorf("%w: %w", ErrUnavailable, ctx.Err())
+	}
+	h.breaker.record(!errors.Is(err, ErrUnavailable), time.Now())
+	return info, err
+}
+
+func (h *HTTP) post(ctx context.Context, body []byte) (*Info, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNotFound
+	case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= http.StatusInternalServerError:
+		return nil, fmt.Errorf("%w: status %d", ErrUnavailable, resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("%w: status %d", ErrRejected, resp.StatusCode)
+	}
+	var info Info
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("%w: invalid response: %w", ErrUnavailable, err)
+	}
+	return &info, nil
+}
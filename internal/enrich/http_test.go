@@ -0,0 +1,138 @@
+package enrich
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+	"time-tracker/configs"
+)
+
+// service answers with the statuses in turn, the last one from then on,
+// and counts the requests.
+type service struct {
+	mu       sync.Mutex
+	statuses []int
+	requests int
+}
+
+func (s *service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	status := s.statuses[min(s.requests, len(s.statuses)-1)]
+	s.requests++
+	s.mu.Unlock()
+
+	w.WriteHeader(status)
+	if status == http.StatusOK {
+		w.Write([]byte(`{"surname": "Ivanov", "name": "Ivan"}`))
+	}
+}
+
+func (s *service) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func newTestHTTP(t *testing.T, svc http.Handler, threshold int) *HTTP {
+	srv := httptest.NewServer(svc)
+	t.Cleanup(srv.Close)
+	return NewHTTP(configs.EnricherConfig{
+		URL:              srv.URL,
+		Timeout:          time.Second,
+		Retries:          2,
+		RetryBackoff:     time.Millisecond,
+		BreakerThreshold: threshold,
+		BreakerCooldown:  time.Hour,
+	})
+}
+
+func TestHTTPRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		err      error
+		requests int
+	}{
+		{"found", []int{200}, nil, 1},
+		{"recovers", []int{503, 500, 200}, nil, 3},
+		{"too many requests", []int{429, 200}, nil, 2},
+		{"gives up", []int{502}, ErrUnavailable, 3},
+		{"not found is final", []int{404, 200}, ErrNotFound, 1},
+		{"rejected is final", []int{400, 200}, ErrRejected, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &service{statuses: tt.statuses}
+			info, err := newTestHTTP(t, svc, 0).Enrich(context.Background(), "1234", "567890")
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error %v, want %v", err, tt.err)
+			}
+			if err == nil && info.Surname != "Ivanov" {
+				t.Fatalf("info %+v", info)
+			}
+			if svc.count() != tt.requests {
+				t.Fatalf("%d requests, want %d", svc.count(), tt.requests)
+			}
+		})
+	}
+}
+
+func TestHTTPBreakerStopsCalls(t *testing.T) {
+	svc := &service{statuses: []int{500}}
+	h := newTestHTTP(t, svc, 3)
+
+	// the three attempts of the first lookup open the breaker
+	if _, err := h.Enrich(context.Background(), "1234", "567890"); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("error %v, want ErrUnavailable", err)
+	}
+	_, err := h.Enrich(context.Background(), "1234", "567890")
+	if !errors.Is(err, errCircuitOpen) {
+		t.Fatalf("error %v, want an open circuit", err)
+	}
+	if svc.count() != 3 {
+		t.Fatalf("%d requests, want 3", svc.count())
+	}
+}
+
+func TestHTTPCancel(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	svc := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+	h := newTestHTTP(t, svc, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := h.Enrich(ctx, "1234", "567890")
+	if !errors.Is(err, ErrUnavailable) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error %v, want ErrUnavailable of the deadline", err)
+	}
+	// giving up is not a failure of the service
+	if !h.breaker.allow(time.Now()) {
+		t.Fatal("a cancelled call opened the breaker")
+	}
+}
+
+func TestHTTPCancelDuringBackoff(t *testing.T) {
+	svc := &service{statuses: []int{503}}
+	h := newTestHTTP(t, svc, 0)
+	h.backoff = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := h.Enrich(ctx, "1234", "567890")
+	if !errors.Is(err, ErrUnavailable) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error %v, want ErrUnavailable of the deadline", err)
+	}
+	if svc.count() != 1 {
+		t.Fatalf("%d requests, want 1", svc.count())
+	}
+}
@@ -0,0 +1,128 @@
+package enrich
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"time-tracker/configs"
+	"time-tracker/internal/models"
+	"time-tracker/internal/storage"
+
+	"go.uber.org/zap"
+)
+
+// Lookups claimed by one poll of the queue.
+const queueBatch = 10
+
+// claimLease hides a claimed job from other workers. It outlasts a lookup
+// with all its retries, a job left by a worker that died is tried again
+// after it.
+const claimLease = 5 * time.Minute
+
+// Worker fills in the users queued by AddPendingUser. Lookups failing with
+// ErrUnavailable are tried again with a doubling backoff, users the
+// service does not know are marked models.EnrichmentFailed.
+type Worker struct {
+	db          storage.EnrichmentDatabase
+	enricher    Enricher
+	logger      *zap.SugaredLogger
+	interval    time.Duration
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	maxAttempts int
+}
+
+func NewWorker(cfg configs.EnricherConfig, db storage.EnrichmentDatabase, enricher Enricher, log *zap.SugaredLogger) *Worker {
+	return &Worker{
+		db:          db,
+		enricher:    enricher,
+		logger:      log,
+		interval:    cfg.QueueInterval,
+		backoff:     cfg.QueueBackoff,
+		maxBackoff:  cfg.QueueMaxBackoff,
+		maxAttempts: cfg.QueueMaxAttempts,
+	}
+}
+
+// Run polls the queue until ctx is done. A lookup cut short by ctx is
+// left to the next run.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		w.drain(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain works off the due jobs batch by batch.
+func (w *Worker) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		jobs, err := w.db.ClaimEnrichmentJobs(ctx, time.Now(), claimLease, queueBatch)
+		if err != nil {
+			if ctx.Err() == nil {
+				w.logger.Errorw("cant claim enrichment jobs", "error", err)
+			}
+			return
+		}
+		for _, job := range jobs {
+			w.process(ctx, job)
+		}
+		if len(jobs) < queueBatch {
+			return
+		}
+	}
+}
+
+func (w *Worker) process(ctx context.Context, job models.EnrichmentJob) {
+	info, err := w.enricher.Enrich(ctx, job.PassSerie, job.PassNumber)
+	if ctx.Err() != nil {
+		return
+	}
+	switch {
+	case err == nil:
+		u := &models.User{
+			Id:         job.UserID,
+			Surname:    info.Surname,
+			Name:       info.Name,
+			Patronymic: info.Patronymic,
+			Address:    info.Address,
+		}
+		err = w.db.CompleteEnrichment(ctx, u)
+		if err == nil {
+			w.logger.Infow("user enriched", "user", job.UserID, "attempts", job.Attempts)
+		}
+	case errors.Is(err, ErrUnavailable) && (w.maxAttempts == 0 || job.Attempts < w.maxAttempts):
+		at := time.Now().Add(w.wait(job.Attempts))
+		w.logger.Warnw("enrichment postponed", "user", job.UserID, "attempts", job.Attempts, "until", at, "error", err)
+		err = w.db.RetryEnrichment(ctx, job.UserID, at, err.Error())
+	default:
+		w.logger.Warnw("enrichment failed", "user", job.UserID, "attempts", job.Attempts, "error", err)
+		err = w.db.FailEnrichment(ctx, job.UserID)
+	}
+	switch {
+	case err == nil || ctx.Err() != nil:
+	case errors.Is(err, sql.ErrNoRows):
+		// the user was deleted while it was looked up
+		w.logger.Infow("enrichment job gone", "user", job.UserID)
+	default:
+		w.logger.Errorw("cant update enrichment job", "user", job.UserID, "error", err)
+	}
+}
+
+// wait is the backoff after the given number of attempts.
+func (w *Worker) wait(attempts int) time.Duration {
+	d := w.backoff
+	for i := 1; i < attempts && d < w.maxBackoff; i++ {
+		d *= 2
+	}
+	if d > w.maxBackoff {
+		d = w.maxBackoff
+	}
+	return d
+}
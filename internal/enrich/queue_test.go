@@ -0,0 +1,204 @@
+package enrich
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"time-tracker/configs"
+	"time-tracker/internal/models"
+	"time-tracker/internal/storage"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestMain(m *testing.M) {
+	// the migrations are found relative to the repository root
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// forEachStore runs test against an empty store of every driver usable
+// without a server, SQLite lives in a temporary file.
+func forEachStore(t *testing.T, test func(t *testing.T, db storage.Database)) {
+	for _, driver := range []string{storage.DriverMemory, storage.DriverSQLite} {
+		t.Run(driver, func(t *testing.T) {
+			db, err := storage.New(configs.DatabaseConfig{
+				Driver: driver,
+				Name:   filepath.Join(t.TempDir(), "test.db"),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := db.Connect(); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { db.Disconnect() })
+			test(t, db)
+		})
+	}
+}
+
+func newTestWorker(db storage.Database, enricher Enricher, maxAttempts int) (*Worker, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	w := NewWorker(configs.EnricherConfig{
+		QueueInterval:    time.Hour,
+		QueueBackoff:     time.Millisecond,
+		QueueMaxBackoff:  time.Second,
+		QueueMaxAttempts: maxAttempts,
+	}, db, enricher, zap.New(core).Sugar())
+	return w, logs
+}
+
+func orgCtx() context.Context {
+	return storage.WithOrg(context.Background(), models.DefaultOrganizationID)
+}
+
+func addPendingUser(t *testing.T, db storage.Database) int {
+	t.Helper()
+	u, err := db.AddPendingUser(orgCtx(), &models.User{PassSerie: "1111", PassNumber: "111111"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Id
+}
+
+func enrichmentStatus(t *testing.T, db storage.Database, userID int) string {
+	t.Helper()
+	u, err := db.GetUserByID(orgCtx(), userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.EnrichmentStatus
+}
+
+// queued tells whether any job would be claimed once every lease is over.
+func queued(t *testing.T, db storage.Database) bool {
+	t.Helper()
+	jobs, err := db.ClaimEnrichmentJobs(context.Background(), time.Now().Add(24*time.Hour), claimLease, queueBatch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(jobs) > 0
+}
+
+func TestWorkerWait(t *testing.T) {
+	w := &Worker{backoff: 10 * time.Second, maxBackoff: time.Minute}
+	for _, tt := range []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{3, 40 * time.Second},
+		{4, time.Minute},
+		{50, time.Minute},
+	} {
+		if got := w.wait(tt.attempts); got != tt.want {
+			t.Errorf("wait(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestWorkerCompletes(t *testing.T) {
+	forEachStore(t, func(t *testing.T, db storage.Database) {
+		userID := addPendingUser(t, db)
+		w, _ := newTestWorker(db, &Stub{People: people}, 0)
+
+		w.drain(context.Background())
+		u, err := db.GetUserByID(orgCtx(), userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.EnrichmentStatus != models.EnrichmentDone || u.Surname != "Ivanov" {
+			t.Fatalf("user = %+v, want Ivanov enriched", u)
+		}
+		if queued(t, db) {
+			t.Fatal("job left after the lookup")
+		}
+	})
+}
+
+func TestWorkerFailsUnknownPerson(t *testing.T) {
+	forEachStore(t, func(t *testing.T, db storage.Database) {
+		userID := addPendingUser(t, db)
+		stub := &Stub{People: map[string]Info{}}
+		w, _ := newTestWorker(db, stub, 0)
+
+		w.drain(context.Background())
+		if status := enrichmentStatus(t, db, userID); status != models.EnrichmentFailed {
+			t.Fatalf("status = %q, want %q", status, models.EnrichmentFailed)
+		}
+		if stub.Calls != 1 || queued(t, db) {
+			t.Fatalf("calls = %d, queued = %v, want one call and no job", stub.Calls, queued(t, db))
+		}
+	})
+}
+
+func TestWorkerGivesUpAfterMaxAttempts(t *testing.T) {
+	forEachStore(t, func(t *testing.T, db storage.Database) {
+		userID := addPendingUser(t, db)
+		stub := &Stub{Err: ErrUnavailable}
+		w, _ := newTestWorker(db, stub, 3)
+
+		for attempt := 1; attempt <= 3; attempt++ {
+			if status := enrichmentStatus(t, db, userID); status != models.EnrichmentPending {
+				t.Fatalf("status before attempt %d = %q, want %q", attempt, status, models.EnrichmentPending)
+			}
+			// the backoff after attempt 2 is 2ms
+			time.Sleep(5 * time.Millisecond)
+			w.drain(context.Background())
+		}
+		if stub.Calls != 3 {
+			t.Fatalf("calls = %d, want 3", stub.Calls)
+		}
+		if status := enrichmentStatus(t, db, userID); status != models.EnrichmentFailed {
+			t.Fatalf("status = %q, want %q", status, models.EnrichmentFailed)
+		}
+		if queued(t, db) {
+			t.Fatal("job left after the last attempt")
+		}
+	})
+}
+
+// deleting removes the user it looks up, as if it was deleted through the
+// API while the service was answering.
+type deleting struct {
+	Stub
+	db     storage.Database
+	userID int
+}
+
+func (d *deleting) Enrich(ctx context.Context, serie, number string) (*Info, error) {
+	if err := d.db.DeleteUser(orgCtx(), d.userID); err != nil {
+		return nil, err
+	}
+	return d.Stub.Enrich(ctx, serie, number)
+}
+
+func TestWorkerUserDeletedDuringLookup(t *testing.T) {
+	forEachStore(t, func(t *testing.T, db storage.Database) {
+		userID := addPendingUser(t, db)
+		w, logs := newTestWorker(db, &deleting{Stub: Stub{People: people}, db: db, userID: userID}, 0)
+
+		w.drain(context.Background())
+		if _, err := db.GetUserByID(orgCtx(), userID); err != sql.ErrNoRows {
+			t.Fatalf("err = %v, want %v", err, sql.ErrNoRows)
+		}
+		if queued(t, db) {
+			t.Fatal("job left of the deleted user")
+		}
+		if logs.FilterMessage("enrichment job gone").Len() != 1 {
+			t.Fatalf("logs = %v, want the job reported gone", logs.All())
+		}
+		if n := logs.FilterLevelExact(zapcore.ErrorLevel).Len(); n != 0 {
+			t.Fatalf("%d errors logged, want none", n)
+		}
+	})
+}
@@ -0,0 +1,36 @@
+package enrich
+
+import (
+	"context"
+	"sync"
+)
+
+// Stub answers from memory without a service. A nil People knows
+// everyone and has nothing to tell about them, so users can be created
+// in local runs. Err, when set, is returned by every call.
+type Stub struct {
+	mu sync.Mutex
+	// People maps "serie number" to the person
+	People map[string]Info
+	Err    error
+	// Calls counts the calls made
+	Calls int
+}
+
+func (s *Stub) Enrich(ctx context.Context, serie, number string) (*Info, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Calls++
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	if s.People == nil {
+		return &Info{}, nil
+	}
+	info, ok := s.People[serie+" "+number]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &info, nil
+}
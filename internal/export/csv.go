@@ -0,0 +1,49 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time-tracker/internal/models"
+)
+
+type csvWriter struct {
+	w       *csv.Writer
+	columns []string
+}
+
+func newCSV(w io.Writer, columns []string) (*csvWriter, error) {
+	c := &csvWriter{w: csv.NewWriter(w), columns: columns}
+	if err := c.w.Write(columns); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *csvWriter) Write(e models.TimeEntry) error {
+	record := make([]string, len(c.columns))
+	for n, column := range c.columns {
+		switch v := value(e, column).(type) {
+		case float64:
+			record[n] = strconv.FormatFloat(v, 'f', 2, 64)
+		case string:
+			record[n] = escapeFormula(v)
+		}
+	}
+	return c.w.Write(record)
+}
+
+// escapeFormula quotes a cell a spreadsheet would run as a formula, a
+// description like "=HYPERLINK(...)" stays text.
+func escapeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
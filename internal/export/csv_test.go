@@ -0,0 +1,37 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+	"time-tracker/internal/models"
+)
+
+func TestCSVEscapesFormulas(t *testing.T) {
+	tests := []struct {
+		desc string
+		want string
+	}{
+		{"=HYPERLINK(\"http://x\")", "description\n\"'=HYPERLINK(\"\"http://x\"\")\"\n"},
+		{"+1", "description\n'+1\n"},
+		{"-1", "description\n'-1\n"},
+		{"@SUM(A1)", "description\n'@SUM(A1)\n"},
+		{"review a=b", "description\nreview a=b\n"},
+		{"", "description\n\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		w, err := New(FormatCSV, &buf, []string{ColumnDescription})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Write(models.TimeEntry{Desc: tt.desc}); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("%q exported as %q, want %q", tt.desc, buf.String(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Package export writes time entries as spreadsheets, one row at a time so
+// that large reports are never held in memory.
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time-tracker/internal/models"
+)
+
+// Formats of an export.
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// Columns of an export.
+const (
+	ColumnDate        = "date"
+	ColumnUser        = "user"
+	ColumnProject     = "project"
+	ColumnDescription = "description"
+	ColumnDuration    = "duration"
+)
+
+var DefaultColumns = []string{ColumnDate, ColumnUser, ColumnProject, ColumnDescription, ColumnDuration}
+
+// Writer writes the rows of an export, Close must be called to finish it.
+type Writer interface {
+	Write(e models.TimeEntry) error
+	Close() error
+}
+
+// New starts an export in the given format and writes its header row.
+func New(format string, w io.Writer, columns []string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSV(w, columns)
+	case FormatXLSX:
+		return newXLSX(w, columns)
+	}
+	return nil, fmt.Errorf("unknown export format %q", format)
+}
+
+// ContentType is the media type of the format.
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return ""
+}
+
+// FormatOf picks the format for an Accept header, "" means none of them.
+func FormatOf(accept string) string {
+	for _, mediaType := range strings.Split(accept, ",") {
+		mediaType, _, _ = strings.Cut(mediaType, ";")
+		switch strings.TrimSpace(mediaType) {
+		case "text/csv":
+			return FormatCSV
+		case ContentType(FormatXLSX):
+			return FormatXLSX
+		}
+	}
+	return ""
+}
+
+// ParseColumns parses a comma separated list of columns, an empty list
+// selects DefaultColumns.
+func ParseColumns(list string) ([]string, error) {
+	if strings.TrimSpace(list) == "" {
+		return DefaultColumns, nil
+	}
+	var columns []string
+	for _, column := range strings.Split(list, ",") {
+		column = strings.TrimSpace(column)
+		switch column {
+		case ColumnDate, ColumnUser, ColumnProject, ColumnDescription, ColumnDuration:
+			columns = append(columns, column)
+		default:
+			return nil, fmt.Errorf("unknown column %q", column)
+		}
+	}
+	return columns, nil
+}
+
+// value is the cell of the column, a string or a number. Durations are
+// decimal hours, which is what spreadsheets sum and payroll expects.
+func value(e models.TimeEntry, column string) interface{} {
+	switch column {
+	case ColumnDate:
+		return e.Date
+	case ColumnUser:
+		return e.User
+	case ColumnProject:
+		return e.Project
+	case ColumnDescription:
+		return e.Desc
+	case ColumnDuration:
+		return float64(e.Seconds) / 3600
+	}
+	return ""
+}
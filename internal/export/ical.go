@@ -0,0 +1,84 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"time-tracker/internal/models"
+	"unicode/utf8"
+)
+
+// Calendar writes time entries as an RFC 5545 calendar with an event per
+// entry, Close must be called to finish it.
+type Calendar struct {
+	w     *bufio.Writer
+	stamp string
+}
+
+const icalTime = "20060102T150405Z"
+
+func NewCalendar(w io.Writer, name string, now time.Time) (*Calendar, error) {
+	c := &Calendar{w: bufio.NewWriter(w), stamp: now.UTC().Format(icalTime)}
+	c.line("BEGIN:VCALENDAR")
+	c.line("VERSION:2.0")
+	c.line("PRODID:-//time-tracker//worklogs//EN")
+	c.line("CALSCALE:GREGORIAN")
+	return c, c.line("X-WR-CALNAME:" + icalText(name))
+}
+
+func (c *Calendar) Write(e models.TimeEntry) error {
+	summary := e.Desc
+	if summary == "" {
+		summary = fmt.Sprintf("Task %d", e.TaskID)
+	}
+	var details []string
+	if e.Project != "" {
+		details = append(details, "Project: "+e.Project)
+	}
+	if len(e.Tags) > 0 {
+		details = append(details, "Tags: "+strings.Join(e.Tags, ", "))
+	}
+	if e.Running {
+		details = append(details, "Running")
+	}
+
+	c.line("BEGIN:VEVENT")
+	c.line(fmt.Sprintf("UID:segment-%d@time-tracker", e.SegmentID))
+	c.line("DTSTAMP:" + c.stamp)
+	c.line("DTSTART:" + e.StartTime.UTC().Format(icalTime))
+	c.line("DTEND:" + e.EndTime.UTC().Format(icalTime))
+	c.line("SUMMARY:" + icalText(summary))
+	if len(details) > 0 {
+		c.line("DESCRIPTION:" + icalText(strings.Join(details, "\n")))
+	}
+	return c.line("END:VEVENT")
+}
+
+func (c *Calendar) Close() error {
+	c.line("END:VCALENDAR")
+	return c.w.Flush()
+}
+
+// line writes a content line folded at 75 octets without splitting a
+// UTF-8 sequence. The space leading a continuation counts, so those carry
+// 74 octets of the line.
+func (c *Calendar) line(s string) error {
+	for width := 75; len(s) > width; width = 74 {
+		cut := width
+		for !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		c.w.WriteString(s[:cut] + "\r\n ")
+		s = s[cut:]
+	}
+	_, err := c.w.WriteString(s + "\r\n")
+	return err
+}
+
+var icalEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func icalText(s string) string {
+	return icalEscaper.Replace(s)
+}
@@ -0,0 +1,45 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"time-tracker/internal/models"
+)
+
+func TestCalendarFoldsLines(t *testing.T) {
+	tests := []struct {
+		name string
+		desc string
+	}{
+		{"ascii", strings.Repeat("a", 300)},
+		{"multibyte", strings.Repeat("ж", 150)},
+		{"mixed", "a" + strings.Repeat("€", 100)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+			c, err := NewCalendar(&buf, "Worklogs", start)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := c.Write(models.TimeEntry{Desc: tt.desc, StartTime: start, EndTime: start.Add(time.Hour)}); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.Close(); err != nil {
+				t.Fatal(err)
+			}
+			for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+				if len(line) > 75 {
+					t.Errorf("line of %d octets: %q", len(line), line)
+				}
+			}
+			unfolded := strings.ReplaceAll(buf.String(), "\r\n ", "")
+			if !strings.Contains(unfolded, "\r\nSUMMARY:"+tt.desc+"\r\n") {
+				t.Errorf("summary lost in folding:\n%s", buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"time"
+	"time-tracker/internal/models"
+
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// Timesheet is a month of work of one user prepared for sign-off.
+type Timesheet struct {
+	User    string
+	Month   time.Time
+	Entries []models.TimeEntry
+}
+
+// Widths of the timesheet table columns in mm, they fill an A4 page
+// between 15 mm margins.
+var timesheetWidths = [4]float64{25, 45, 90, 20}
+
+// WriteTimesheet renders the timesheet as a PDF with a table per day,
+// the month total and signature lines. The Go fonts are embedded so any
+// script in names and descriptions prints.
+func WriteTimesheet(w io.Writer, t Timesheet) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes("go", "", goregular.TTF)
+	pdf.AddUTF8FontFromBytes("go", "B", gobold.TTF)
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AddPage()
+
+	pdf.SetFont("go", "B", 16)
+	pdf.CellFormat(0, 10, "Timesheet", "", 1, "L", false, 0, "")
+	pdf.SetFont("go", "", 11)
+	pdf.CellFormat(0, 6, "Employee: "+t.User, "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, fmt.Sprintf("Period: %s (%s)", t.Month.Format("January 2006"), t.Month.Location()), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	pdf.SetFont("go", "B", 10)
+	row(pdf, "B", "Date", "Project", "Description", "Hours")
+	var total, day time.Duration
+	for n, e := range t.Entries {
+		pdf.SetFont("go", "", 10)
+		row(pdf, "", e.Date, e.Project, e.Desc, hours(time.Duration(e.Seconds)*time.Second))
+		day += time.Duration(e.Seconds) * time.Second
+		if n == len(t.Entries)-1 || t.Entries[n+1].Date != e.Date {
+			pdf.SetFont("go", "B", 10)
+			row(pdf, "B", "", "", "Total for "+e.Date, hours(day))
+			total += day
+			day = 0
+		}
+	}
+	pdf.SetFont("go", "B", 11)
+	row(pdf, "TB", "", "", "Total for the month", hours(total))
+
+	pdf.Ln(20)
+	pdf.SetFont("go", "", 11)
+	for _, role := range []string{"Employee", "Approved by"} {
+		pdf.CellFormat(90, 6, role+": ______________________", "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 6, "Date: ______________", "", 1, "L", false, 0, "")
+		pdf.Ln(12)
+	}
+	return pdf.Output(w)
+}
+
+// row prints a table row, cells longer than their column are cut.
+func row(pdf *fpdf.Fpdf, border string, cells ...string) {
+	for n, cell := range cells {
+		align := "L"
+		if n == len(cells)-1 {
+			align = "R"
+		}
+		pdf.CellFormat(timesheetWidths[n], 7, fit(pdf, cell, timesheetWidths[n]-2), border, 0, align, false, 0, "")
+	}
+	pdf.Ln(-1)
+}
+
+func fit(pdf *fpdf.Fpdf, s string, width float64) string {
+	if pdf.GetStringWidth(s) <= width {
+		return s
+	}
+	r := []rune(s)
+	for len(r) > 0 && pdf.GetStringWidth(string(r)+"…") > width {
+		r = r[:len(r)-1]
+	}
+	return string(r) + "…"
+}
+
+// hours formats a duration as h:mm.
+func hours(d time.Duration) string {
+	minutes := int64(d / time.Minute)
+	return fmt.Sprintf("%d:%02d", minutes/60, minutes%60)
+}
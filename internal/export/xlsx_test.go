@@ -0,0 +1,136 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"testing"
+	"time-tracker/internal/models"
+)
+
+// sheetCell is a cell of the worksheet, t is empty for a number.
+type sheetCell struct {
+	Type   string `xml:"t,attr"`
+	Value  string `xml:"v"`
+	Inline string `xml:"is>t"`
+}
+
+// readSheet unpacks the workbook and returns the cells of its sheet, a
+// number as "n:<value>" and a string as is.
+func readSheet(t *testing.T, workbook []byte) [][]string {
+	t.Helper()
+	z, err := zip.NewReader(bytes.NewReader(workbook), int64(len(workbook)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := make(map[string][]byte)
+	for _, f := range z.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[f.Name] = body
+	}
+	for _, part := range xlsxParts {
+		if _, ok := parts[part.name]; !ok {
+			t.Fatalf("workbook misses %s", part.name)
+		}
+	}
+
+	var sheet struct {
+		Rows []struct {
+			Cells []sheetCell `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal(parts["xl/worksheets/sheet1.xml"], &sheet); err != nil {
+		t.Fatal(err)
+	}
+	var rows [][]string
+	for _, row := range sheet.Rows {
+		var cells []string
+		for _, c := range row.Cells {
+			switch c.Type {
+			case "":
+				cells = append(cells, "n:"+c.Value)
+			case "inlineStr":
+				cells = append(cells, c.Inline)
+			default:
+				t.Fatalf("cell of type %q", c.Type)
+			}
+		}
+		rows = append(rows, cells)
+	}
+	return rows
+}
+
+func TestXLSX(t *testing.T) {
+	entries := []models.TimeEntry{
+		{Date: "2024-03-04", User: "Ivanov Ivan", Project: "Billing", Desc: "review <a & b>", Duration: models.Duration{Seconds: 5400}},
+		{Date: "2024-03-05", User: "Ivanov Ivan", Desc: "  standup", Duration: models.Duration{Seconds: 59}},
+	}
+	tests := []struct {
+		name    string
+		columns []string
+		want    [][]string
+	}{
+		{
+			name:    "default columns",
+			columns: DefaultColumns,
+			want: [][]string{
+				{"date", "user", "project", "description", "duration"},
+				{"2024-03-04", "Ivanov Ivan", "Billing", "review <a & b>", "n:1.5"},
+				{"2024-03-05", "Ivanov Ivan", "", "  standup", "n:0.01638888888888889"},
+			},
+		},
+		{
+			name:    "selected columns in the given order",
+			columns: []string{ColumnDuration, ColumnDate},
+			want: [][]string{
+				{"duration", "date"},
+				{"n:1.5", "2024-03-04"},
+				{"n:0.01638888888888889", "2024-03-05"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := New(FormatXLSX, &buf, tt.columns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range entries {
+				if err := w.Write(e); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if got := readSheet(t, buf.Bytes()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sheet = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestXLSXEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := New(FormatXLSX, &buf, []string{ColumnDescription})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readSheet(t, buf.Bytes()), [][]string{{"description"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sheet = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,222 @@
+// Package importer imports the detailed CSV exports of Toggl Track and
+// Clockify as finished tasks.
+package importer
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"time-tracker/internal/models"
+	"time-tracker/internal/storage"
+)
+
+// Sources of an import.
+const (
+	SourceToggl    = "toggl"
+	SourceClockify = "clockify"
+)
+
+// Both trackers name the columns alike, they differ in the date format.
+// Clockify writes dates as configured in its settings, the US default
+// and ISO dates are understood.
+var dateLayouts = map[string][]string{
+	SourceToggl:    {"2006-01-02"},
+	SourceClockify: {"01/02/2006", "2006-01-02"},
+}
+
+var timeLayouts = []string{"15:04:05", "15:04", "03:04:05 PM", "3:04:05 PM", "03:04 PM", "3:04 PM"}
+
+var requiredColumns = []string{"description", "start date", "start time", "end date", "end time"}
+
+// ErrFormat wraps the errors of an export that cannot be read.
+var ErrFormat = errors.New("invalid export")
+
+type Options struct {
+	Source string
+	// Location is the time zone of the export, both trackers write local
+	// times without an offset
+	Location *time.Location
+	// Users maps an email, or a user name when the export has no emails,
+	// to the passport ("1234 567890") of a user. Emails and names that are
+	// passports themselves need no mapping.
+	Users  map[string]string
+	DryRun bool
+}
+
+// Import reads the export from r and imports it into db in one
+// transaction. Rows with issues are listed in the report and stop the
+// whole import. An error means the export could not be read at all.
+func Import(ctx context.Context, db storage.Database, r io.Reader, opts Options) (*models.ImportReport, error) {
+	layouts, ok := dateLayouts[opts.Source]
+	if !ok {
+		return nil, fmt.Errorf("unknown import source %q", opts.Source)
+	}
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot read the header: %v", ErrFormat, err)
+	}
+	columns := make(map[string]int)
+	for n, name := range header {
+		if n == 0 {
+			// Clockify starts its exports with a byte order mark
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		columns[strings.ToLower(strings.TrimSpace(name))] = n
+	}
+	for _, name := range requiredColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%w: column %q is missing", ErrFormat, name)
+		}
+	}
+	_, hasUser := columns["user"]
+	_, hasEmail := columns["email"]
+	if !hasUser && !hasEmail {
+		return nil, fmt.Errorf(`%w: column "email" or "user" is missing`, ErrFormat)
+	}
+
+	report := &models.ImportReport{
+		DryRun:      opts.DryRun,
+		NewClients:  []string{},
+		NewProjects: []string{},
+		NewTags:     []string{},
+		Unmapped:    []models.ImportIssue{},
+		Conflicts:   []models.ImportIssue{},
+		Invalid:     []models.ImportIssue{},
+	}
+	users := &userMap{db: db, users: opts.Users, ids: make(map[string]int)}
+	var tasks []models.ImportTask
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrFormat, err)
+		}
+		line, _ := reader.FieldPos(0)
+		report.Rows++
+		field := func(name string) string {
+			n, ok := columns[name]
+			if !ok || n >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[n])
+		}
+
+		user := field("email")
+		if user == "" {
+			user = field("user")
+		}
+		userID, ok, err := users.resolve(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			report.Unmapped = append(report.Unmapped, models.ImportIssue{Line: line, User: user, Reason: "no user with a mapped passport"})
+			continue
+		}
+		start, err := parseTime(field("start date"), field("start time"), layouts, opts.Location)
+		if err != nil {
+			report.Invalid = append(report.Invalid, models.ImportIssue{Line: line, User: user, Reason: err.Error()})
+			continue
+		}
+		end, err := parseTime(field("end date"), field("end time"), layouts, opts.Location)
+		if err != nil {
+			report.Invalid = append(report.Invalid, models.ImportIssue{Line: line, User: user, Reason: err.Error()})
+			continue
+		}
+		var tags []string
+		if list := field("tags"); list != "" {
+			tags = models.NormalizeTags(strings.Split(list, ","))
+		}
+		tasks = append(tasks, models.ImportTask{
+			Line:      line,
+			User:      user,
+			UserID:    userID,
+			Client:    field("client"),
+			Project:   field("project"),
+			Desc:      field("description"),
+			Tags:      tags,
+			StartTime: start,
+			EndTime:   end,
+		})
+	}
+
+	if err := db.ImportTasks(ctx, tasks, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// ParseUsers parses "email:passport" pairs into Options.Users.
+func ParseUsers(pairs []string) (map[string]string, error) {
+	users := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		user, passport, ok := strings.Cut(pair, ":")
+		if _, _, valid := models.ParsePassport(passport); !ok || !valid {
+			return nil, fmt.Errorf("user mapping %q is not email:passport", pair)
+		}
+		users[strings.TrimSpace(user)] = passport
+	}
+	return users, nil
+}
+
+func parseTime(date, clock string, layouts []string, loc *time.Location) (time.Time, error) {
+	for _, dateLayout := range layouts {
+		for _, timeLayout := range timeLayouts {
+			t, err := time.ParseInLocation(dateLayout+" "+timeLayout, date+" "+clock, loc)
+			if err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse time %q %q", date, clock)
+}
+
+// userMap resolves the users of an export by passport, remembering the
+// answers for the following rows.
+type userMap struct {
+	db    storage.Database
+	users map[string]string
+	ids   map[string]int
+}
+
+func (m *userMap) resolve(ctx context.Context, user string) (int, bool, error) {
+	if id, ok := m.ids[user]; ok {
+		return id, id != 0, nil
+	}
+	passport := user
+	if mapped, ok := m.users[user]; ok {
+		passport = mapped
+	}
+	serie, number, ok := models.ParsePassport(passport)
+	if !ok {
+		m.ids[user] = 0
+		return 0, false, nil
+	}
+	found, err := m.db.GetUsers(ctx, models.GetUsersRequest{
+		PassSerie:      serie,
+		PassportNumber: number,
+		Page:           1,
+		PageSize:       1,
+	})
+	if err != nil {
+		return 0, false, err
+	}
+	for id := range found {
+		m.ids[user] = id
+		return id, true, nil
+	}
+	m.ids[user] = 0
+	return 0, false, nil
+}
@@ -0,0 +1,346 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"time-tracker/configs"
+	"time-tracker/internal/models"
+	"time-tracker/internal/storage"
+)
+
+func TestMain(m *testing.M) {
+	// the migrations are found relative to the repository root
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// forEachStore runs test against an empty store of every driver usable
+// without a server, SQLite lives in a temporary file.
+func forEachStore(t *testing.T, test func(t *testing.T, db storage.Database)) {
+	for _, driver := range []string{storage.DriverMemory, storage.DriverSQLite} {
+		t.Run(driver, func(t *testing.T) {
+			db, err := storage.New(configs.DatabaseConfig{
+				Driver: driver,
+				Name:   filepath.Join(t.TempDir(), "test.db"),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := db.Connect(); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { db.Disconnect() })
+			test(t, db)
+		})
+	}
+}
+
+func orgCtx() context.Context {
+	return storage.WithOrg(context.Background(), models.DefaultOrganizationID)
+}
+
+// addUser stores the user with passport 1234 567890.
+func addUser(t *testing.T, db storage.Database) int {
+	t.Helper()
+	u, err := db.AddUser(orgCtx(), &models.User{PassSerie: "1234", PassNumber: "567890", Surname: "Ivanov"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Id
+}
+
+// imported is a stored segment as compared by the tests.
+type imported struct {
+	Start, End string
+	Project    string
+	Desc       string
+	Tags       []string
+}
+
+// storedEntries lists the segments of the user in 2024 in UTC.
+func storedEntries(t *testing.T, db storage.Database, userID int) []imported {
+	t.Helper()
+	req := &models.GetUserWorklogsRequest{
+		UserID:    userID,
+		StartDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	var entries []imported
+	err := db.EachUserInterval(orgCtx(), req, func(e models.TimeEntry) error {
+		entries = append(entries, imported{
+			Start:   e.StartTime.Format(time.DateTime),
+			End:     e.EndTime.Format(time.DateTime),
+			Project: e.Project,
+			Desc:    e.Desc,
+			Tags:    e.Tags,
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func projectNames(t *testing.T, db storage.Database) []string {
+	t.Helper()
+	projects, err := db.GetProjects(orgCtx(), models.GetProjectsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, p := range projects {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+const togglExport = `User,Email,Client,Project,Task,Description,Billable,Start date,Start time,End date,End time,Duration,Tags,Amount ()
+Ivan Ivanov,ivan@example.com,Acme,Website,,Landing page,Yes,2024-03-04,09:00:00,2024-03-04,10:30:00,01:30:00,"design, Review",
+Ivan Ivanov,ivan@example.com,,,,Standup,No,2024-03-04,23:45:00,2024-03-05,00:15:00,00:30:00,,
+`
+
+// Clockify starts with a byte order mark and writes US dates and times.
+const clockifyExport = "\ufeff" + `Project,Client,Description,Task,User,Group,Email,Tags,Billable,Start Date,Start Time,End Date,End Time,Duration (h),Duration (decimal)
+Website,Acme,Landing page,,Ivan Ivanov,,ivan@example.com,design,Yes,03/04/2024,09:00:00 AM,03/04/2024,10:30:00 AM,01:30:00,1.50
+Website,Acme,Bugfix,,Ivan Ivanov,,ivan@example.com,,Yes,03/04/2024,1:15:00 PM,03/04/2024,2:00:00 PM,00:45:00,0.75
+`
+
+func TestImport(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		source      string
+		export      string
+		loc         *time.Location
+		newProjects []string
+		newTags     []string
+		want        []imported
+	}{
+		{
+			name:        "toggl",
+			source:      SourceToggl,
+			export:      togglExport,
+			loc:         time.UTC,
+			newProjects: []string{"Acme / Website"},
+			newTags:     []string{"design", "review"},
+			want: []imported{
+				{"2024-03-04 09:00:00", "2024-03-04 10:30:00", "Website", "Landing page", []string{"design", "review"}},
+				{"2024-03-04 23:45:00", "2024-03-05 00:15:00", "", "Standup", nil},
+			},
+		},
+		{
+			name:        "clockify",
+			source:      SourceClockify,
+			export:      clockifyExport,
+			loc:         berlin,
+			newProjects: []string{"Acme / Website"},
+			newTags:     []string{"design"},
+			want: []imported{
+				{"2024-03-04 08:00:00", "2024-03-04 09:30:00", "Website", "Landing page", []string{"design"}},
+				{"2024-03-04 12:15:00", "2024-03-04 13:00:00", "Website", "Bugfix", nil},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forEachStore(t, func(t *testing.T, db storage.Database) {
+				userID := addUser(t, db)
+				report, err := Import(orgCtx(), db, strings.NewReader(tt.export), Options{
+					Source:   tt.source,
+					Location: tt.loc,
+					Users:    map[string]string{"ivan@example.com": "1234 567890"},
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !report.Imported || report.Rows != 2 || report.Tasks != 2 || !report.OK() {
+					t.Fatalf("report = %+v, want 2 rows imported", report)
+				}
+				if !reflect.DeepEqual(report.NewClients, []string{"Acme"}) || !reflect.DeepEqual(report.NewProjects, tt.newProjects) {
+					t.Errorf("new clients %v and projects %v, want [Acme] and %v", report.NewClients, report.NewProjects, tt.newProjects)
+				}
+				if !reflect.DeepEqual(report.NewTags, tt.newTags) {
+					t.Errorf("new tags = %v, want %v", report.NewTags, tt.newTags)
+				}
+				if entries := storedEntries(t, db, userID); !reflect.DeepEqual(entries, tt.want) {
+					t.Errorf("entries = %+v, want %+v", entries, tt.want)
+				}
+			})
+		})
+	}
+}
+
+func TestImportIssuesKeepNothing(t *testing.T) {
+	const header = "User,Email,Client,Project,Task,Description,Billable,Start date,Start time,End date,End time,Duration,Tags\n"
+	const valid = "Ivan,ivan@example.com,Acme,Website,,Valid row,Yes,2024-03-04,09:00:00,2024-03-04,10:00:00,01:00:00,new-tag\n"
+	tests := []struct {
+		name   string
+		rows   string
+		issues func(r *models.ImportReport) []models.ImportIssue
+		reason string
+	}{
+		{
+			name:   "malformed date",
+			rows:   valid + "Ivan,ivan@example.com,,,,Bad date,No,04.03.2024,11:00:00,2024-03-04,12:00:00,01:00:00,\n",
+			issues: func(r *models.ImportReport) []models.ImportIssue { return r.Invalid },
+			reason: `cannot parse time "04.03.2024" "11:00:00"`,
+		},
+		{
+			name:   "end before start",
+			rows:   valid + "Ivan,ivan@example.com,,,,Backwards,No,2024-03-04,12:00:00,2024-03-04,11:00:00,,\n",
+			issues: func(r *models.ImportReport) []models.ImportIssue { return r.Invalid },
+		},
+		{
+			name:   "unknown user",
+			rows:   valid + "Petr,petr@example.com,,,,Unmapped,No,2024-03-04,11:00:00,2024-03-04,12:00:00,01:00:00,\n",
+			issues: func(r *models.ImportReport) []models.ImportIssue { return r.Unmapped },
+			reason: "no user with a mapped passport",
+		},
+		{
+			name:   "rows overlapping each other",
+			rows:   valid + "Ivan,ivan@example.com,,,,Overlap,No,2024-03-04,09:30:00,2024-03-04,10:30:00,01:00:00,\n",
+			issues: func(r *models.ImportReport) []models.ImportIssue { return r.Conflicts },
+		},
+		{
+			name:   "row overlapping a stored task",
+			rows:   valid + "Ivan,ivan@example.com,,,,Overlap,No,2024-03-05,09:30:00,2024-03-05,10:30:00,01:00:00,\n",
+			issues: func(r *models.ImportReport) []models.ImportIssue { return r.Conflicts },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forEachStore(t, func(t *testing.T, db storage.Database) {
+				userID := addUser(t, db)
+				start := time.Date(2024, 3, 5, 9, 0, 0, 0, time.UTC)
+				end := start.Add(time.Hour)
+				stored := &models.Task{UserID: userID, StartTime: start, EndTime: &end, Desc: "Stored"}
+				if err := db.AddTask(orgCtx(), stored); err != nil {
+					t.Fatal(err)
+				}
+
+				report, err := Import(orgCtx(), db, strings.NewReader(header+tt.rows), Options{
+					Source: SourceToggl,
+					Users:  map[string]string{"ivan@example.com": "1234 567890"},
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				issues := tt.issues(report)
+				if report.Imported || len(issues) != 1 || issues[0].Line != 3 {
+					t.Fatalf("report = %+v, want nothing imported and an issue on line 3", report)
+				}
+				if tt.reason != "" && issues[0].Reason != tt.reason {
+					t.Errorf("reason = %q, want %q", issues[0].Reason, tt.reason)
+				}
+				// the valid first row is rolled back with everything it created
+				if entries := storedEntries(t, db, userID); len(entries) != 1 || entries[0].Desc != "Stored" {
+					t.Errorf("entries = %+v, want only the stored task", entries)
+				}
+				if names := projectNames(t, db); len(names) != 0 {
+					t.Errorf("projects = %v, want none", names)
+				}
+				tags, err := db.GetTags(orgCtx())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(tags) != 0 {
+					t.Errorf("tags = %v, want none", tags)
+				}
+			})
+		})
+	}
+}
+
+func TestImportDryRun(t *testing.T) {
+	forEachStore(t, func(t *testing.T, db storage.Database) {
+		userID := addUser(t, db)
+		report, err := Import(orgCtx(), db, strings.NewReader(togglExport), Options{
+			Source: SourceToggl,
+			Users:  map[string]string{"ivan@example.com": "1234 567890"},
+			DryRun: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Imported || !report.DryRun || report.Tasks != 2 || !report.OK() {
+			t.Fatalf("report = %+v, want 2 tasks that would be imported", report)
+		}
+		if !reflect.DeepEqual(report.NewProjects, []string{"Acme / Website"}) {
+			t.Errorf("new projects = %v, want the ones that would be created", report.NewProjects)
+		}
+		if entries := storedEntries(t, db, userID); len(entries) != 0 {
+			t.Errorf("entries = %+v, want none", entries)
+		}
+		if names := projectNames(t, db); len(names) != 0 {
+			t.Errorf("projects = %v, want none", names)
+		}
+	})
+}
+
+func TestImportUserByPassport(t *testing.T) {
+	// an export without emails names the users, a passport needs no mapping
+	const export = "User,Description,Start date,Start time,End date,End time\n" +
+		"1234 567890,Passport,2024-03-04,09:00:00,2024-03-04,10:00:00\n"
+	forEachStore(t, func(t *testing.T, db storage.Database) {
+		userID := addUser(t, db)
+		report, err := Import(orgCtx(), db, strings.NewReader(export), Options{Source: SourceToggl})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !report.Imported || report.Tasks != 1 {
+			t.Fatalf("report = %+v, want the row imported", report)
+		}
+		if entries := storedEntries(t, db, userID); len(entries) != 1 {
+			t.Errorf("entries = %+v, want one", entries)
+		}
+	})
+}
+
+func TestImportFormatErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		export string
+	}{
+		{"empty", ""},
+		{"missing column", "Email,Description,Start date,Start time,End date\n"},
+		{"no user column", "Description,Start date,Start time,End date,End time\n"},
+		{"broken quotes", "Email,Description,Start date,Start time,End date,End time\n" + `a@b,"open,2024-03-04,09:00,2024-03-04,10:00` + "\n"},
+	}
+	db := storage.NewMemory()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Import(orgCtx(), db, strings.NewReader(tt.export), Options{Source: SourceToggl})
+			if !errors.Is(err, ErrFormat) {
+				t.Fatalf("err = %v, want %v", err, ErrFormat)
+			}
+		})
+	}
+}
+
+func TestParseUsers(t *testing.T) {
+	users, err := ParseUsers([]string{"ivan@example.com:1234 567890", " petr@example.com :4321098765"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"ivan@example.com": "1234 567890", "petr@example.com": "4321098765"}
+	if !reflect.DeepEqual(users, want) {
+		t.Errorf("users = %v, want %v", users, want)
+	}
+	for _, pair := range []string{"ivan@example.com", "ivan@example.com:12345"} {
+		if _, err := ParseUsers([]string{pair}); err == nil {
+			t.Errorf("ParseUsers(%q) succeeded", pair)
+		}
+	}
+}
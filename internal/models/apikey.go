@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// APIKey is a personal key scripts and integrations authenticate with
+// instead of logging in. The key itself is shown only once, when it is
+// created, only its hash is stored.
+type APIKey struct {
+	ID     int    `json:"id"`
+	UserID int    `json:"user_id"`
+	Name   string `json:"name"`
+	// Prefix is the start of the key, it tells keys apart
+	Prefix     string     `json:"prefix"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// CreateAPIKeyRequest is the body of POST /users/:id/api-keys.
+type CreateAPIKeyRequest struct {
+	Name string `json:"name" binding:"required,max=64"`
+}
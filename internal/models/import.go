@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// ImportTask is a finished task read from the export of another tracker.
+// Client and Project are names, they are created when missing.
+type ImportTask struct {
+	Line      int
+	User      string
+	UserID    int
+	Client    string
+	Project   string
+	Desc      string
+	Tags      []string
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+// ImportIssue is a row of an export that cannot be imported.
+type ImportIssue struct {
+	Line   int    `json:"line"`
+	User   string `json:"user,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// ImportReport tells what an import did or, in a dry run, would do.
+// Nothing is imported when any row has an issue.
+type ImportReport struct {
+	DryRun      bool          `json:"dry_run"`
+	Imported    bool          `json:"imported"`
+	Rows        int           `json:"rows"`
+	Tasks       int           `json:"tasks"`
+	NewClients  []string      `json:"new_clients"`
+	NewProjects []string      `json:"new_projects"`
+	NewTags     []string      `json:"new_tags"`
+	Unmapped    []ImportIssue `json:"unmapped"`
+	Conflicts   []ImportIssue `json:"conflicts"`
+	Invalid     []ImportIssue `json:"invalid"`
+}
+
+// OK reports whether every row can be imported.
+func (r *ImportReport) OK() bool {
+	return len(r.Unmapped) == 0 && len(r.Conflicts) == 0 && len(r.Invalid) == 0
+}
+
+type ImportRequest struct {
+	Source   string `form:"source" binding:"required,oneof=toggl clockify"`
+	TimeZone string `form:"tz"`
+	DryRun   bool   `form:"dry_run"`
+	// Users are email:passport pairs, see importer.Options.Users
+	Users []string `form:"user"`
+}
@@ -0,0 +1,11 @@
+package models
+
+// Organization is a tenant. Users, their tasks, clients, projects and
+// teams belong to exactly one and never see the rows of another.
+type Organization struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// DefaultOrganizationID owns everything created before organizations.
+const DefaultOrganizationID = 1
@@ -0,0 +1,16 @@
+package models
+
+type Client struct {
+	ID   int    `json:"id" db:"id"`
+	Name string `json:"name" db:"name" binding:"required"`
+}
+
+type Project struct {
+	ID       int    `json:"id" db:"id"`
+	ClientID *int   `json:"client_id,omitempty" db:"client_id"`
+	Name     string `json:"name" db:"name" binding:"required"`
+}
+
+type GetProjectsRequest struct {
+	ClientID *int `form:"client_id"`
+}
@@ -0,0 +1,70 @@
+package models
+
+// Role decides what a user may do, see auth.Allow.
+type Role string
+
+const (
+	// RoleAdmin manages users and may do everything.
+	RoleAdmin Role = "admin"
+	// RoleManager reads the reports of their team.
+	RoleManager Role = "manager"
+	// RoleEmployee manages only their own tasks.
+	RoleEmployee Role = "employee"
+)
+
+// Valid reports whether r is one of the known roles.
+func (r Role) Valid() bool {
+	switch r {
+	case RoleAdmin, RoleManager, RoleEmployee:
+		return true
+	}
+	return false
+}
+
+// Credential is the password hash of a user found by passport, empty when
+// the user has no password yet.
+type Credential struct {
+	UserID       int
+	OrgID        int
+	PasswordHash string
+}
+
+// Principal is the authenticated user a request acts for.
+type Principal struct {
+	UserID int  `json:"user_id"`
+	OrgID  int  `json:"org_id"`
+	Role   Role `json:"role"`
+	// Team are the ids of the users whose manager is UserID and of the
+	// members of the teams UserID leads
+	Team []int `json:"team"`
+	// Teams are the ids of the teams UserID leads
+	Teams []int `json:"teams"`
+}
+
+// Manages reports whether userID is in the principal's team.
+func (p *Principal) Manages(userID int) bool {
+	for _, id := range p.Team {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// Leads reports whether the principal leads the team.
+func (p *Principal) Leads(teamID int) bool {
+	for _, id := range p.Teams {
+		if id == teamID {
+			return true
+		}
+	}
+	return false
+}
+
+// SetRoleRequest is the body of PUT /users/:id/role.
+type SetRoleRequest struct {
+	Role Role `json:"role" binding:"required"`
+	// ManagerID puts the user in the team of that manager, null removes
+	// the user from any team
+	ManagerID *int `json:"manager_id"`
+}
@@ -3,22 +3,170 @@ package models
 import "time"
 
 type Task struct {
-	ID        int       `json:"id" db:"id"`
-	UserID    int       `json:"user_id" db:"user_id"`
-	StartTime time.Time `json:"start_time" db:"start_time"`
-	EndTime   time.Time `json:"end_time" db:"end_time"`
-	Desc      string    `json:"description" db:"description"`
+	ID        int        `json:"id" db:"id"`
+	UserID    int        `json:"user_id" db:"user_id"`
+	ProjectID *int       `json:"project_id,omitempty" db:"project_id"`
+	StartTime time.Time  `json:"start_time" db:"start_time"`
+	EndTime   *time.Time `json:"end_time,omitempty" db:"end_time"` // nil while the task is running
+	Desc      string     `json:"description" db:"description"`
+	Tags      []string   `json:"tags,omitempty"`
+	// Segments are the intervals actually worked, a paused task has no open one
+	Segments []TaskSegment `json:"segments,omitempty"`
 }
 
+// Conflict is a pair of overlapping segments of one user, Start and End
+// bound the doubly counted time.
+type Conflict struct {
+	TaskID         int       `json:"task_id"`
+	SegmentID      int       `json:"segment_id"`
+	OtherTaskID    int       `json:"other_task_id"`
+	OtherSegmentID int       `json:"other_segment_id"`
+	Start          time.Time `json:"overlap_start"`
+	End            time.Time `json:"overlap_end"`
+	Seconds        int64     `json:"overlap_seconds"`
+}
+
+// TaskUpdate is a retroactive change of a task, nil fields are kept.
+// StartTime moves the start of the first segment, EndTime the end of
+// the last one and stops the task.
+type TaskUpdate struct {
+	StartTime *time.Time `json:"start_time"`
+	EndTime   *time.Time `json:"end_time"`
+	Desc      *string    `json:"description"`
+}
+
+// TaskSegment is one uninterrupted interval of work on a task.
+type TaskSegment struct {
+	ID        int        `json:"id" db:"id"`
+	TaskID    int        `json:"task_id" db:"task_id"`
+	StartTime time.Time  `json:"start_time" db:"start_time"`
+	EndTime   *time.Time `json:"end_time,omitempty" db:"end_time"`
+}
+
+// Duration sums the segments, counting an open one up to now.
+func (t *Task) Duration(now time.Time) time.Duration {
+	var d time.Duration
+	for _, s := range t.Segments {
+		end := now
+		if s.EndTime != nil {
+			end = *s.EndTime
+		}
+		d += end.Sub(s.StartTime)
+	}
+	return d
+}
+
+// ActiveTask is a running task with the time spent on it so far.
+type ActiveTask struct {
+	Task
+	ElapsedSeconds int64  `json:"elapsed_seconds"`
+	Elapsed        string `json:"elapsed"`
+}
+
+// Duration is a span of time as exact seconds with its normalized
+// breakdown, Minutes is always below 60.
+type Duration struct {
+	Seconds int64 `json:"seconds"`
+	Hours   int64 `json:"hours"`
+	Minutes int64 `json:"minutes"`
+}
+
+func NewDuration(d time.Duration) Duration {
+	seconds := int64(d / time.Second)
+	return Duration{
+		Seconds: seconds,
+		Hours:   seconds / 3600,
+		Minutes: seconds % 3600 / 60,
+	}
+}
+
+// Worklog is the time spent on a task within a report. Running is set when
+// the task has a running segment, it is counted up to now.
 type Worklog struct {
-	TaskID  int    `json:"task_id"`
-	Desc    string `json:"description"`
-	Hours   int    `json:"hours"`
-	Minutes int    `json:"minutes"`
+	TaskID    int      `json:"task_id"`
+	ProjectID *int     `json:"project_id,omitempty"`
+	Desc      string   `json:"description"`
+	Tags      []string `json:"tags,omitempty"`
+	Running   bool     `json:"running,omitempty"`
+	Duration
+}
+
+// WorklogGroup is the time of a report summed by the group_by key.
+type WorklogGroup struct {
+	Key string `json:"key"`
+	Duration
+}
+
+// WorklogReport is a grouped report. Total counts every interval once,
+// even when it falls into several tag groups.
+type WorklogReport struct {
+	GroupBy  string         `json:"group_by"`
+	TimeZone string         `json:"time_zone"`
+	Groups   []WorklogGroup `json:"groups"`
+	Total    Duration       `json:"total"`
+}
+
+// TimeEntry is the part of a task interval within one day of the report
+// time zone, a row of the exports.
+type TimeEntry struct {
+	Date      string    `json:"date"`
+	SegmentID int       `json:"segment_id"`
+	TaskID    int       `json:"task_id"`
+	UserID    int       `json:"user_id"`
+	User      string    `json:"user"`
+	ProjectID *int      `json:"project_id,omitempty"`
+	Project   string    `json:"project"`
+	Desc      string    `json:"description"`
+	Tags      []string  `json:"tags,omitempty"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Running   bool      `json:"running,omitempty"`
+	Duration
 }
 
+// Values of GetUserWorklogsRequest.GroupBy.
+const (
+	// GroupByTag sums time per tag, a task with several tags counts in each
+	// of them and untagged tasks are summed under an empty key.
+	GroupByTag = "tag"
+	// GroupByDay, GroupByWeek and GroupByMonth sum time per calendar bucket
+	// keyed 2006-01-02, 2006-W01 (ISO week) and 2006-01. Intervals crossing
+	// a bucket boundary are split at midnight of the report time zone.
+	GroupByDay   = "day"
+	GroupByWeek  = "week"
+	GroupByMonth = "month"
+	// GroupByTask and GroupByProject key the groups by task and project id,
+	// tasks without a project are summed under an empty key.
+	GroupByTask    = "task"
+	GroupByProject = "project"
+)
+
 type GetUserWorklogsRequest struct {
 	UserID    int       `form:"user_id" binding:"required"`
 	StartDate time.Time `form:"start_date" binding:"required"`
 	EndDate   time.Time `form:"end_date" binding:"required"`
+	ProjectID *int      `form:"project_id"`
+	ClientID  *int      `form:"client_id"`
+	// Tags keeps tasks having any of the tags, ExcludeTags drops them
+	Tags        []string `form:"tag"`
+	ExcludeTags []string `form:"exclude_tag"`
+	GroupBy     string   `form:"group_by" binding:"omitempty,oneof=tag day week month task project"`
+	// TimeZone is an IANA name, the offset of StartDate is used when empty
+	TimeZone string `form:"tz"`
+	// Format overrides the Accept header, Columns picks the export columns
+	Format  string `form:"format" binding:"omitempty,oneof=json csv xlsx"`
+	Columns string `form:"columns"`
+}
+
+type GetTimesheetRequest struct {
+	Month    string `form:"month" binding:"required"`
+	TimeZone string `form:"tz"`
+}
+
+// Location is the time zone the report buckets are computed in.
+func (r *GetUserWorklogsRequest) Location() (*time.Location, error) {
+	if r.TimeZone == "" {
+		return r.StartDate.Location(), nil
+	}
+	return time.LoadLocation(r.TimeZone)
 }
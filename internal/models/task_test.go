@@ -0,0 +1,41 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewDuration(t *testing.T) {
+	tests := []struct {
+		name string
+		d    time.Duration
+		want Duration
+	}{
+		{"zero", 0, Duration{}},
+		{"seconds only", 59 * time.Second, Duration{Seconds: 59}},
+		{"an hour and a half", 5400 * time.Second, Duration{Seconds: 5400, Hours: 1, Minutes: 30}},
+		{"not rounded up", 59*time.Minute + 59*time.Second, Duration{Seconds: 3599, Minutes: 59}},
+		{"fractions dropped", 90*time.Second + 999*time.Millisecond, Duration{Seconds: 90, Minutes: 1}},
+		{"hours past a day", 25*time.Hour + time.Minute, Duration{Seconds: 90060, Hours: 25, Minutes: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewDuration(tt.d); got != tt.want {
+				t.Errorf("NewDuration(%v) = %+v, want %+v", tt.d, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTaskDuration(t *testing.T) {
+	start := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	pause := start.Add(time.Hour)
+	task := Task{Segments: []TaskSegment{
+		{StartTime: start, EndTime: &pause},
+		// the open segment counts up to now
+		{StartTime: pause.Add(30 * time.Minute)},
+	}}
+	if got := task.Duration(pause.Add(45 * time.Minute)); got != time.Hour+15*time.Minute {
+		t.Errorf("Duration = %v, want 1h15m", got)
+	}
+}
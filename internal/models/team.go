@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Team groups users for reports. The lead reads the reports of all
+// members when they are a manager.
+type Team struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name" binding:"required"`
+	LeadID  *int   `json:"lead_id"`
+	Members []int  `json:"members"`
+}
+
+type GetTeamWorklogsRequest struct {
+	StartDate time.Time `form:"start_date" binding:"required"`
+	EndDate   time.Time `form:"end_date" binding:"required"`
+}
+
+// MemberTotal is the time a team member tracked in a team report.
+type MemberTotal struct {
+	UserID int    `json:"user_id"`
+	Name   string `json:"name"`
+	Duration
+}
+
+// ProjectTotal is the time the team tracked on a project, tasks without
+// a project have a nil ProjectID.
+type ProjectTotal struct {
+	ProjectID *int   `json:"project_id"`
+	Project   string `json:"project"`
+	Duration
+}
+
+// TeamReport sums the time of all team members over a range, per member
+// and per project.
+type TeamReport struct {
+	TeamID    int            `json:"team_id"`
+	StartDate time.Time      `json:"start_date"`
+	EndDate   time.Time      `json:"end_date"`
+	Members   []MemberTotal  `json:"members"`
+	Projects  []ProjectTotal `json:"projects"`
+	Total     Duration       `json:"total"`
+}
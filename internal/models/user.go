@@ -1,13 +1,63 @@
 package models
 
+import (
+	"regexp"
+	"strings"
+)
+
+var passportRe = regexp.MustCompile(`^(\d{4})\s*(\d{6})$`)
+
+// ParsePassport splits a passport written as "1234 567890" into its serie
+// and number.
+func ParsePassport(passport string) (serie, number string, ok bool) {
+	match := passportRe.FindStringSubmatch(strings.TrimSpace(passport))
+	if match == nil {
+		return "", "", false
+	}
+	return match[1], match[2], true
+}
+
+// Values of User.EnrichmentStatus.
+const (
+	// EnrichmentDone users have the names and address from the people info service
+	EnrichmentDone = "enriched"
+	// EnrichmentPending users wait for a lookup in the enrichment queue
+	EnrichmentPending = "pending_enrichment"
+	// EnrichmentFailed users are not known to the service, or the lookup
+	// was retried too often
+	EnrichmentFailed = "enrichment_failed"
+)
+
 type User struct {
-	Id         int    `json:"id" db:"id"`
-	PassNumber string `json:"passport_number" db:"passport_number"`
-	PassSerie  string `json:"pass_serie" db:"pass_serie"`
-	Surname    string `json:"surname" db:"surname"`
-	Name       string `json:"name" db:"name"`
-	Patronymic string `json:"patronymic" db:"patronymic"`
-	Address    string `json:"address" db:"address"`
+	Id               int    `json:"id" db:"id"`
+	PassNumber       string `json:"passport_number" db:"passport_number"`
+	PassSerie        string `json:"pass_serie" db:"pass_serie"`
+	Surname          string `json:"surname" db:"surname"`
+	Name             string `json:"name" db:"name"`
+	Patronymic       string `json:"patronymic" db:"patronymic"`
+	Address          string `json:"address" db:"address"`
+	EnrichmentStatus string `json:"enrichment_status" db:"enrichment_status"`
+}
+
+// EnrichmentJob is a queued lookup of the people info of a user.
+type EnrichmentJob struct {
+	UserID     int
+	OrgID      int
+	PassSerie  string
+	PassNumber string
+	// Attempts counts the lookups started, the current one included
+	Attempts int
+}
+
+// FullName joins the non-empty parts of the user's name.
+func (u *User) FullName() string {
+	var parts []string
+	for _, part := range []string{u.Surname, u.Name, u.Patronymic} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, " ")
 }
 
 type GetUsersRequest struct {
@@ -17,6 +67,9 @@ type GetUsersRequest struct {
 	Name           string `form:"name"`
 	Patronymic     string `form:"patronymic"`
 	Address        string `form:"address"`
-	Page           int    `form:"page" binding:"required"`
-	PageSize       int    `form:"page_size" binding:"required"`
+	// EnrichmentStatus finds e.g. the users still pending enrichment
+	EnrichmentStatus string `form:"enrichment_status"`
+	TeamID           *int   `form:"team_id"`
+	Page             int    `form:"page" binding:"required"`
+	PageSize         int    `form:"page_size" binding:"required"`
 }
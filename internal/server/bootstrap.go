@@ -0,0 +1,63 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time-tracker/internal/auth"
+	"time-tracker/internal/models"
+	"time-tracker/internal/storage"
+)
+
+// BootstrapAdmin creates the admin configured by ADMIN_BOOTSTRAP_PASSPORT
+// in the default organization, so a fresh database, the memory one
+// included, has someone who can log in. A user of that passport in the
+// default organization who already has a password is left as is, one
+// without gets the configured password and the admin role.
+func (s *Server) BootstrapAdmin(ctx context.Context) error {
+	if s.cfg.AdminPassport == "" {
+		return nil
+	}
+	serie, number, ok := models.ParsePassport(s.cfg.AdminPassport)
+	if !ok {
+		return fmt.Errorf("invalid ADMIN_BOOTSTRAP_PASSPORT: %q", s.cfg.AdminPassport)
+	}
+	hash, err := auth.HashPassword(s.cfg.AdminPassword)
+	if err != nil {
+		return fmt.Errorf("invalid ADMIN_BOOTSTRAP_PASSWORD: %w", err)
+	}
+
+	creds, err := s.db.GetCredentials(ctx, serie, number)
+	if err != nil {
+		return fmt.Errorf("cant find admin: %w", err)
+	}
+	var existing *models.Credential
+	for i := range creds {
+		if creds[i].OrgID == models.DefaultOrganizationID {
+			existing = &creds[i]
+		}
+	}
+	ctx = storage.WithOrg(ctx, models.DefaultOrganizationID)
+	var id int
+	switch {
+	case existing == nil:
+		u, err := s.db.AddUser(ctx, &models.User{PassSerie: serie, PassNumber: number})
+		if err != nil {
+			return fmt.Errorf("cant add admin: %w", err)
+		}
+		id = u.Id
+	case existing.PasswordHash != "":
+		s.logger.Infow("bootstrap admin already has a password", "user", existing.UserID)
+		return nil
+	default:
+		id = existing.UserID
+	}
+
+	if err := s.db.SetUserPassword(ctx, id, hash); err != nil {
+		return fmt.Errorf("cant set admin password: %w", err)
+	}
+	if err := s.db.SetUserRole(ctx, id, models.RoleAdmin, nil); err != nil {
+		return fmt.Errorf("cant set admin role: %w", err)
+	}
+	s.logger.Infow("bootstrap admin ready", "user", id)
+	return nil
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time-tracker/internal/models"
+)
+
+// login returns the access token of the passport and password.
+func (ts *testServer) login(passport, password string) string {
+	ts.t.Helper()
+	var out struct {
+		AccessToken string `json:"access_token"`
+	}
+	body := map[string]string{"passport_number": passport, "password": password}
+	ts.expect(ts.do("", http.MethodPost, "/login", body), http.StatusOK, &out)
+	return out.AccessToken
+}
+
+func TestBootstrapAdmin(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, ts *testServer) {
+		ts.srv.cfg.AdminPassport = "5555 000001"
+		ts.srv.cfg.AdminPassword = "first password"
+		if err := ts.srv.BootstrapAdmin(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		token := ts.login("5555 000001", "first password")
+		// only admins create users
+		ts.expect(ts.do(token, http.MethodPost, "/create", map[string]string{"passport_number": "1111 111111"}), http.StatusOK, nil)
+
+		// a restart with another password does not take the account over
+		ts.srv.cfg.AdminPassword = "second password"
+		if err := ts.srv.BootstrapAdmin(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		ts.login("5555 000001", "first password")
+	})
+}
+
+func TestBootstrapAdminWithoutPassword(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, ts *testServer) {
+		id, _ := ts.addUser(models.DefaultOrganizationID, models.User{PassSerie: "5555", PassNumber: "000002"}, models.RoleEmployee)
+		ts.srv.cfg.AdminPassport = "5555 000002"
+		ts.srv.cfg.AdminPassword = "a long password"
+		if err := ts.srv.BootstrapAdmin(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		ts.login("5555 000002", "a long password")
+		p, err := ts.db.GetPrincipal(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Role != models.RoleAdmin {
+			t.Fatalf("role %q, want admin", p.Role)
+		}
+	})
+}
+
+func TestBootstrapAdminPassportOfAnotherOrganization(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, ts *testServer) {
+		other := models.Organization{Name: "Other"}
+		if err := ts.db.AddOrganization(context.Background(), &other); err != nil {
+			t.Fatal(err)
+		}
+		otherID, _ := ts.addUser(other.ID, models.User{PassSerie: "5555", PassNumber: "000003"}, models.RoleEmployee)
+		ts.srv.cfg.AdminPassport = "5555 000003"
+		ts.srv.cfg.AdminPassword = "a long password"
+		if err := ts.srv.BootstrapAdmin(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		// the admin is a new user of the default organization
+		creds, err := ts.db.GetCredentials(context.Background(), "5555", "000003")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(creds) != 2 || creds[0].UserID != otherID || creds[0].PasswordHash != "" || creds[1].OrgID != models.DefaultOrganizationID {
+			t.Fatalf("credentials = %+v, want the other user untouched and an admin in the default organization", creds)
+		}
+		p, err := ts.db.GetPrincipal(context.Background(), otherID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Role != models.RoleEmployee {
+			t.Fatalf("role of the other user %q, want employee", p.Role)
+		}
+		ts.login("5555 000003", "a long password")
+	})
+}
+
+func TestBootstrapAdminInvalidConfig(t *testing.T) {
+	tests := []struct {
+		passport string
+		password string
+	}{
+		{"5555", "a long password"},
+		{"5555 000003", "short"},
+	}
+	ts := newTestServer(t, drivers[0])
+	for _, tt := range tests {
+		ts.srv.cfg.AdminPassport, ts.srv.cfg.AdminPassword = tt.passport, tt.password
+		if err := ts.srv.BootstrapAdmin(context.Background()); err == nil {
+			t.Errorf("bootstrapped %q with %q", tt.passport, tt.password)
+		}
+	}
+}
@@ -1,13 +1,15 @@
 package server
 
 import (
-	"bytes"
 	"database/sql"
-	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
-	"strings"
+	"time"
+	"time-tracker/configs"
+	"time-tracker/internal/auth"
+	"time-tracker/internal/enrich"
 	"time-tracker/internal/models"
 	"time-tracker/internal/utils"
 
@@ -15,16 +17,63 @@ import (
 )
 
 func (s *Server) prepareRoutes() {
-	s.r.Handle(http.MethodGet, "/users", s.getUsersHandler)
-	s.r.Handle(http.MethodGet, "/users/tasks", s.getUserTasksHandler)
-	s.r.Handle(http.MethodPost, "/create", s.createUserHandler)
-	s.r.Handle(http.MethodDelete, "/users/:id", s.deleteUserHandler)
-	s.r.Handle(http.MethodPut, "/users/:id", s.updateUserHandler)
-	s.r.Handle(http.MethodPost, "/tasks/start", s.startTaskHandler)
-	s.r.Handle(http.MethodPost, "/tasks/:id/stop", s.stopTaskHandler)
+	s.r.Handle(http.MethodPost, "/login", s.loginHandler)
+	// calendar clients cannot send a bearer token, the feed checks its own
+	s.r.Handle(http.MethodGet, "/users/:id/calendar.ics", s.getCalendarHandler)
+
+	r := s.r.Group("/", s.authMiddleware)
+	r.Handle(http.MethodGet, "/users", s.getUsersHandler)
+	r.Handle(http.MethodGet, "/users/tasks", s.getUserTasksHandler)
+	r.Handle(http.MethodPost, "/create", s.createUserHandler)
+	r.Handle(http.MethodDelete, "/users/:id", s.deleteUserHandler)
+	r.Handle(http.MethodPut, "/users/:id", s.updateUserHandler)
+	r.Handle(http.MethodPut, "/users/:id/password", s.setPasswordHandler)
+	r.Handle(http.MethodPut, "/users/:id/role", s.setRoleHandler)
+	r.Handle(http.MethodPost, "/users/:id/api-keys", s.createAPIKeyHandler)
+	r.Handle(http.MethodGet, "/users/:id/api-keys", s.getAPIKeysHandler)
+	r.Handle(http.MethodDelete, "/users/:id/api-keys/:key_id", s.deleteAPIKeyHandler)
+	r.Handle(http.MethodGet, "/users/:id/tasks/active", s.getActiveTaskHandler)
+	r.Handle(http.MethodGet, "/users/:id/conflicts", s.getUserConflictsHandler)
+	r.Handle(http.MethodGet, "/users/:id/timesheet.pdf", s.getTimesheetHandler)
+	r.Handle(http.MethodPost, "/users/:id/calendar/token", s.createCalendarTokenHandler)
+	r.Handle(http.MethodPost, "/tasks/start", s.startTaskHandler)
+	r.Handle(http.MethodPost, "/tasks/:id/stop", s.stopTaskHandler)
+	r.Handle(http.MethodPost, "/tasks/:id/pause", s.pauseTaskHandler)
+	r.Handle(http.MethodPost, "/tasks/:id/resume", s.resumeTaskHandler)
+	r.Handle(http.MethodPut, "/tasks/:id/tags", s.setTaskTagsHandler)
+	r.Handle(http.MethodPost, "/tasks", s.createTaskHandler)
+	r.Handle(http.MethodGet, "/tasks/:id", s.getTaskHandler)
+	r.Handle(http.MethodPatch, "/tasks/:id", s.updateTaskHandler)
+	r.Handle(http.MethodDelete, "/tasks/:id", s.deleteTaskHandler)
+	r.Handle(http.MethodGet, "/tags", s.getTagsHandler)
+	r.Handle(http.MethodPost, "/import", s.importHandler)
+	r.Handle(http.MethodGet, "/enrichment/cache", s.getEnrichmentCacheHandler)
+
+	r.Handle(http.MethodPost, "/clients", s.createClientHandler)
+	r.Handle(http.MethodGet, "/clients", s.getClientsHandler)
+	r.Handle(http.MethodGet, "/clients/:id", s.getClientHandler)
+	r.Handle(http.MethodPut, "/clients/:id", s.updateClientHandler)
+	r.Handle(http.MethodDelete, "/clients/:id", s.deleteClientHandler)
+	r.Handle(http.MethodPost, "/projects", s.createProjectHandler)
+	r.Handle(http.MethodGet, "/projects", s.getProjectsHandler)
+	r.Handle(http.MethodGet, "/projects/:id", s.getProjectHandler)
+	r.Handle(http.MethodPut, "/projects/:id", s.updateProjectHandler)
+	r.Handle(http.MethodDelete, "/projects/:id", s.deleteProjectHandler)
+
+	r.Handle(http.MethodPost, "/teams", s.createTeamHandler)
+	r.Handle(http.MethodGet, "/teams", s.getTeamsHandler)
+	r.Handle(http.MethodGet, "/teams/:id", s.getTeamHandler)
+	r.Handle(http.MethodPut, "/teams/:id", s.updateTeamHandler)
+	r.Handle(http.MethodDelete, "/teams/:id", s.deleteTeamHandler)
+	r.Handle(http.MethodPut, "/teams/:id/members/:user_id", s.addTeamMemberHandler)
+	r.Handle(http.MethodDelete, "/teams/:id/members/:user_id", s.removeTeamMemberHandler)
+	r.Handle(http.MethodGet, "/teams/:id/worklogs", s.getTeamWorklogsHandler)
 }
 
 func (s *Server) getUsersHandler(ctx *gin.Context) {
+	if !s.allow(ctx, auth.ListUsers, 0) {
+		return
+	}
 	var req models.GetUsersRequest
 
 	if err := ctx.ShouldBindQuery(&req); err != nil {
@@ -33,7 +82,7 @@ func (s *Server) getUsersHandler(ctx *gin.Context) {
 		return
 	}
 	s.logger.Debugw("getUsersHandler", "req", req)
-	users, err := s.db.GetUsers(req)
+	users, err := s.db.GetUsers(ctx.Request.Context(), req)
 	if err != nil {
 		s.logger.Errorw("getUsersHandler", "err", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"err": err})
@@ -56,8 +105,30 @@ func (s *Server) getUserTasksHandler(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"err": "invalid query parameters"})
 		return
 	}
+	if !s.allow(ctx, auth.ReadUserData, req.UserID) {
+		return
+	}
+	req.Tags = models.NormalizeTags(req.Tags)
+	req.ExcludeTags = models.NormalizeTags(req.ExcludeTags)
 	s.logger.Debugw("getUserTasksHandler", "req: ", req)
-	worklogs, err := s.db.GetUserWorklogs(&req)
+	if format := exportFormat(ctx, req.Format); format != "" {
+		s.exportWorklogs(ctx, &req, format)
+		return
+	}
+	if req.GroupBy != "" {
+		if _, err := req.Location(); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"err": "unknown time zone"})
+			return
+		}
+		report, err := s.db.GetUserWorklogGroups(ctx.Request.Context(), &req)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"err": "failed to get worklogs"})
+			return
+		}
+		ctx.JSON(http.StatusOK, report)
+		return
+	}
+	worklogs, err := s.db.GetUserWorklogs(ctx.Request.Context(), &req)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"err": "failed to get worklogs"})
 		return
@@ -68,70 +139,124 @@ func (s *Server) getUserTasksHandler(ctx *gin.Context) {
 }
 
 func (s *Server) createUserHandler(ctx *gin.Context) {
+	if !s.allow(ctx, auth.ManageUsers, 0) {
+		return
+	}
 	var input struct {
 		PassportNumber string `json:"passport_number"`
+		Password       string `json:"password"`
 	}
 
 	if err := ctx.ShouldBindJSON(&input); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
 		return
 	}
+	var passwordHash string
+	if input.Password != "" {
+		hash, err := auth.HashPassword(input.Password)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+			return
+		}
+		passwordHash = hash
+	}
 	s.logger.Debugw("createUserHandler", "passport_number", input.PassportNumber)
-	reqBody, err := json.Marshal(map[string]string{
-		"passport_number": input.PassportNumber,
-	})
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"err": "failed to marshal request"})
+	serie, number, ok := models.ParsePassport(input.PassportNumber)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": "passport_number must look like 1234 567890"})
 		return
 	}
-	// external api call (just for example)
-	resp, err := http.Post("http://localhost:8081/info", "application/json", bytes.NewBuffer(reqBody))
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"err": "failed to call external API"})
+	if s.cfg.UserEnrichment == configs.UserEnrichmentAsync {
+		s.createPendingUser(ctx, serie, number, passwordHash)
 		return
 	}
-	defer resp.Body.Close()
-	s.logger.Debugw("createUserHandler", "resp", resp.Body)
-	if resp.StatusCode == http.StatusBadRequest {
-		ctx.JSON(http.StatusBadRequest, gin.H{"err": "bad request"})
+	info, err := s.enricher.Enrich(ctx.Request.Context(), serie, number)
+	if err != nil {
+		switch {
+		case errors.Is(err, enrich.ErrNotFound), errors.Is(err, enrich.ErrRejected):
+			ctx.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		case errors.Is(err, enrich.ErrUnavailable):
+			s.logger.Warnw("people info service unavailable", "error", err)
+			ctx.JSON(http.StatusServiceUnavailable, gin.H{"err": enrich.ErrUnavailable.Error()})
+		default:
+			s.logger.Errorln("failed to enrich user, error: ", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		}
 		return
 	}
-	if resp.StatusCode == http.StatusInternalServerError {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"err": "internal server error"})
+	newUser := &models.User{
+		PassNumber: number,
+		PassSerie:  serie,
+		Surname:    info.Surname,
+		Name:       info.Name,
+		Patronymic: info.Patronymic,
+		Address:    info.Address,
+	}
+	s.logger.Debugw("createUserHandler", "full user", newUser)
+	u, err := s.db.AddUser(ctx.Request.Context(), newUser)
+	if err != nil {
+		s.addUserError(ctx, err)
 		return
 	}
-	if resp.StatusCode == http.StatusNotFound {
-		ctx.JSON(http.StatusBadRequest, gin.H{"err": "user with these passport data not found in database"})
+	if passwordHash != "" {
+		if err := s.db.SetUserPassword(ctx.Request.Context(), u.Id, passwordHash); err != nil {
+			s.logger.Errorln("cant set user password, error: ", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+			return
+		}
+	}
+	s.logger.Infow("user successfully added to db", "user", u)
+	ctx.JSON(http.StatusOK, u)
+}
+
+// createPendingUser stores the user without people info and leaves the
+// lookup to the enrichment queue.
+func (s *Server) createPendingUser(ctx *gin.Context, serie, number, passwordHash string) {
+	u, err := s.db.AddPendingUser(ctx.Request.Context(), &models.User{PassNumber: number, PassSerie: serie})
+	if err != nil {
+		s.addUserError(ctx, err)
 		return
 	}
+	if passwordHash != "" {
+		if err := s.db.SetUserPassword(ctx.Request.Context(), u.Id, passwordHash); err != nil {
+			s.logger.Errorln("cant set user password, error: ", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+			return
+		}
+	}
+	s.logger.Infow("user added to db, enrichment queued", "user", u)
+	ctx.JSON(http.StatusAccepted, u)
+}
 
-	var apiResponse models.User
-	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
-		s.logger.Errorw("createUserHandler", "cant decode resp", resp.Body)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"err": "failed to decode API response"})
+// addUserError answers a failed AddUser or AddPendingUser.
+func (s *Server) addUserError(ctx *gin.Context, err error) {
+	if errors.Is(err, utils.ErrAlreadyExists) {
+		ctx.JSON(http.StatusConflict, gin.H{"err": "a user with this passport already exists"})
 		return
 	}
-	passport := strings.Fields(input.PassportNumber)
-	newUser := &models.User{
-		PassNumber: passport[1],
-		PassSerie:  passport[0],
-		Surname:    apiResponse.Surname,
-		Name:       apiResponse.Name,
-		Patronymic: apiResponse.Patronymic,
-		Address:    apiResponse.Address,
+	s.logger.Errorln("cant add user to db, error: ", err)
+	ctx.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+}
+
+// getEnrichmentCacheHandler reports how often the people info service was
+// spared by the cache. The cache serves the whole process, so the counters
+// include the lookups of every organization; they hold no passports.
+func (s *Server) getEnrichmentCacheHandler(ctx *gin.Context) {
+	if !s.allow(ctx, auth.ManageUsers, 0) {
+		return
 	}
-	s.logger.Debugw("createUserHandler", "full user", newUser)
-	u, err := s.db.AddUser(newUser)
-	if err != nil {
-		s.logger.Errorln("cant add user to db, error: ", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+	cache, ok := s.enricher.(*enrich.Cache)
+	if !ok {
+		ctx.JSON(http.StatusNotFound, gin.H{"err": "enrichment cache is disabled"})
 		return
 	}
-	s.logger.Infow("user successfully added to db", "user", u)
-	ctx.JSON(http.StatusOK, u)
+	ctx.JSON(http.StatusOK, cache.Stats())
 }
 
 func (s *Server) deleteUserHandler(ctx *gin.Context) {
+	if !s.allow(ctx, auth.ManageUsers, 0) {
+		return
+	}
 	id_param := ctx.Param("id")
 	s.logger.Debugw("deleteUserHandler", "id", id_param)
 	id, err := strconv.Atoi(id_param)
@@ -140,7 +265,11 @@ func (s *Server) deleteUserHandler(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"err": "id is not a number"})
 		return
 	}
-	err = s.db.DeleteUser(id)
+	err = s.db.DeleteUser(ctx.Request.Context(), id)
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{"err": "User not found"})
+		return
+	}
 	if err != nil {
 		s.logger.Debugw("deleteUserHandler", "cant delete user with err", err)
 		ctx.JSON(http.StatusBadRequest, gin.H{"res": "cant delete", "err": err.Error()})
@@ -151,6 +280,9 @@ func (s *Server) deleteUserHandler(ctx *gin.Context) {
 }
 
 func (s *Server) updateUserHandler(ctx *gin.Context) {
+	if !s.allow(ctx, auth.ManageUsers, 0) {
+		return
+	}
 	var updateUserInput struct {
 		Surname    *string `json:"surname,omitempty"`
 		Name       *string `json:"name,omitempty"`
@@ -166,8 +298,9 @@ func (s *Server) updateUserHandler(ctx *gin.Context) {
 	id, err := strconv.Atoi(id_param)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		return
 	}
-	u, err := s.db.GetUserByID(id)
+	u, err := s.db.GetUserByID(ctx.Request.Context(), id)
 	s.logger.Debugw("getUserByID", "user", u)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -193,7 +326,12 @@ func (s *Server) updateUserHandler(ctx *gin.Context) {
 		u.Address = *updateUserInput.Address
 	}
 	s.logger.Debugln("full new user info", "user", u)
-	err = s.db.UpdateUser(u)
+	err = s.db.UpdateUser(ctx.Request.Context(), u)
+	if err == sql.ErrNoRows {
+		// deleted since it was read
+		ctx.JSON(http.StatusNotFound, gin.H{"err": "User not found"})
+		return
+	}
 	if err != nil {
 		s.logger.Errorln("cant update user, error: ", err.Error())
 		ctx.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
@@ -205,8 +343,9 @@ func (s *Server) updateUserHandler(ctx *gin.Context) {
 
 func (s *Server) startTaskHandler(ctx *gin.Context) {
 	var input struct {
-		UserID int    `json:"user_id"`
-		Desc   string `json:"description"`
+		ProjectID *int     `json:"project_id"`
+		Desc      string   `json:"description"`
+		Tags      []string `json:"tags"`
 	}
 
 	if err := ctx.ShouldBindJSON(&input); err != nil {
@@ -215,34 +354,148 @@ func (s *Server) startTaskHandler(ctx *gin.Context) {
 	}
 	s.logger.Debugw("startTaskHandler", "input_task", input)
 	task := &models.Task{
-		UserID: input.UserID,
-		Desc:   input.Desc,
+		UserID:    actingUser(ctx),
+		ProjectID: input.ProjectID,
+		Desc:      input.Desc,
+		Tags:      models.NormalizeTags(input.Tags),
 	}
-	err := s.db.AddStartTask(task)
+	stopRunning := s.cfg.TaskStartPolicy == configs.TaskStartStopRunning
+	err := s.db.AddStartTask(ctx.Request.Context(), task, stopRunning)
 	if err != nil {
-		s.logger.Errorln("failed to add task to db, error: ", err.Error())
-		ctx.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		s.taskError(ctx, "failed to add task to db", err)
 		return
 	}
 	s.logger.Infoln("successfully start task")
-	ctx.JSON(http.StatusOK, gin.H{"res": "task started"})
+	ctx.JSON(http.StatusOK, gin.H{"res": "task started", "task": task})
 
 }
 
 func (s *Server) stopTaskHandler(ctx *gin.Context) {
+	id, ok := s.allowTask(ctx, auth.WriteUserData)
+	if !ok {
+		return
+	}
+	s.logger.Debugw("stopTaskHandler", "id", id)
+	err := s.db.AddEndTask(ctx.Request.Context(), id)
+	if err != nil {
+		s.taskError(ctx, "failed to end task", err)
+		return
+	}
+	s.logger.Infoln("successfully stopped task")
+	ctx.JSON(http.StatusOK, gin.H{"res": "task ended"})
+}
+
+func (s *Server) pauseTaskHandler(ctx *gin.Context) {
+	id, ok := s.allowTask(ctx, auth.WriteUserData)
+	if !ok {
+		return
+	}
+	s.logger.Debugw("pauseTaskHandler", "id", id)
+	err := s.db.PauseTask(ctx.Request.Context(), id)
+	if err != nil {
+		s.taskError(ctx, "failed to pause task", err)
+		return
+	}
+	s.logger.Infoln("successfully paused task")
+	ctx.JSON(http.StatusOK, gin.H{"res": "task paused"})
+}
+
+func (s *Server) resumeTaskHandler(ctx *gin.Context) {
+	id, ok := s.allowTask(ctx, auth.WriteUserData)
+	if !ok {
+		return
+	}
+	s.logger.Debugw("resumeTaskHandler", "id", id)
+	stopRunning := s.cfg.TaskStartPolicy == configs.TaskStartStopRunning
+	err := s.db.ResumeTask(ctx.Request.Context(), id, stopRunning)
+	if err != nil {
+		s.taskError(ctx, "failed to resume task", err)
+		return
+	}
+	s.logger.Infoln("successfully resumed task")
+	ctx.JSON(http.StatusOK, gin.H{"res": "task resumed"})
+}
+
+func (s *Server) setTaskTagsHandler(ctx *gin.Context) {
+	var input struct {
+		Tags []string `json:"tags"`
+	}
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		return
+	}
+	id, ok := s.allowTask(ctx, auth.WriteUserData)
+	if !ok {
+		return
+	}
+	tags := models.NormalizeTags(input.Tags)
+	s.logger.Debugw("setTaskTagsHandler", "id", id, "tags", tags)
+	err := s.db.SetTaskTags(ctx.Request.Context(), id, tags)
+	if err != nil {
+		s.taskError(ctx, "failed to set task tags", err)
+		return
+	}
+	s.logger.Infoln("successfully updated task tags")
+	ctx.JSON(http.StatusOK, gin.H{"res": "tags updated", "tags": tags})
+}
+
+func (s *Server) getTagsHandler(ctx *gin.Context) {
+	// the tags come from the tasks of everyone in the organization
+	if !s.allow(ctx, auth.ListUsers, 0) {
+		return
+	}
+	tags, err := s.db.GetTags(ctx.Request.Context())
+	if err != nil {
+		s.logger.Errorln("failed to get tags, error: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+// taskError answers with the status matching a task state error.
+func (s *Server) taskError(ctx *gin.Context, msg string, err error) {
+	switch {
+	case err == sql.ErrNoRows:
+		ctx.JSON(http.StatusNotFound, gin.H{"err": "task not found"})
+	case errors.Is(err, utils.ErrProjectNotFound), isEntryError(err):
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+	case errors.Is(err, utils.ErrTaskAlreadyRunning),
+		errors.Is(err, utils.ErrTaskNotRunning),
+		errors.Is(err, utils.ErrTaskStopped),
+		errors.Is(err, utils.ErrOverlap):
+		ctx.JSON(http.StatusConflict, gin.H{"err": err.Error()})
+	default:
+		s.logger.Errorln(msg+", error: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+	}
+}
+
+func (s *Server) getActiveTaskHandler(ctx *gin.Context) {
 	id_param := ctx.Param("id")
-	s.logger.Debugw("stopTaskHandler", "id", id_param)
+	s.logger.Debugw("getActiveTaskHandler", "id", id_param)
 	id, err := strconv.Atoi(id_param)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"err": "incorrect task id"})
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": "id is not a number"})
+		return
+	}
+	if !s.allow(ctx, auth.ReadUserData, id) {
 		return
 	}
-	err = s.db.AddEndTask(id)
+	task, err := s.db.GetActiveTask(ctx.Request.Context(), id)
 	if err != nil {
-		s.logger.Errorln("failed to end task, error: ", err.Error())
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"err": "no running task"})
+			return
+		}
+		s.logger.Errorln("failed to get active task, error: ", err.Error())
 		ctx.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
 		return
 	}
-	s.logger.Infoln("successfully stopped task")
-	ctx.JSON(http.StatusOK, gin.H{"res": "task ended"})
+	elapsed := task.Duration(time.Now()).Truncate(time.Second)
+	ctx.JSON(http.StatusOK, models.ActiveTask{
+		Task:           *task,
+		ElapsedSeconds: int64(elapsed.Seconds()),
+		Elapsed:        elapsed.String(),
+	})
 }
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time-tracker/internal/auth"
+	"time-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// createAPIKeyHandler issues a new API key of the user. The key is in the
+// response only, it cannot be shown again. It takes a login, a leaked key
+// must not be able to mint keys that outlive its revocation.
+func (s *Server) createAPIKeyHandler(ctx *gin.Context) {
+	if byAPIKey(ctx) {
+		ctx.JSON(http.StatusForbidden, gin.H{"err": "api keys are created with an access token"})
+		return
+	}
+	var req models.CreateAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		return
+	}
+	id, ok := s.idParam(ctx)
+	if !ok || !s.allow(ctx, auth.WriteUserData, id) {
+		return
+	}
+	key, prefix, err := auth.NewAPIKey()
+	if err != nil {
+		s.logger.Errorln("failed to generate api key, error: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{"err": "failed to generate key"})
+		return
+	}
+	apiKey := models.APIKey{UserID: id, Name: req.Name, Prefix: prefix}
+	if err := s.db.AddAPIKey(ctx.Request.Context(), &apiKey, auth.HashAPIKey(key)); err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"err": "user not found"})
+			return
+		}
+		s.logger.Errorln("failed to save api key, error: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	s.logger.Infow("api key created", "user", id, "key", apiKey.ID)
+	ctx.JSON(http.StatusCreated, gin.H{"key": key, "api_key": apiKey})
+}
+
+func (s *Server) getAPIKeysHandler(ctx *gin.Context) {
+	id, ok := s.idParam(ctx)
+	if !ok || !s.allow(ctx, auth.WriteUserData, id) || !s.userFound(ctx, id) {
+		return
+	}
+	keys, err := s.db.GetAPIKeys(ctx.Request.Context(), id)
+	if err != nil {
+		s.logger.Errorln("failed to get api keys, error: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"api_keys": keys})
+}
+
+// deleteAPIKeyHandler revokes a key, requests with it fail right away.
+func (s *Server) deleteAPIKeyHandler(ctx *gin.Context) {
+	id, ok := s.idParam(ctx)
+	if !ok || !s.allow(ctx, auth.WriteUserData, id) {
+		return
+	}
+	keyID, err := strconv.Atoi(ctx.Param("key_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": "key_id is not a number"})
+		return
+	}
+	if err := s.db.DeleteAPIKey(ctx.Request.Context(), id, keyID); err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"err": "api key not found"})
+			return
+		}
+		s.logger.Errorln("failed to delete api key, error: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	s.logger.Infow("api key revoked", "user", id, "key", keyID)
+	ctx.JSON(http.StatusOK, gin.H{"res": "api key revoked"})
+}
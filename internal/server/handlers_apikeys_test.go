@@ -0,0 +1,23 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time-tracker/internal/models"
+)
+
+func TestCreateAPIKeyNeedsAccessToken(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, ts *testServer) {
+		id, token := ts.addUser(models.DefaultOrganizationID, admin, models.RoleAdmin)
+		path := "/users/" + itoa(id) + "/api-keys"
+
+		var created struct {
+			Key string `json:"key"`
+		}
+		ts.expect(ts.do(token, http.MethodPost, path, map[string]string{"name": "script"}), http.StatusCreated, &created)
+
+		// the key works for everything else
+		ts.expect(ts.do(created.Key, http.MethodGet, path, nil), http.StatusOK, nil)
+		ts.expect(ts.do(created.Key, http.MethodPost, path, map[string]string{"name": "another"}), http.StatusForbidden, nil)
+	})
+}
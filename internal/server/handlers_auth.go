@@ -0,0 +1,241 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"time-tracker/internal/auth"
+	"time-tracker/internal/models"
+	"time-tracker/internal/storage"
+	"time-tracker/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// principalKey is the gin context key holding the authenticated principal.
+const principalKey = "principal"
+
+// apiKeyAuthKey is set in the gin context when an API key, not an access
+// token, authenticated the request.
+const apiKeyAuthKey = "api_key_auth"
+
+func (s *Server) loginHandler(ctx *gin.Context) {
+	var input struct {
+		PassportNumber string `json:"passport_number" binding:"required"`
+		Password       string `json:"password" binding:"required"`
+		// OrganizationID picks the user of a passport known in several
+		// organizations with the same password
+		OrganizationID int `json:"organization_id"`
+	}
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		return
+	}
+	serie, number, ok := models.ParsePassport(input.PassportNumber)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": "passport_number must look like 1234 567890"})
+		return
+	}
+	creds, err := s.db.GetCredentials(ctx.Request.Context(), serie, number)
+	if err != nil {
+		s.logger.Errorln("failed to get password hash, error: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	// passports are unique per organization, the password tells the users
+	// of one apart
+	var matched []int
+	for _, c := range creds {
+		if input.OrganizationID != 0 && c.OrgID != input.OrganizationID {
+			continue
+		}
+		if auth.CheckPassword(c.PasswordHash, input.Password) {
+			matched = append(matched, c.UserID)
+		}
+	}
+	// unknown users, users without a password and wrong passwords look the same
+	if len(matched) == 0 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"err": "invalid credentials"})
+		return
+	}
+	if len(matched) > 1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": "the credentials fit several organizations, set organization_id"})
+		return
+	}
+	id := matched[0]
+	token, expires, err := auth.NewToken(s.cfg.JWTSecret, id, time.Now(), s.cfg.TokenTTL)
+	if err != nil {
+		s.logger.Errorln("failed to sign token, error: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{"err": "failed to sign token"})
+		return
+	}
+	s.logger.Infow("user logged in", "user", id)
+	ctx.JSON(http.StatusOK, gin.H{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_at":   expires.UTC(),
+	})
+}
+
+// authMiddleware rejects requests without a valid bearer token or API
+// key and records the principal of the user it belongs to. The role is
+// read on every request, so a change applies to tokens already issued.
+func (s *Server) authMiddleware(ctx *gin.Context) {
+	raw, ok := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+	if !ok || raw == "" {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"err": "missing bearer token"})
+		return
+	}
+	var id int
+	var err error
+	if auth.IsAPIKey(raw) {
+		id, err = s.db.UseAPIKey(ctx.Request.Context(), auth.HashAPIKey(raw), time.Now())
+		if err != nil && err != sql.ErrNoRows {
+			s.logger.Errorln("failed to check api key, error: ", err.Error())
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+			return
+		}
+		if err == sql.ErrNoRows {
+			err = auth.ErrInvalidAPIKey
+		}
+	} else {
+		id, err = auth.ParseToken(s.cfg.JWTSecret, raw)
+	}
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"err": err.Error()})
+		return
+	}
+	p, err := s.db.GetPrincipal(ctx.Request.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			// the user was deleted after the token was issued
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"err": auth.ErrInvalidToken.Error()})
+			return
+		}
+		s.logger.Errorln("failed to get principal, error: ", err.Error())
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	ctx.Set(principalKey, *p)
+	if auth.IsAPIKey(raw) {
+		ctx.Set(apiKeyAuthKey, true)
+	}
+	// every storage call of the request stays in the user's organization
+	ctx.Request = ctx.Request.WithContext(storage.WithOrg(ctx.Request.Context(), p.OrgID))
+	ctx.Next()
+}
+
+// principal is the user authenticated by authMiddleware.
+func principal(ctx *gin.Context) models.Principal {
+	p, _ := ctx.Get(principalKey)
+	return p.(models.Principal)
+}
+
+// byAPIKey reports whether an API key authenticated the request.
+func byAPIKey(ctx *gin.Context) bool {
+	return ctx.GetBool(apiKeyAuthKey)
+}
+
+// actingUser is the id of the user authenticated by authMiddleware.
+func actingUser(ctx *gin.Context) int {
+	return principal(ctx).UserID
+}
+
+// allow consults the policy and answers 403 when the principal may not
+// do action on the data of ownerID.
+func (s *Server) allow(ctx *gin.Context, action auth.Action, ownerID int) bool {
+	p := principal(ctx)
+	if auth.Allow(p, action, ownerID) {
+		return true
+	}
+	s.logger.Infow("access denied", "user", p.UserID, "role", p.Role, "path", ctx.FullPath(), "owner", ownerID)
+	ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"err": utils.ErrForbidden.Error()})
+	return false
+}
+
+// allowTask is allow for the owner of the task in the :id parameter,
+// answering 404 for an unknown task.
+func (s *Server) allowTask(ctx *gin.Context, action auth.Action) (int, bool) {
+	id, ok := s.idParam(ctx)
+	if !ok {
+		return 0, false
+	}
+	task, err := s.db.GetTaskByID(ctx.Request.Context(), id)
+	if err != nil {
+		s.taskError(ctx, "failed to get task", err)
+		return 0, false
+	}
+	return id, s.allow(ctx, action, task.UserID)
+}
+
+// setPasswordHandler changes the password of a user, admins may reset
+// anyone's password.
+func (s *Server) setPasswordHandler(ctx *gin.Context) {
+	var input struct {
+		Password string `json:"password" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		return
+	}
+	id, ok := s.idParam(ctx)
+	if !ok {
+		return
+	}
+	if !s.allow(ctx, auth.WriteUserData, id) {
+		return
+	}
+	hash, err := auth.HashPassword(input.Password)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		return
+	}
+	if err := s.db.SetUserPassword(ctx.Request.Context(), id, hash); err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"err": "user not found"})
+			return
+		}
+		s.logger.Errorln("failed to set password, error: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	s.logger.Infow("password changed", "user", id)
+	ctx.JSON(http.StatusOK, gin.H{"res": "password changed"})
+}
+
+// setRoleHandler assigns the role and manager of a user.
+func (s *Server) setRoleHandler(ctx *gin.Context) {
+	if !s.allow(ctx, auth.ManageUsers, 0) {
+		return
+	}
+	var req models.SetRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		return
+	}
+	if !req.Role.Valid() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": "role must be admin, manager or employee"})
+		return
+	}
+	id, ok := s.idParam(ctx)
+	if !ok {
+		return
+	}
+	err := s.db.SetUserRole(ctx.Request.Context(), id, req.Role, req.ManagerID)
+	if err != nil {
+		switch {
+		case err == sql.ErrNoRows:
+			ctx.JSON(http.StatusNotFound, gin.H{"err": "user not found"})
+		case errors.Is(err, utils.ErrInvalidManager):
+			ctx.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		default:
+			s.logger.Errorln("failed to set role, error: ", err.Error())
+			ctx.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		}
+		return
+	}
+	s.logger.Infow("role changed", "user", id, "role", req.Role, "manager", req.ManagerID)
+	ctx.JSON(http.StatusOK, gin.H{"res": "role changed"})
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+	"time-tracker/internal/models"
+)
+
+func TestPolicyForbids(t *testing.T) {
+	day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+	window := "&start_date=" + day.Format(time.RFC3339) + "&end_date=" + day.AddDate(0, 0, 1).Format(time.RFC3339)
+	forEachDriver(t, func(t *testing.T, ts *testServer) {
+		ctx := orgCtx(models.DefaultOrganizationID)
+		managerID, manager := ts.addUser(models.DefaultOrganizationID, models.User{PassSerie: "7000", PassNumber: "000001"}, models.RoleManager)
+		reportID, report := ts.addUser(models.DefaultOrganizationID, models.User{PassSerie: "7000", PassNumber: "000002"}, models.RoleEmployee)
+		otherID, _ := ts.addUser(models.DefaultOrganizationID, models.User{PassSerie: "7000", PassNumber: "000003"}, models.RoleEmployee)
+		if err := ts.db.SetUserRole(ctx, reportID, models.RoleEmployee, &managerID); err != nil {
+			t.Fatal(err)
+		}
+		led := models.Team{Name: "Led", LeadID: &managerID}
+		other := models.Team{Name: "Other"}
+		for _, team := range []*models.Team{&led, &other} {
+			if err := ts.db.AddTeam(ctx, team); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := ts.db.AddTeamMember(ctx, other.ID, otherID); err != nil {
+			t.Fatal(err)
+		}
+		start, end := day.Add(9*time.Hour), day.Add(10*time.Hour)
+		otherTask := models.Task{UserID: otherID, StartTime: start, EndTime: &end}
+		reportTask := models.Task{UserID: reportID, StartTime: start, EndTime: &end}
+		for _, task := range []*models.Task{&otherTask, &reportTask} {
+			if err := ts.db.AddTask(ctx, task); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		tests := []struct {
+			name   string
+			token  string
+			method string
+			path   string
+			body   any
+			status int
+		}{
+			{"employee reads own worklogs", report, http.MethodGet, "/users/tasks?user_id=" + itoa(reportID) + window, nil, http.StatusOK},
+			{"employee reads another user", report, http.MethodGet, "/users/tasks?user_id=" + itoa(otherID) + window, nil, http.StatusForbidden},
+			{"employee reads the manager", report, http.MethodGet, "/users/" + itoa(managerID) + "/tasks/active", nil, http.StatusForbidden},
+			{"employee reads a task of another user", report, http.MethodGet, "/tasks/" + itoa(otherTask.ID), nil, http.StatusForbidden},
+			{"employee stops a task of another user", report, http.MethodPost, "/tasks/" + itoa(otherTask.ID) + "/stop", nil, http.StatusForbidden},
+			{"employee lists users", report, http.MethodGet, "/users?page=1&page_size=10", nil, http.StatusForbidden},
+			{"employee sets a role", report, http.MethodPut, "/users/" + itoa(reportID) + "/role", map[string]string{"role": "admin"}, http.StatusForbidden},
+			{"employee reads a team", report, http.MethodGet, "/teams/" + itoa(led.ID) + "/worklogs?" + window[1:], nil, http.StatusForbidden},
+
+			{"manager reads a report", manager, http.MethodGet, "/users/tasks?user_id=" + itoa(reportID) + window, nil, http.StatusOK},
+			{"manager reads a task of a report", manager, http.MethodGet, "/tasks/" + itoa(reportTask.ID), nil, http.StatusOK},
+			{"manager reads outside the reports", manager, http.MethodGet, "/users/tasks?user_id=" + itoa(otherID) + window, nil, http.StatusForbidden},
+			{"manager reads a task outside the reports", manager, http.MethodGet, "/tasks/" + itoa(otherTask.ID), nil, http.StatusForbidden},
+			{"manager edits a task of a report", manager, http.MethodPatch, "/tasks/" + itoa(reportTask.ID), map[string]string{"description": "edited"}, http.StatusForbidden},
+			{"manager reads the led team", manager, http.MethodGet, "/teams/" + itoa(led.ID) + "/worklogs?" + window[1:], nil, http.StatusOK},
+			{"manager reads another team", manager, http.MethodGet, "/teams/" + itoa(other.ID) + "/worklogs?" + window[1:], nil, http.StatusForbidden},
+			{"manager sets a role", manager, http.MethodPut, "/users/" + itoa(reportID) + "/role", map[string]string{"role": "manager"}, http.StatusForbidden},
+			{"manager creates a user", manager, http.MethodPost, "/create", map[string]string{"passport_number": "1111 111111"}, http.StatusForbidden},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ts.expect(ts.do(tt.token, tt.method, tt.path, tt.body), tt.status, nil)
+			})
+		}
+
+		// nothing forbidden took effect
+		var got models.Task
+		ts.expect(ts.do(manager, http.MethodGet, "/tasks/"+itoa(reportTask.ID), nil), http.StatusOK, &got)
+		if got.Desc != "" {
+			t.Errorf("task edited to %q", got.Desc)
+		}
+		principal, err := ts.db.GetPrincipal(ctx, reportID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if principal.Role != models.RoleEmployee {
+			t.Errorf("role changed to %s", principal.Role)
+		}
+	})
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+	"time-tracker/internal/auth"
+	"time-tracker/internal/export"
+	"time-tracker/internal/models"
+	"time-tracker/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// calendarFeedDays is how far back the calendar feed reaches.
+const calendarFeedDays = 365
+
+// createCalendarTokenHandler issues a new calendar feed token for the user,
+// the previous one stops working. Only a hash of the token is stored.
+func (s *Server) createCalendarTokenHandler(ctx *gin.Context) {
+	id, ok := s.idParam(ctx)
+	if !ok || !s.allow(ctx, auth.WriteUserData, id) {
+		return
+	}
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		s.logger.Errorln("failed to generate calendar token, error: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{"err": "failed to generate token"})
+		return
+	}
+	token := hex.EncodeToString(raw)
+	if err := s.db.SetCalendarTokenHash(ctx.Request.Context(), id, calendarTokenHash(token)); err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"err": "user not found"})
+			return
+		}
+		s.logger.Errorln("failed to save calendar token, error: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{
+		"token": token,
+		"url":   fmt.Sprintf("/users/%d/calendar.ics?token=%s", id, token),
+	})
+}
+
+// getCalendarHandler serves the user's tracked time as an iCalendar feed.
+// Calendar clients cannot log in, the token in the URL is the only check.
+func (s *Server) getCalendarHandler(ctx *gin.Context) {
+	id, ok := s.idParam(ctx)
+	if !ok {
+		return
+	}
+	var hash string
+	p, err := s.db.GetPrincipal(ctx.Request.Context(), id)
+	if err == nil {
+		// the feed has no principal, it reads in the organization of its user
+		ctx.Request = ctx.Request.WithContext(storage.WithOrg(ctx.Request.Context(), p.OrgID))
+		hash, err = s.db.GetCalendarTokenHash(ctx.Request.Context(), id)
+	}
+	if err != nil && err != sql.ErrNoRows {
+		s.logger.Errorln("failed to get calendar token, error: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	// unknown users and wrong tokens look the same
+	given := calendarTokenHash(ctx.Query("token"))
+	if err == sql.ErrNoRows || subtle.ConstantTimeCompare([]byte(hash), []byte(given)) != 1 {
+		ctx.JSON(http.StatusForbidden, gin.H{"err": "invalid token"})
+		return
+	}
+
+	now := time.Now()
+	req := &models.GetUserWorklogsRequest{
+		UserID:    id,
+		StartDate: now.AddDate(0, 0, -calendarFeedDays),
+		EndDate:   now,
+		TimeZone:  "UTC",
+	}
+	ctx.Header("Content-Type", "text/calendar; charset=utf-8")
+	ctx.Header("Content-Disposition", fmt.Sprintf(`inline; filename="worklogs-%d.ics"`, id))
+	ctx.Status(http.StatusOK)
+	calendar, err := export.NewCalendar(ctx.Writer, fmt.Sprintf("Worklogs of user %d", id), now)
+	if err == nil {
+		err = s.db.EachUserInterval(ctx.Request.Context(), req, calendar.Write)
+	}
+	if err == nil {
+		err = calendar.Close()
+	}
+	if err != nil {
+		s.logger.Errorln("failed to write calendar, error: ", err.Error())
+		if !ctx.Writer.Written() {
+			ctx.Writer.Header().Del("Content-Disposition")
+			ctx.Writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+			ctx.JSON(http.StatusInternalServerError, gin.H{"err": "failed to write calendar"})
+		}
+	}
+}
+
+func calendarTokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,27 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAccessLogRedactsToken(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/users/1/calendar.ics?token=abc123", "/users/1/calendar.ics?token=REDACTED"},
+		{"/users/1/calendar.ics?tz=UTC&token=abc123", "/users/1/calendar.ics?token=REDACTED&tz=UTC"},
+		{"/users/1/calendar.ics?token=abc%zz", "/users/1/calendar.ics?REDACTED"},
+		{"/users/tasks?user_id=1", "/users/tasks?user_id=1"},
+		{"/users", "/users"},
+	}
+	for _, tt := range tests {
+		line := accessLog(gin.LogFormatterParams{Method: "GET", StatusCode: 200, Path: tt.path})
+		if !strings.Contains(line, `"`+tt.want+`"`) || strings.Contains(line, "abc") {
+			t.Errorf("%s logged as %s", tt.path, line)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"time"
+	"time-tracker/internal/auth"
+	"time-tracker/internal/models"
+	"time-tracker/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) createTaskHandler(ctx *gin.Context) {
+	var input struct {
+		ProjectID *int      `json:"project_id"`
+		Desc      string    `json:"description"`
+		Tags      []string  `json:"tags"`
+		StartTime time.Time `json:"start_time" binding:"required"`
+		EndTime   time.Time `json:"end_time" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		return
+	}
+	s.logger.Debugw("createTaskHandler", "input_task", input)
+	task := &models.Task{
+		UserID:    actingUser(ctx),
+		ProjectID: input.ProjectID,
+		StartTime: input.StartTime,
+		EndTime:   &input.EndTime,
+		Desc:      input.Desc,
+		Tags:      models.NormalizeTags(input.Tags),
+	}
+	if err := s.db.AddTask(ctx.Request.Context(), task); err != nil {
+		s.taskError(ctx, "failed to add time entry", err)
+		return
+	}
+	s.logger.Infow("time entry successfully added to db", "task", task.ID)
+	ctx.JSON(http.StatusOK, task)
+}
+
+func (s *Server) getTaskHandler(ctx *gin.Context) {
+	id, ok := s.idParam(ctx)
+	if !ok {
+		return
+	}
+	task, err := s.db.GetTaskByID(ctx.Request.Context(), id)
+	if err != nil {
+		s.taskError(ctx, "failed to get task", err)
+		return
+	}
+	if !s.allow(ctx, auth.ReadUserData, task.UserID) {
+		return
+	}
+	ctx.JSON(http.StatusOK, task)
+}
+
+func (s *Server) updateTaskHandler(ctx *gin.Context) {
+	var upd models.TaskUpdate
+	if err := ctx.ShouldBindJSON(&upd); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		return
+	}
+	id, ok := s.allowTask(ctx, auth.WriteUserData)
+	if !ok {
+		return
+	}
+	s.logger.Debugw("updateTaskHandler", "id", id, "update", upd)
+	task, err := s.db.UpdateTask(ctx.Request.Context(), id, upd)
+	if err != nil {
+		s.taskError(ctx, "failed to update task", err)
+		return
+	}
+	s.logger.Infoln("successfully updated task")
+	ctx.JSON(http.StatusOK, task)
+}
+
+func (s *Server) deleteTaskHandler(ctx *gin.Context) {
+	id, ok := s.allowTask(ctx, auth.WriteUserData)
+	if !ok {
+		return
+	}
+	if err := s.db.DeleteTask(ctx.Request.Context(), id); err != nil {
+		s.taskError(ctx, "failed to delete task", err)
+		return
+	}
+	s.logger.Infoln("successfully deleted task")
+	ctx.JSON(http.StatusOK, gin.H{"res": "successfully deleted"})
+}
+
+// isEntryError reports whether err rejects the times of an entry.
+func isEntryError(err error) bool {
+	return errors.Is(err, utils.ErrInvalidInterval) || errors.Is(err, utils.ErrFutureTime)
+}
+
+func (s *Server) getUserConflictsHandler(ctx *gin.Context) {
+	id, ok := s.idParam(ctx)
+	if !ok || !s.allow(ctx, auth.ReadUserData, id) || !s.userFound(ctx, id) {
+		return
+	}
+	conflicts, err := s.db.GetUserConflicts(ctx.Request.Context(), id)
+	if err != nil {
+		s.logger.Errorln("failed to get conflicts, error: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"conflicts": conflicts})
+}
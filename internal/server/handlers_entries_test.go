@@ -0,0 +1,68 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+	"time-tracker/internal/models"
+)
+
+func TestTimeEntryErrors(t *testing.T) {
+	day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+	at := func(hour, minute int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+	forEachDriver(t, func(t *testing.T, ts *testServer) {
+		_, token := ts.addUser(models.DefaultOrganizationID, models.User{PassSerie: "7000", PassNumber: "000201"}, models.RoleEmployee)
+		entry := func(start, end time.Time) map[string]any {
+			return map[string]any{"description": "entry", "start_time": start, "end_time": end}
+		}
+
+		var stored models.Task
+		ts.expect(ts.do(token, http.MethodPost, "/tasks", entry(at(9, 0), at(10, 0))), http.StatusOK, &stored)
+		var running struct {
+			Task models.Task `json:"task"`
+		}
+		ts.expect(ts.do(token, http.MethodPost, "/tasks/start", map[string]string{"description": "running"}), http.StatusOK, &running)
+		path := "/tasks/" + itoa(stored.ID)
+		runningPath := "/tasks/" + itoa(running.Task.ID)
+
+		tests := []struct {
+			name   string
+			method string
+			path   string
+			body   any
+			status int
+		}{
+			{"create ending before it starts", http.MethodPost, "/tasks", entry(at(12, 0), at(11, 0)), http.StatusBadRequest},
+			{"create of zero length", http.MethodPost, "/tasks", entry(at(12, 0), at(12, 0)), http.StatusBadRequest},
+			{"create ending in the future", http.MethodPost, "/tasks", entry(at(12, 0), time.Now().Add(time.Hour)), http.StatusBadRequest},
+			{"create overlapping", http.MethodPost, "/tasks", entry(at(9, 30), at(10, 30)), http.StatusConflict},
+			{"create overlapping the running task", http.MethodPost, "/tasks", entry(running.Task.StartTime.Add(-time.Hour), time.Now()), http.StatusConflict},
+			{"edit ending before it starts", http.MethodPatch, path, map[string]any{"end_time": at(8, 0)}, http.StatusBadRequest},
+			{"edit to zero length", http.MethodPatch, path, map[string]any{"end_time": at(9, 0)}, http.StatusBadRequest},
+			{"edit ending in the future", http.MethodPatch, path, map[string]any{"end_time": time.Now().Add(time.Hour)}, http.StatusBadRequest},
+			{"edit of a running task starting past now", http.MethodPatch, runningPath, map[string]any{"start_time": time.Now().Add(time.Hour)}, http.StatusBadRequest},
+			{"edit onto the running task", http.MethodPatch, path, map[string]any{"start_time": running.Task.StartTime.Add(-2 * time.Hour), "end_time": time.Now()}, http.StatusConflict},
+			{"edit of an unknown task", http.MethodPatch, "/tasks/" + itoa(stored.ID+100), map[string]any{"end_time": at(11, 0)}, http.StatusNotFound},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ts.expect(ts.do(token, tt.method, tt.path, tt.body), tt.status, nil)
+			})
+		}
+
+		// nothing of the refused requests was stored
+		var got models.Task
+		ts.expect(ts.do(token, http.MethodGet, path, nil), http.StatusOK, &got)
+		if !got.StartTime.Equal(at(9, 0)) || got.EndTime == nil || !got.EndTime.Equal(at(10, 0)) {
+			t.Fatalf("entry = %+v, want 9:00-10:00 unchanged", got)
+		}
+		var gotRunning models.Task
+		ts.expect(ts.do(token, http.MethodGet, runningPath, nil), http.StatusOK, &gotRunning)
+		if !gotRunning.StartTime.Equal(running.Task.StartTime) || gotRunning.EndTime != nil {
+			t.Fatalf("running task = %+v, want it unchanged", gotRunning)
+		}
+		ts.expect(ts.do(token, http.MethodPatch, path, map[string]any{"end_time": at(10, 30)}), http.StatusOK, nil)
+	})
+}
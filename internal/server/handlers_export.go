@@ -0,0 +1,133 @@
+package server
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+	"time-tracker/internal/auth"
+	"time-tracker/internal/export"
+	"time-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportFormat picks the export format from format= or else the Accept
+// header, "" keeps the JSON response.
+func exportFormat(ctx *gin.Context, format string) string {
+	switch format {
+	case "json":
+		return ""
+	case "":
+		return export.FormatOf(ctx.GetHeader("Accept"))
+	}
+	return format
+}
+
+// exportWorklogs streams the worklogs as a spreadsheet with one row per
+// segment and day, a task paused and resumed has a row for every stretch.
+func (s *Server) exportWorklogs(ctx *gin.Context, req *models.GetUserWorklogsRequest, format string) {
+	columns, err := export.ParseColumns(req.Columns)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		return
+	}
+	if _, err := req.Location(); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": "unknown time zone"})
+		return
+	}
+	user, err := s.db.GetUserByID(ctx.Request.Context(), req.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"err": "user not found"})
+			return
+		}
+		s.logger.Errorln("failed to get user, error: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+
+	ctx.Header("Content-Type", export.ContentType(format))
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="worklogs-%d.%s"`, req.UserID, format))
+	ctx.Status(http.StatusOK)
+	w, err := export.New(format, ctx.Writer, columns)
+	if err == nil {
+		err = s.db.EachUserTimeEntry(ctx.Request.Context(), req, func(e models.TimeEntry) error {
+			e.User = user.FullName()
+			return w.Write(e)
+		})
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		s.logger.Errorln("failed to export worklogs, error: ", err.Error())
+		if ctx.Writer.Written() {
+			// the status is sent, only a cut connection tells the client
+			// the file is incomplete
+			panic(http.ErrAbortHandler)
+		}
+		ctx.Writer.Header().Del("Content-Disposition")
+		ctx.Writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		ctx.JSON(http.StatusInternalServerError, gin.H{"err": "failed to export worklogs"})
+	}
+}
+
+func (s *Server) getTimesheetHandler(ctx *gin.Context) {
+	id, ok := s.idParam(ctx)
+	if !ok || !s.allow(ctx, auth.ReadUserData, id) {
+		return
+	}
+	var req models.GetTimesheetRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": "invalid query parameters"})
+		return
+	}
+	loc, err := time.LoadLocation(req.TimeZone)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": "unknown time zone"})
+		return
+	}
+	month, err := time.ParseInLocation("2006-01", req.Month, loc)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": "month must be YYYY-MM"})
+		return
+	}
+	user, err := s.db.GetUserByID(ctx.Request.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"err": "user not found"})
+			return
+		}
+		s.logger.Errorln("failed to get user, error: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return
+	}
+
+	sheet := export.Timesheet{User: user.FullName(), Month: month}
+	worklogs := &models.GetUserWorklogsRequest{
+		UserID:    id,
+		StartDate: month,
+		EndDate:   month.AddDate(0, 1, 0),
+		TimeZone:  req.TimeZone,
+	}
+	err = s.db.EachUserTimeEntry(ctx.Request.Context(), worklogs, func(e models.TimeEntry) error {
+		sheet.Entries = append(sheet.Entries, e)
+		return nil
+	})
+	if err != nil {
+		s.logger.Errorln("failed to get worklogs, error: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{"err": "failed to get worklogs"})
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := export.WriteTimesheet(&buf, sheet); err != nil {
+		s.logger.Errorln("failed to render timesheet, error: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{"err": "failed to render timesheet"})
+		return
+	}
+	ctx.Header("Content-Disposition", fmt.Sprintf(`inline; filename="timesheet-%d-%s.pdf"`, id, req.Month))
+	ctx.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+	"time-tracker/internal/models"
+	"time-tracker/internal/storage"
+)
+
+// brokenExport fails an export after rows enough to flush the header.
+type brokenExport struct {
+	storage.Database
+	rows int
+}
+
+func (b brokenExport) EachUserTimeEntry(ctx context.Context, req *models.GetUserWorklogsRequest, fn func(models.TimeEntry) error) error {
+	for n := 0; n < b.rows; n++ {
+		e := models.TimeEntry{Date: "2024-01-01", TaskID: n, Desc: strings.Repeat("x", 100)}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return errors.New("connection lost")
+}
+
+func TestExportErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		rows  int
+		abort bool
+	}{
+		{"before any row went out", 0, false},
+		{"in the middle of the file", 100, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t, storage.DriverMemory)
+			id, token := ts.addUser(models.DefaultOrganizationID, admin, models.RoleAdmin)
+			ts.srv.db = brokenExport{Database: ts.db, rows: tt.rows}
+
+			day := time.Now().UTC().Format("2006-01-02")
+			path := "/users/tasks?format=csv&user_id=" + itoa(id) + "&start_date=" + day + "T00:00:00Z&end_date=" + day + "T23:00:00Z"
+			defer func() {
+				err := recover()
+				if tt.abort && err != http.ErrAbortHandler {
+					t.Fatalf("recovered %v, want http.ErrAbortHandler", err)
+				}
+				if !tt.abort && err != nil {
+					t.Fatalf("recovered %v", err)
+				}
+			}()
+			rec := ts.do(token, http.MethodGet, path, nil)
+			if tt.abort {
+				t.Fatalf("answered %d without cutting the connection", rec.Code)
+			}
+			ts.expect(rec, http.StatusInternalServerError, nil)
+		})
+	}
+}
+
+// pdfText inflates the content streams of a PDF, where its text is, and
+// drops the zero bytes UTF-16 puts into Latin text.
+func pdfText(t *testing.T, pdf []byte) string {
+	t.Helper()
+	var text strings.Builder
+	for {
+		_, rest, ok := bytes.Cut(pdf, []byte("stream\n"))
+		if !ok {
+			return text.String()
+		}
+		stream, after, _ := bytes.Cut(rest, []byte("\nendstream"))
+		pdf = after
+		r, err := zlib.NewReader(bytes.NewReader(stream))
+		if err != nil {
+			// not every stream is compressed, fonts are not needed
+			continue
+		}
+		data, _ := io.ReadAll(r)
+		text.Write(bytes.ReplaceAll(data, []byte{0}, nil))
+	}
+}
+
+func TestTimesheetShowsEmployeeName(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, ts *testServer) {
+		u := models.User{PassSerie: "2222", PassNumber: "222222", Surname: "Petrov", Name: "Petr", Patronymic: "Petrovich"}
+		id, token := ts.addUser(models.DefaultOrganizationID, u, models.RoleEmployee)
+
+		rec := ts.do(token, http.MethodGet, "/users/"+itoa(id)+"/timesheet.pdf?month=2024-01&tz=UTC", nil)
+		ts.expect(rec, http.StatusOK, nil)
+		if text := pdfText(t, rec.Body.Bytes()); !strings.Contains(text, "Employee: Petrov Petr Petrovich)") {
+			t.Fatalf("timesheet does not name the employee:\n%s", text)
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"time"
+	"time-tracker/internal/auth"
+	"time-tracker/internal/importer"
+	"time-tracker/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportSize limits the CSV body of an import.
+const maxImportSize = 32 << 20
+
+func (s *Server) importHandler(ctx *gin.Context) {
+	if !s.allow(ctx, auth.ImportData, 0) {
+		return
+	}
+	var req models.ImportRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": "invalid query parameters"})
+		return
+	}
+	loc, err := time.LoadLocation(req.TimeZone)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": "unknown time zone"})
+		return
+	}
+	users, err := importer.ParseUsers(req.Users)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		return
+	}
+
+	body := http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImportSize)
+	report, err := importer.Import(ctx.Request.Context(), s.db, body, importer.Options{
+		Source:   req.Source,
+		Location: loc,
+		Users:    users,
+		DryRun:   req.DryRun,
+	})
+	if err != nil {
+		if errors.Is(err, importer.ErrFormat) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+			return
+		}
+		s.logger.Errorln("failed to import, error: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{"err": "failed to import"})
+		return
+	}
+	s.logger.Infow("import finished", "source", req.Source, "dry_run", req.DryRun, "rows", report.Rows, "imported", report.Imported)
+	if !report.DryRun && !report.Imported {
+		ctx.JSON(http.StatusConflict, report)
+		return
+	}
+	ctx.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,217 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time-tracker/internal/auth"
+	"time-tracker/internal/models"
+	"time-tracker/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) createClientHandler(ctx *gin.Context) {
+	if !s.allow(ctx, auth.ManageProjects, 0) {
+		return
+	}
+	var c models.Client
+	if err := ctx.ShouldBindJSON(&c); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		return
+	}
+	s.logger.Debugw("createClientHandler", "client", c)
+	if err := s.db.AddClient(ctx.Request.Context(), &c); err != nil {
+		s.projectError(ctx, "failed to add client", err)
+		return
+	}
+	s.logger.Infow("client successfully added to db", "client", c)
+	ctx.JSON(http.StatusOK, c)
+}
+
+func (s *Server) getClientsHandler(ctx *gin.Context) {
+	clients, err := s.db.GetClients(ctx.Request.Context())
+	if err != nil {
+		s.projectError(ctx, "failed to get clients", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"clients": clients})
+}
+
+func (s *Server) getClientHandler(ctx *gin.Context) {
+	id, ok := s.idParam(ctx)
+	if !ok {
+		return
+	}
+	c, err := s.db.GetClientByID(ctx.Request.Context(), id)
+	if err != nil {
+		s.projectError(ctx, "failed to get client", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, c)
+}
+
+func (s *Server) updateClientHandler(ctx *gin.Context) {
+	if !s.allow(ctx, auth.ManageProjects, 0) {
+		return
+	}
+	id, ok := s.idParam(ctx)
+	if !ok {
+		return
+	}
+	var c models.Client
+	if err := ctx.ShouldBindJSON(&c); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		return
+	}
+	c.ID = id
+	s.logger.Debugw("updateClientHandler", "client", c)
+	if err := s.db.UpdateClient(ctx.Request.Context(), &c); err != nil {
+		s.projectError(ctx, "failed to update client", err)
+		return
+	}
+	s.logger.Infoln("successfully updated client")
+	ctx.JSON(http.StatusOK, c)
+}
+
+func (s *Server) deleteClientHandler(ctx *gin.Context) {
+	if !s.allow(ctx, auth.ManageProjects, 0) {
+		return
+	}
+	id, ok := s.idParam(ctx)
+	if !ok {
+		return
+	}
+	if err := s.db.DeleteClient(ctx.Request.Context(), id); err != nil {
+		s.projectError(ctx, "failed to delete client", err)
+		return
+	}
+	s.logger.Infoln("successfully deleted client")
+	ctx.JSON(http.StatusOK, gin.H{"res": "successfully deleted"})
+}
+
+func (s *Server) createProjectHandler(ctx *gin.Context) {
+	if !s.allow(ctx, auth.ManageProjects, 0) {
+		return
+	}
+	var p models.Project
+	if err := ctx.ShouldBindJSON(&p); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		return
+	}
+	s.logger.Debugw("createProjectHandler", "project", p)
+	if err := s.db.AddProject(ctx.Request.Context(), &p); err != nil {
+		s.projectError(ctx, "failed to add project", err)
+		return
+	}
+	s.logger.Infow("project successfully added to db", "project", p)
+	ctx.JSON(http.StatusOK, p)
+}
+
+func (s *Server) getProjectsHandler(ctx *gin.Context) {
+	var req models.GetProjectsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": "invalid query parameters"})
+		return
+	}
+	projects, err := s.db.GetProjects(ctx.Request.Context(), req)
+	if err != nil {
+		s.projectError(ctx, "failed to get projects", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"projects": projects})
+}
+
+func (s *Server) getProjectHandler(ctx *gin.Context) {
+	id, ok := s.idParam(ctx)
+	if !ok {
+		return
+	}
+	p, err := s.db.GetProjectByID(ctx.Request.Context(), id)
+	if err != nil {
+		s.projectError(ctx, "failed to get project", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, p)
+}
+
+func (s *Server) updateProjectHandler(ctx *gin.Context) {
+	if !s.allow(ctx, auth.ManageProjects, 0) {
+		return
+	}
+	id, ok := s.idParam(ctx)
+	if !ok {
+		return
+	}
+	var p models.Project
+	if err := ctx.ShouldBindJSON(&p); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		return
+	}
+	p.ID = id
+	s.logger.Debugw("updateProjectHandler", "project", p)
+	if err := s.db.UpdateProject(ctx.Request.Context(), &p); err != nil {
+		s.projectError(ctx, "failed to update project", err)
+		return
+	}
+	s.logger.Infoln("successfully updated project")
+	ctx.JSON(http.StatusOK, p)
+}
+
+func (s *Server) deleteProjectHandler(ctx *gin.Context) {
+	if !s.allow(ctx, auth.ManageProjects, 0) {
+		return
+	}
+	id, ok := s.idParam(ctx)
+	if !ok {
+		return
+	}
+	if err := s.db.DeleteProject(ctx.Request.Context(), id); err != nil {
+		s.projectError(ctx, "failed to delete project", err)
+		return
+	}
+	s.logger.Infoln("successfully deleted project")
+	ctx.JSON(http.StatusOK, gin.H{"res": "successfully deleted"})
+}
+
+// projectError answers with the status matching a client or project storage error.
+func (s *Server) projectError(ctx *gin.Context, msg string, err error) {
+	switch {
+	case err == sql.ErrNoRows:
+		ctx.JSON(http.StatusNotFound, gin.H{"err": "not found"})
+	case errors.Is(err, utils.ErrClientNotFound):
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+	case errors.Is(err, utils.ErrAlreadyExists), errors.Is(err, utils.ErrInUse):
+		ctx.JSON(http.StatusConflict, gin.H{"err": err.Error()})
+	default:
+		s.logger.Errorln(msg+", error: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+	}
+}
+
+// idParam parses the :id path parameter, answering 400 when it is not a number.
+func (s *Server) idParam(ctx *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": "id is not a number"})
+		return 0, false
+	}
+	return id, true
+}
+
+// userFound answers 404 unless the user is in the organization of the
+// request, for lists of a user that would otherwise come back empty.
+func (s *Server) userFound(ctx *gin.Context, id int) bool {
+	_, err := s.db.GetUserByID(ctx.Request.Context(), id)
+	if err == sql.ErrNoRows {
+		ctx.JSON(http.StatusNotFound, gin.H{"err": "user not found"})
+		return false
+	}
+	if err != nil {
+		s.logger.Errorln("failed to get user, error: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time-tracker/internal/auth"
+	"time-tracker/internal/models"
+	"time-tracker/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) createTeamHandler(ctx *gin.Context) {
+	if !s.allow(ctx, auth.ManageUsers, 0) {
+		return
+	}
+	var t models.Team
+	if err := ctx.ShouldBindJSON(&t); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		return
+	}
+	s.logger.Debugw("createTeamHandler", "team", t)
+	if err := s.db.AddTeam(ctx.Request.Context(), &t); err != nil {
+		s.teamError(ctx, "failed to add team", err)
+		return
+	}
+	s.logger.Infow("team successfully added to db", "team", t)
+	ctx.JSON(http.StatusOK, t)
+}
+
+func (s *Server) getTeamsHandler(ctx *gin.Context) {
+	if !s.allow(ctx, auth.ListUsers, 0) {
+		return
+	}
+	teams, err := s.db.GetTeams(ctx.Request.Context())
+	if err != nil {
+		s.teamError(ctx, "failed to get teams", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"teams": teams})
+}
+
+func (s *Server) getTeamHandler(ctx *gin.Context) {
+	if !s.allow(ctx, auth.ListUsers, 0) {
+		return
+	}
+	id, ok := s.idParam(ctx)
+	if !ok {
+		return
+	}
+	t, err := s.db.GetTeamByID(ctx.Request.Context(), id)
+	if err != nil {
+		s.teamError(ctx, "failed to get team", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, t)
+}
+
+func (s *Server) updateTeamHandler(ctx *gin.Context) {
+	if !s.allow(ctx, auth.ManageUsers, 0) {
+		return
+	}
+	var t models.Team
+	if err := ctx.ShouldBindJSON(&t); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+		return
+	}
+	id, ok := s.idParam(ctx)
+	if !ok {
+		return
+	}
+	t.ID = id
+	if err := s.db.UpdateTeam(ctx.Request.Context(), &t); err != nil {
+		s.teamError(ctx, "failed to update team", err)
+		return
+	}
+	s.logger.Infow("team successfully updated", "team", t.ID)
+	ctx.JSON(http.StatusOK, gin.H{"res": "successfully updated"})
+}
+
+func (s *Server) deleteTeamHandler(ctx *gin.Context) {
+	if !s.allow(ctx, auth.ManageUsers, 0) {
+		return
+	}
+	id, ok := s.idParam(ctx)
+	if !ok {
+		return
+	}
+	if err := s.db.DeleteTeam(ctx.Request.Context(), id); err != nil {
+		s.teamError(ctx, "failed to delete team", err)
+		return
+	}
+	s.logger.Infow("team successfully deleted", "team", id)
+	ctx.JSON(http.StatusOK, gin.H{"res": "successfully deleted"})
+}
+
+func (s *Server) addTeamMemberHandler(ctx *gin.Context) {
+	teamID, userID, ok := s.memberParams(ctx)
+	if !ok {
+		return
+	}
+	if err := s.db.AddTeamMember(ctx.Request.Context(), teamID, userID); err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"err": "team or user not found"})
+			return
+		}
+		s.teamError(ctx, "failed to add team member", err)
+		return
+	}
+	s.logger.Infow("team member added", "team", teamID, "user", userID)
+	ctx.JSON(http.StatusOK, gin.H{"res": "member added"})
+}
+
+func (s *Server) removeTeamMemberHandler(ctx *gin.Context) {
+	teamID, userID, ok := s.memberParams(ctx)
+	if !ok {
+		return
+	}
+	if err := s.db.RemoveTeamMember(ctx.Request.Context(), teamID, userID); err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"err": "user is not a member of the team"})
+			return
+		}
+		s.teamError(ctx, "failed to remove team member", err)
+		return
+	}
+	s.logger.Infow("team member removed", "team", teamID, "user", userID)
+	ctx.JSON(http.StatusOK, gin.H{"res": "member removed"})
+}
+
+// getTeamWorklogsHandler sums the time of all team members per member and
+// per project.
+func (s *Server) getTeamWorklogsHandler(ctx *gin.Context) {
+	id, ok := s.idParam(ctx)
+	if !ok || !s.allow(ctx, auth.ReadTeamData, id) {
+		return
+	}
+	var req models.GetTeamWorklogsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": "invalid query parameters"})
+		return
+	}
+	report, err := s.db.GetTeamWorklogs(ctx.Request.Context(), id, &req)
+	if err != nil {
+		s.teamError(ctx, "failed to get team worklogs", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, report)
+}
+
+// memberParams checks the policy and parses the team and user of a
+// membership route.
+func (s *Server) memberParams(ctx *gin.Context) (int, int, bool) {
+	if !s.allow(ctx, auth.ManageUsers, 0) {
+		return 0, 0, false
+	}
+	teamID, ok := s.idParam(ctx)
+	if !ok {
+		return 0, 0, false
+	}
+	userID, err := strconv.Atoi(ctx.Param("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": "user_id is not a number"})
+		return 0, 0, false
+	}
+	return teamID, userID, true
+}
+
+// teamError answers with the status matching a team storage error.
+func (s *Server) teamError(ctx *gin.Context, msg string, err error) {
+	switch {
+	case err == sql.ErrNoRows:
+		ctx.JSON(http.StatusNotFound, gin.H{"err": "team not found"})
+	case errors.Is(err, utils.ErrLeadNotFound):
+		ctx.JSON(http.StatusBadRequest, gin.H{"err": err.Error()})
+	case errors.Is(err, utils.ErrAlreadyExists):
+		ctx.JSON(http.StatusConflict, gin.H{"err": err.Error()})
+	default:
+		s.logger.Errorln(msg+", error: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{"err": err.Error()})
+	}
+}
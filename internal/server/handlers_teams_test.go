@@ -0,0 +1,76 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+	"time-tracker/internal/models"
+)
+
+func TestTeamsAPI(t *testing.T) {
+	day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+	window := "?start_date=" + day.Format(time.RFC3339) + "&end_date=" + day.AddDate(0, 0, 1).Format(time.RFC3339)
+	forEachDriver(t, func(t *testing.T, ts *testServer) {
+		ctx := orgCtx(models.DefaultOrganizationID)
+		_, token := ts.addUser(models.DefaultOrganizationID, admin, models.RoleAdmin)
+		leadID, _ := ts.addUser(models.DefaultOrganizationID, models.User{PassSerie: "7000", PassNumber: "000101", Surname: "Lead"}, models.RoleManager)
+		memberID, _ := ts.addUser(models.DefaultOrganizationID, models.User{PassSerie: "7000", PassNumber: "000102", Surname: "Member"}, models.RoleEmployee)
+		ts.addUser(models.DefaultOrganizationID, models.User{PassSerie: "7000", PassNumber: "000103"}, models.RoleEmployee)
+
+		var team models.Team
+		ts.expect(ts.do(token, http.MethodPost, "/teams", map[string]any{"name": "Backend", "lead_id": leadID}), http.StatusOK, &team)
+		ts.expect(ts.do(token, http.MethodPost, "/teams", map[string]any{"name": "Backend"}), http.StatusConflict, nil)
+		ts.expect(ts.do(token, http.MethodPost, "/teams", map[string]any{"name": "Frontend", "lead_id": memberID + 100}), http.StatusBadRequest, nil)
+		path := "/teams/" + itoa(team.ID)
+
+		for _, id := range []int{leadID, memberID} {
+			ts.expect(ts.do(token, http.MethodPut, path+"/members/"+itoa(id), nil), http.StatusOK, nil)
+		}
+		ts.expect(ts.do(token, http.MethodPut, path+"/members/"+itoa(memberID+100), nil), http.StatusNotFound, nil)
+
+		var found usersResponse
+		ts.expect(ts.do(token, http.MethodGet, "/users?page=1&page_size=10&team_id="+itoa(team.ID), nil), http.StatusOK, &found)
+		if len(found.Users) != 2 || found.Users[itoa(leadID)].Surname != "Lead" || found.Users[itoa(memberID)].Surname != "Member" {
+			t.Fatalf("users of the team = %+v, want the lead and the member", found.Users)
+		}
+
+		for _, task := range []struct {
+			userID     int
+			start, end time.Duration
+		}{
+			{leadID, 9 * time.Hour, 10 * time.Hour},
+			{memberID, 9 * time.Hour, 12 * time.Hour},
+		} {
+			end := day.Add(task.end)
+			add := models.Task{UserID: task.userID, StartTime: day.Add(task.start), EndTime: &end}
+			if err := ts.db.AddTask(ctx, &add); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var report models.TeamReport
+		ts.expect(ts.do(token, http.MethodGet, path+"/worklogs"+window, nil), http.StatusOK, &report)
+		if report.Total.Seconds != 4*3600 || len(report.Members) != 2 ||
+			report.Members[0].UserID != memberID || report.Members[0].Seconds != 3*3600 ||
+			report.Members[1].UserID != leadID || report.Members[1].Seconds != 3600 {
+			t.Fatalf("report = %+v, want 3h of the member and 1h of the lead", report)
+		}
+
+		ts.expect(ts.do(token, http.MethodDelete, path+"/members/"+itoa(leadID), nil), http.StatusOK, nil)
+		ts.expect(ts.do(token, http.MethodDelete, path+"/members/"+itoa(leadID), nil), http.StatusNotFound, nil)
+		found = usersResponse{}
+		ts.expect(ts.do(token, http.MethodGet, "/users?page=1&page_size=10&team_id="+itoa(team.ID), nil), http.StatusOK, &found)
+		if _, ok := found.Users[itoa(memberID)]; len(found.Users) != 1 || !ok {
+			t.Fatalf("users of the team = %+v, want only the member", found.Users)
+		}
+
+		ts.expect(ts.do(token, http.MethodPut, path, map[string]any{"name": "Platform"}), http.StatusOK, nil)
+		var got models.Team
+		ts.expect(ts.do(token, http.MethodGet, path, nil), http.StatusOK, &got)
+		if got.Name != "Platform" || got.LeadID != nil || len(got.Members) != 1 || got.Members[0] != memberID {
+			t.Fatalf("team = %+v, want Platform without a lead", got)
+		}
+		ts.expect(ts.do(token, http.MethodDelete, path, nil), http.StatusOK, nil)
+		ts.expect(ts.do(token, http.MethodGet, path, nil), http.StatusNotFound, nil)
+		ts.expect(ts.do(token, http.MethodGet, path+"/worklogs"+window, nil), http.StatusNotFound, nil)
+	})
+}
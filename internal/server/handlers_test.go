@@ -0,0 +1,268 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+	"time-tracker/configs"
+	"time-tracker/internal/models"
+)
+
+var admin = models.User{PassSerie: "9999", PassNumber: "000001", Surname: "Root", Name: "Admin"}
+
+type usersResponse struct {
+	Users map[string]models.User `json:"users"`
+}
+
+func TestCreateUser(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, ts *testServer) {
+		_, token := ts.addUser(models.DefaultOrganizationID, admin, models.RoleAdmin)
+
+		var created models.User
+		ts.expect(ts.do(token, http.MethodPost, "/create", map[string]string{"passport_number": "1111 111111"}), http.StatusOK, &created)
+		want := models.User{
+			Id:               created.Id,
+			PassSerie:        "1111",
+			PassNumber:       "111111",
+			Surname:          "Ivanov",
+			Name:             "Ivan",
+			Patronymic:       "Ivanovich",
+			Address:          "Moscow",
+			EnrichmentStatus: models.EnrichmentDone,
+		}
+		if created != want {
+			t.Fatalf("created %+v, want %+v", created, want)
+		}
+
+		var found usersResponse
+		ts.expect(ts.do(token, http.MethodGet, "/users?page=1&page_size=10&pass_serie=1111", nil), http.StatusOK, &found)
+		if len(found.Users) != 1 {
+			t.Fatalf("found %d users, want 1", len(found.Users))
+		}
+		for _, u := range found.Users {
+			if u != want {
+				t.Fatalf("listed %+v, want %+v", u, want)
+			}
+		}
+	})
+}
+
+func TestCreateUserErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		role     models.Role
+		passport string
+		status   int
+	}{
+		{"bad passport", models.RoleAdmin, "12 34", http.StatusBadRequest},
+		{"unknown passport", models.RoleAdmin, "2222 222222", http.StatusBadRequest},
+		{"not an admin", models.RoleManager, "1111 111111", http.StatusForbidden},
+	}
+	forEachDriver(t, func(t *testing.T, ts *testServer) {
+		for i, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				u := admin
+				u.PassNumber = "00000" + string(rune('2'+i))
+				_, token := ts.addUser(models.DefaultOrganizationID, u, tt.role)
+				rec := ts.do(token, http.MethodPost, "/create", map[string]string{"passport_number": tt.passport})
+				ts.expect(rec, tt.status, nil)
+			})
+		}
+	})
+}
+
+func TestUpdateUserKeepsOtherFields(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, ts *testServer) {
+		_, token := ts.addUser(models.DefaultOrganizationID, admin, models.RoleAdmin)
+		id, _ := ts.addUser(models.DefaultOrganizationID, models.User{
+			PassSerie: "1234", PassNumber: "567890", Surname: "Petrov", Name: "Petr", Patronymic: "Petrovich",
+		}, models.RoleEmployee)
+
+		ts.expect(ts.do(token, http.MethodPut, "/users/"+itoa(id), map[string]string{"address": "Kazan"}), http.StatusOK, nil)
+
+		// listed rather than read back by GetUserByID, which could undo its own mistakes
+		var found usersResponse
+		ts.expect(ts.do(token, http.MethodGet, "/users?page=1&page_size=10&pass_serie=1234", nil), http.StatusOK, &found)
+		u := found.Users[itoa(id)]
+		if u.Surname != "Petrov" || u.Name != "Petr" || u.Patronymic != "Petrovich" || u.Address != "Kazan" {
+			t.Fatalf("updated user %+v", u)
+		}
+	})
+}
+
+func TestDeleteUser(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, ts *testServer) {
+		_, token := ts.addUser(models.DefaultOrganizationID, admin, models.RoleAdmin)
+		id, _ := ts.addUser(models.DefaultOrganizationID, models.User{PassSerie: "1234", PassNumber: "567890"}, models.RoleEmployee)
+
+		ts.expect(ts.do(token, http.MethodDelete, "/users/"+itoa(id), nil), http.StatusOK, nil)
+		ts.expect(ts.do(token, http.MethodGet, "/users?page=1&page_size=10&pass_serie=1234", nil), http.StatusNotFound, nil)
+		ts.expect(ts.do(token, http.MethodPut, "/users/"+itoa(id), map[string]string{"address": "Kazan"}), http.StatusNotFound, nil)
+	})
+}
+
+func TestRequestsNeedToken(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, ts *testServer) {
+		ts.expect(ts.do("", http.MethodGet, "/users?page=1&page_size=10", nil), http.StatusUnauthorized, nil)
+		ts.expect(ts.do("not-a-token", http.MethodGet, "/users?page=1&page_size=10", nil), http.StatusUnauthorized, nil)
+	})
+}
+
+func TestGetTagsPolicy(t *testing.T) {
+	tests := []struct {
+		role   models.Role
+		status int
+	}{
+		{models.RoleEmployee, http.StatusForbidden},
+		{models.RoleManager, http.StatusOK},
+		{models.RoleAdmin, http.StatusOK},
+	}
+	forEachDriver(t, func(t *testing.T, ts *testServer) {
+		for i, tt := range tests {
+			t.Run(string(tt.role), func(t *testing.T) {
+				u := models.User{PassSerie: "5000", PassNumber: "00000" + itoa(i)}
+				_, token := ts.addUser(models.DefaultOrganizationID, u, tt.role)
+				ts.expect(ts.do(token, http.MethodGet, "/tags", nil), tt.status, nil)
+			})
+		}
+	})
+}
+
+func TestWorklogGroups(t *testing.T) {
+	day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -3)
+	forEachDriver(t, func(t *testing.T, ts *testServer) {
+		id, token := ts.addUser(models.DefaultOrganizationID, admin, models.RoleAdmin)
+		start, end := day.Add(22*time.Hour), day.Add(30*time.Hour)
+		if err := ts.db.AddTask(orgCtx(models.DefaultOrganizationID), &models.Task{UserID: id, StartTime: start, EndTime: &end}); err != nil {
+			t.Fatal(err)
+		}
+		path := "/users/tasks?user_id=" + itoa(id) +
+			"&start_date=" + day.Format(time.RFC3339) + "&end_date=" + day.AddDate(0, 0, 2).Format(time.RFC3339)
+
+		var report models.WorklogReport
+		ts.expect(ts.do(token, http.MethodGet, path+"&group_by=day", nil), http.StatusOK, &report)
+		want := []models.WorklogGroup{
+			{Key: day.Format("2006-01-02"), Duration: models.Duration{Seconds: 7200, Hours: 2}},
+			{Key: day.AddDate(0, 0, 1).Format("2006-01-02"), Duration: models.Duration{Seconds: 21600, Hours: 6}},
+		}
+		if len(report.Groups) != len(want) || report.Groups[0] != want[0] || report.Groups[1] != want[1] {
+			t.Fatalf("groups = %+v, want %+v", report.Groups, want)
+		}
+		if report.Total.Seconds != 28800 {
+			t.Errorf("total = %+v, want 8h", report.Total)
+		}
+
+		ts.expect(ts.do(token, http.MethodGet, path+"&group_by=year", nil), http.StatusBadRequest, nil)
+		ts.expect(ts.do(token, http.MethodGet, path+"&group_by=day&tz=Mars/Olympus", nil), http.StatusBadRequest, nil)
+	})
+}
+
+func TestTaskStateMachine(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, ts *testServer) {
+		_, token := ts.addUser(models.DefaultOrganizationID, models.User{PassSerie: "6000", PassNumber: "000001"}, models.RoleEmployee)
+		start := func() string {
+			var started struct {
+				Task models.Task `json:"task"`
+			}
+			ts.expect(ts.do(token, http.MethodPost, "/tasks/start", map[string]string{"description": "work"}), http.StatusOK, &started)
+			return "/tasks/" + itoa(started.Task.ID)
+		}
+		task := start()
+
+		steps := []struct {
+			name   string
+			path   string
+			status int
+		}{
+			{"pause running", task + "/pause", http.StatusOK},
+			{"pause paused", task + "/pause", http.StatusConflict},
+			{"resume paused", task + "/resume", http.StatusOK},
+			{"resume running", task + "/resume", http.StatusConflict},
+			{"pause again", task + "/pause", http.StatusOK},
+			{"stop paused", task + "/stop", http.StatusOK},
+			{"stop stopped", task + "/stop", http.StatusConflict},
+			{"pause stopped", task + "/pause", http.StatusConflict},
+			{"resume stopped", task + "/resume", http.StatusConflict},
+			{"stop unknown", "/tasks/999/stop", http.StatusNotFound},
+			{"pause unknown", "/tasks/999/pause", http.StatusNotFound},
+			{"resume unknown", "/tasks/999/resume", http.StatusNotFound},
+		}
+		for _, step := range steps {
+			t.Run(step.name, func(t *testing.T) {
+				ts.expect(ts.do(token, http.MethodPost, step.path, nil), step.status, nil)
+			})
+		}
+
+		var stopped models.Task
+		ts.expect(ts.do(token, http.MethodGet, task, nil), http.StatusOK, &stopped)
+		if stopped.EndTime == nil || len(stopped.Segments) != 2 {
+			t.Fatalf("task %+v, want it stopped with 2 segments", stopped)
+		}
+		for _, segment := range stopped.Segments {
+			if segment.EndTime == nil {
+				t.Fatalf("segment %+v left open", segment)
+			}
+		}
+
+		// a paused task does not hold the running slot, resuming it does
+		paused := start()
+		ts.expect(ts.do(token, http.MethodPost, paused+"/pause", nil), http.StatusOK, nil)
+		running := start()
+		ts.expect(ts.do(token, http.MethodPost, paused+"/resume", nil), http.StatusConflict, nil)
+		ts.expect(ts.do(token, http.MethodPost, running+"/stop", nil), http.StatusOK, nil)
+		ts.expect(ts.do(token, http.MethodPost, paused+"/resume", nil), http.StatusOK, nil)
+	})
+}
+
+func TestTaskStartPolicy(t *testing.T) {
+	for _, policy := range []string{configs.TaskStartReject, configs.TaskStartStopRunning} {
+		t.Run(policy, func(t *testing.T) {
+			forEachDriver(t, func(t *testing.T, ts *testServer) {
+				ts.srv.cfg.TaskStartPolicy = policy
+				userID, token := ts.addUser(models.DefaultOrganizationID, models.User{PassSerie: "6000", PassNumber: "000002"}, models.RoleEmployee)
+				active := "/users/" + itoa(userID) + "/tasks/active"
+				start := func(desc string, status int) int {
+					var started struct {
+						Task models.Task `json:"task"`
+					}
+					ts.expect(ts.do(token, http.MethodPost, "/tasks/start", map[string]string{"description": desc}), status, &started)
+					return started.Task.ID
+				}
+				activeID := func() int {
+					var task models.ActiveTask
+					ts.expect(ts.do(token, http.MethodGet, active, nil), http.StatusOK, &task)
+					if task.EndTime != nil || task.ElapsedSeconds < 0 {
+						t.Fatalf("active task = %+v, want it running", task)
+					}
+					return task.ID
+				}
+
+				ts.expect(ts.do(token, http.MethodGet, active, nil), http.StatusNotFound, nil)
+				first := start("first", http.StatusOK)
+				if id := activeID(); id != first {
+					t.Fatalf("active task %d, want %d", id, first)
+				}
+
+				var stopped models.Task
+				if policy == configs.TaskStartReject {
+					ts.expect(ts.do(token, http.MethodPost, "/tasks/start", map[string]string{"description": "second"}), http.StatusConflict, nil)
+					if id := activeID(); id != first {
+						t.Fatalf("active task %d after the refused start, want %d", id, first)
+					}
+					ts.expect(ts.do(token, http.MethodPost, "/tasks/"+itoa(first)+"/stop", nil), http.StatusOK, nil)
+				} else {
+					second := start("second", http.StatusOK)
+					if id := activeID(); id != second {
+						t.Fatalf("active task %d, want the second %d", id, second)
+					}
+					ts.expect(ts.do(token, http.MethodPost, "/tasks/"+itoa(second)+"/stop", nil), http.StatusOK, nil)
+				}
+				ts.expect(ts.do(token, http.MethodGet, "/tasks/"+itoa(first), nil), http.StatusOK, &stopped)
+				if stopped.EndTime == nil {
+					t.Fatalf("first task = %+v, want it stopped", stopped)
+				}
+				ts.expect(ts.do(token, http.MethodGet, active, nil), http.StatusNotFound, nil)
+			})
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+	"time-tracker/internal/auth"
+	"time-tracker/internal/models"
+)
+
+func TestOtherOrganizationIsNotFound(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, ts *testServer) {
+		ctx := orgCtx(models.DefaultOrganizationID)
+		userA, _ := ts.addUser(models.DefaultOrganizationID, models.User{PassSerie: "1000", PassNumber: "000001"}, models.RoleEmployee)
+		client := models.Client{Name: "Client A"}
+		if err := ts.db.AddClient(ctx, &client); err != nil {
+			t.Fatal(err)
+		}
+		project := models.Project{ClientID: &client.ID, Name: "Project A"}
+		if err := ts.db.AddProject(ctx, &project); err != nil {
+			t.Fatal(err)
+		}
+		start := time.Now().UTC().Add(-3 * time.Hour)
+		end := start.Add(time.Hour)
+		task := models.Task{UserID: userA, ProjectID: &project.ID, StartTime: start, EndTime: &end}
+		if err := ts.db.AddTask(ctx, &task); err != nil {
+			t.Fatal(err)
+		}
+		running := models.Task{UserID: userA}
+		if err := ts.db.AddStartTask(ctx, &running, false); err != nil {
+			t.Fatal(err)
+		}
+		team := models.Team{Name: "Team A", LeadID: &userA}
+		if err := ts.db.AddTeam(ctx, &team); err != nil {
+			t.Fatal(err)
+		}
+		key := models.APIKey{UserID: userA, Name: "key", Prefix: "tt_a"}
+		if err := ts.db.AddAPIKey(ctx, &key, "hash-a"); err != nil {
+			t.Fatal(err)
+		}
+
+		other := models.Organization{Name: "Other"}
+		if err := ts.db.AddOrganization(context.Background(), &other); err != nil {
+			t.Fatal(err)
+		}
+		userB, token := ts.addUser(other.ID, models.User{PassSerie: "2000", PassNumber: "000002"}, models.RoleAdmin)
+
+		user, taskID, runningID := "/users/"+itoa(userA), "/tasks/"+itoa(task.ID), "/tasks/"+itoa(running.ID)
+		day := start.Format("2006-01-02")
+		tests := []struct {
+			method string
+			path   string
+			body   any
+		}{
+			{http.MethodPut, user, map[string]string{"surname": "Changed"}},
+			{http.MethodDelete, user, nil},
+			{http.MethodPut, user + "/password", map[string]string{"password": "a long password"}},
+			{http.MethodPut, user + "/role", map[string]string{"role": "manager"}},
+			{http.MethodPost, user + "/api-keys", map[string]string{"name": "stolen"}},
+			{http.MethodGet, user + "/api-keys", nil},
+			{http.MethodDelete, user + "/api-keys/" + itoa(key.ID), nil},
+			{http.MethodDelete, "/users/" + itoa(userB) + "/api-keys/" + itoa(key.ID), nil},
+			{http.MethodGet, user + "/tasks/active", nil},
+			{http.MethodGet, user + "/conflicts", nil},
+			{http.MethodGet, user + "/timesheet.pdf?month=" + day[:7], nil},
+			{http.MethodPost, user + "/calendar/token", nil},
+			{http.MethodGet, "/users/tasks?format=csv&user_id=" + itoa(userA) + "&start_date=" + day + "T00:00:00Z&end_date=" + day + "T23:59:59Z", nil},
+			{http.MethodGet, taskID, nil},
+			{http.MethodPatch, taskID, map[string]string{"description": "changed"}},
+			{http.MethodPut, taskID + "/tags", map[string][]string{"tags": {"changed"}}},
+			{http.MethodPost, taskID + "/resume", nil},
+			{http.MethodPost, runningID + "/pause", nil},
+			{http.MethodPost, runningID + "/stop", nil},
+			{http.MethodDelete, taskID, nil},
+			{http.MethodGet, "/clients/" + itoa(client.ID), nil},
+			{http.MethodPut, "/clients/" + itoa(client.ID), map[string]string{"name": "Changed"}},
+			{http.MethodDelete, "/clients/" + itoa(client.ID), nil},
+			{http.MethodGet, "/projects/" + itoa(project.ID), nil},
+			{http.MethodPut, "/projects/" + itoa(project.ID), map[string]string{"name": "Changed"}},
+			{http.MethodDelete, "/projects/" + itoa(project.ID), nil},
+			{http.MethodGet, "/teams/" + itoa(team.ID), nil},
+			{http.MethodPut, "/teams/" + itoa(team.ID), map[string]string{"name": "Changed"}},
+			{http.MethodPut, "/teams/" + itoa(team.ID) + "/members/" + itoa(userB), nil},
+			{http.MethodDelete, "/teams/" + itoa(team.ID) + "/members/" + itoa(userA), nil},
+			{http.MethodGet, "/teams/" + itoa(team.ID) + "/worklogs?start_date=" + day + "T00:00:00Z&end_date=" + day + "T23:59:59Z", nil},
+			{http.MethodDelete, "/teams/" + itoa(team.ID), nil},
+		}
+		for _, tt := range tests {
+			t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+				rec := ts.do(token, tt.method, tt.path, tt.body)
+				if rec.Code != http.StatusNotFound {
+					t.Fatalf("status %d, want 404: %s", rec.Code, rec.Body)
+				}
+			})
+		}
+
+		// the lists of b leave the rows of a out
+		var users usersResponse
+		ts.expect(ts.do(token, http.MethodGet, "/users?page=1&page_size=100", nil), http.StatusOK, &users)
+		if len(users.Users) != 1 {
+			t.Fatalf("listed %d users, want only the admin of b", len(users.Users))
+		}
+	})
+}
+
+func TestLoginAcrossOrganizations(t *testing.T) {
+	forEachDriver(t, func(t *testing.T, ts *testServer) {
+		other := models.Organization{Name: "Other"}
+		if err := ts.db.AddOrganization(context.Background(), &other); err != nil {
+			t.Fatal(err)
+		}
+		_, adminB := ts.addUser(other.ID, models.User{PassSerie: "2000", PassNumber: "000002"}, models.RoleAdmin)
+		userA, _ := ts.addUser(models.DefaultOrganizationID, models.User{PassSerie: "1111", PassNumber: "111111"}, models.RoleEmployee)
+		hash, err := auth.HashPassword("password of a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ts.db.SetUserPassword(orgCtx(models.DefaultOrganizationID), userA, hash); err != nil {
+			t.Fatal(err)
+		}
+
+		// the passport of a user of the default organization is free in another one
+		var userB models.User
+		ts.expect(ts.do(adminB, http.MethodPost, "/create", map[string]string{"passport_number": "1111 111111", "password": "password of b"}), http.StatusOK, &userB)
+		ts.expect(ts.do(adminB, http.MethodPost, "/create", map[string]string{"passport_number": "1111 111111"}), http.StatusConflict, nil)
+
+		loggedIn := func(password string, org int) int {
+			t.Helper()
+			var out struct {
+				AccessToken string `json:"access_token"`
+			}
+			body := map[string]any{"passport_number": "1111 111111", "password": password, "organization_id": org}
+			ts.expect(ts.do("", http.MethodPost, "/login", body), http.StatusOK, &out)
+			id, err := auth.ParseToken(testSecret, out.AccessToken)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return id
+		}
+		if id := loggedIn("password of a", 0); id != userA {
+			t.Fatalf("logged in as %d, want %d", id, userA)
+		}
+		if id := loggedIn("password of b", 0); id != userB.Id {
+			t.Fatalf("logged in as %d, want %d", id, userB.Id)
+		}
+		ts.expect(ts.do("", http.MethodPost, "/login", map[string]any{"passport_number": "1111 111111", "password": "password of b", "organization_id": models.DefaultOrganizationID}), http.StatusUnauthorized, nil)
+
+		// with one password for both the organization must be named
+		if err := ts.db.SetUserPassword(orgCtx(other.ID), userB.Id, hash); err != nil {
+			t.Fatal(err)
+		}
+		ts.expect(ts.do("", http.MethodPost, "/login", map[string]string{"passport_number": "1111 111111", "password": "password of a"}), http.StatusBadRequest, nil)
+		if id := loggedIn("password of a", other.ID); id != userB.Id {
+			t.Fatalf("logged in as %d, want %d", id, userB.Id)
+		}
+	})
+}
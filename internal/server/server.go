@@ -1,7 +1,16 @@
 package server
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"strings"
+	"time"
 	"time-tracker/configs"
+	"time-tracker/internal/enrich"
 	"time-tracker/internal/storage"
 
 	"github.com/gin-gonic/gin"
@@ -9,29 +18,107 @@ import (
 )
 
 type Server struct {
-	cfg    configs.ServerConfig
-	r      *gin.Engine
-	db     storage.Database
-	logger *zap.SugaredLogger
-}
-
-func New(cfg configs.ServerConfig, db storage.Database, log *zap.SugaredLogger) *Server {
-	return &Server{
-		r:      gin.Default(),
-		cfg:    cfg,
-		db:     db,
-		logger: log,
+	cfg      configs.ServerConfig
+	r        *gin.Engine
+	srv      *http.Server
+	db       storage.Database
+	enricher enrich.Enricher
+	logger   *zap.SugaredLogger
+}
+
+func New(cfg configs.ServerConfig, db storage.Database, enricher enrich.Enricher, log *zap.SugaredLogger) *Server {
+	r := gin.New()
+	s := &Server{
+		r: r,
+		srv: &http.Server{
+			Addr:    cfg.Host + ":" + cfg.Port,
+			Handler: r,
+		},
+		cfg:      cfg,
+		db:       db,
+		enricher: enricher,
+		logger:   log,
+	}
+	r.Use(gin.LoggerWithFormatter(accessLog), s.recovery)
+	return s
+}
+
+// accessLog formats a request like gin's default logger, with the secret
+// of a calendar feed URL left out.
+func accessLog(param gin.LogFormatterParams) string {
+	var statusColor, methodColor, resetColor string
+	if param.IsOutputColor() {
+		statusColor = param.StatusCodeColor()
+		methodColor = param.MethodColor()
+		resetColor = param.ResetColor()
+	}
+	if param.Latency > time.Minute {
+		param.Latency = param.Latency.Truncate(time.Second)
+	}
+	return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v\n%s",
+		param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		statusColor, param.StatusCode, resetColor,
+		param.Latency,
+		param.ClientIP,
+		methodColor, param.Method, resetColor,
+		redactToken(param.Path),
+		param.ErrorMessage,
+	)
+}
+
+// redactToken hides the token query parameter of a logged path.
+func redactToken(path string) string {
+	base, query, ok := strings.Cut(path, "?")
+	if !ok {
+		return path
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		// a token may hide in what did not parse
+		return base + "?REDACTED"
 	}
+	if !values.Has("token") {
+		return path
+	}
+	values.Set("token", "REDACTED")
+	return base + "?" + values.Encode()
+}
+
+// recovery answers 500 when a handler panics, like gin.Recovery. A panic
+// with http.ErrAbortHandler is passed on, net/http then cuts the
+// connection of a response that cannot be finished.
+func (s *Server) recovery(ctx *gin.Context) {
+	defer func() {
+		err := recover()
+		if err == nil {
+			return
+		}
+		if err == http.ErrAbortHandler {
+			panic(err)
+		}
+		s.logger.Errorw("handler panicked", "error", err, "stack", string(debug.Stack()))
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+	}()
+	ctx.Next()
 }
 
+// Start serves requests until Shutdown is called.
 func (s *Server) Start() error {
 	s.prepare()
-	addr := s.cfg.Host + ":" + s.cfg.Port
-	s.logger.Infow("server ready to start", "addr", addr)
-	s.r.Run(addr)
+	s.logger.Infow("server ready to start", "addr", s.srv.Addr)
+	err := s.srv.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
 	return nil
 }
 
+// Shutdown stops accepting connections and waits for in-flight requests
+// until they finish or ctx is done.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.srv.Shutdown(ctx)
+}
+
 func (s *Server) prepare() {
 	s.prepareRoutes()
 	// ... //
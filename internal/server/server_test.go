@@ -0,0 +1,237 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+	"time-tracker/configs"
+	"time-tracker/internal/auth"
+	"time-tracker/internal/enrich"
+	"time-tracker/internal/models"
+	"time-tracker/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const testSecret = "test-secret-that-is-long-enough-for-hs256"
+
+// drivers are the backends the handlers are tested against, they must
+// behave the same.
+var drivers = []string{storage.DriverMemory, storage.DriverSQLite}
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	// the migrations are found relative to the repository root
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// openDB returns an empty, connected database of driver, SQLite lives in
+// a temporary file.
+func openDB(t *testing.T, driver string) storage.Database {
+	t.Helper()
+	db, err := storage.New(configs.DatabaseConfig{
+		Driver: driver,
+		Name:   filepath.Join(t.TempDir(), "test.db"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Disconnect() })
+	return db
+}
+
+type testServer struct {
+	t        *testing.T
+	db       storage.Database
+	enricher *enrich.Stub
+	srv      *Server
+}
+
+func newTestServer(t *testing.T, driver string) *testServer {
+	t.Helper()
+	db := openDB(t, driver)
+	stub := &enrich.Stub{People: map[string]enrich.Info{
+		"1111 111111": {Surname: "Ivanov", Name: "Ivan", Patronymic: "Ivanovich", Address: "Moscow"},
+	}}
+	cfg := configs.ServerConfig{
+		JWTSecret:       testSecret,
+		TokenTTL:        time.Hour,
+		TaskStartPolicy: configs.TaskStartReject,
+		UserEnrichment:  configs.UserEnrichmentSync,
+	}
+	s := New(cfg, db, stub, zap.NewNop().Sugar())
+	s.prepare()
+	return &testServer{t: t, db: db, enricher: stub, srv: s}
+}
+
+// forEachDriver runs test against a fresh server of every driver.
+func forEachDriver(t *testing.T, test func(t *testing.T, ts *testServer)) {
+	for _, driver := range drivers {
+		t.Run(driver, func(t *testing.T) {
+			test(t, newTestServer(t, driver))
+		})
+	}
+}
+
+// addUser stores u in org with role and returns a bearer token of it.
+func (ts *testServer) addUser(org int, u models.User, role models.Role) (int, string) {
+	ts.t.Helper()
+	ctx := storage.WithOrg(context.Background(), org)
+	if _, err := ts.db.AddUser(ctx, &u); err != nil {
+		ts.t.Fatal(err)
+	}
+	if role != models.RoleEmployee {
+		if err := ts.db.SetUserRole(ctx, u.Id, role, nil); err != nil {
+			ts.t.Fatal(err)
+		}
+	}
+	token, _, err := auth.NewToken(testSecret, u.Id, time.Now(), time.Hour)
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	return u.Id, token
+}
+
+// do sends a request with body encoded as JSON, a nil body sends none.
+func (ts *testServer) do(token, method, path string, body any) *httptest.ResponseRecorder {
+	ts.t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			ts.t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	ts.srv.r.ServeHTTP(rec, req)
+	return rec
+}
+
+// expect fails the test unless rec has status and decodes its body into out.
+func (ts *testServer) expect(rec *httptest.ResponseRecorder, status int, out any) {
+	ts.t.Helper()
+	if rec.Code != status {
+		ts.t.Fatalf("status %d, want %d: %s", rec.Code, status, rec.Body)
+	}
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			ts.t.Fatalf("decode %s: %v", rec.Body, err)
+		}
+	}
+}
+
+// orgCtx scopes storage calls of a test to org.
+func orgCtx(org int) context.Context {
+	return storage.WithOrg(context.Background(), org)
+}
+
+func itoa(n int) string {
+	return strconv.Itoa(n)
+}
+
+func TestShutdownDrainsRequests(t *testing.T) {
+	// a free port, net/http cannot tell which one it picked for :0
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	host, port, _ := net.SplitHostPort(addr)
+
+	s := New(configs.ServerConfig{Host: host, Port: port, JWTSecret: testSecret}, openDB(t, storage.DriverMemory), &enrich.Stub{}, zap.NewNop().Sugar())
+	started, release := make(chan struct{}), make(chan struct{})
+	s.r.GET("/slow", func(ctx *gin.Context) {
+		close(started)
+		<-release
+		ctx.String(http.StatusOK, "done")
+	})
+	stopped := make(chan error, 1)
+	go func() { stopped <- s.Start() }()
+
+	type response struct {
+		status int
+		body   string
+		err    error
+	}
+	responses := make(chan response, 1)
+	go func() {
+		// the server may not listen yet
+		for {
+			resp, err := http.Get("http://" + addr + "/slow")
+			if err != nil {
+				if errors.Is(err, syscall.ECONNREFUSED) {
+					time.Sleep(10 * time.Millisecond)
+					continue
+				}
+				responses <- response{err: err}
+				return
+			}
+			body, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			responses <- response{status: resp.StatusCode, body: string(body), err: err}
+			return
+		}
+	}()
+	select {
+	case <-started:
+	case r := <-responses:
+		t.Fatalf("request ended before it started: %+v", r)
+	case <-time.After(5 * time.Second):
+		t.Fatal("request never reached the handler")
+	}
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- s.Shutdown(context.Background()) }()
+	// new connections are refused once the listener is closed, while the
+	// request in flight holds up Shutdown
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			break
+		}
+		conn.Close()
+		if time.Now().After(deadline) {
+			t.Fatal("server still accepts connections after Shutdown")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	select {
+	case err := <-shutdown:
+		t.Fatalf("Shutdown returned %v with a request in flight", err)
+	default:
+	}
+
+	close(release)
+	if r := <-responses; r.err != nil || r.status != http.StatusOK || r.body != "done" {
+		t.Fatalf("in-flight request got %+v, want 200 done", r)
+	}
+	if err := <-shutdown; err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if err := <-stopped; err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+}
@@ -1,26 +1,199 @@
 package storage
 
-import "time-tracker/internal/models"
+import (
+	"context"
+	"fmt"
+	"time"
+	"time-tracker/configs"
+	"time-tracker/internal/models"
+	"time-tracker/internal/utils"
+)
 
+// Supported values of DB_DRIVER.
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite3"
+	DriverMemory   = "memory"
+)
+
+// Database is scoped to the organization set by WithOrg: rows of other
+// organizations look like missing rows and a call without an organization
+// fails with utils.ErrNoOrganization. GetCredentials, GetPrincipal,
+// UseAPIKey and OrganizationDatabase work across organizations, they are
+// how a request finds its organization. So does the enrichment queue
+// apart from AddPendingUser.
 type Database interface {
 	Connect() error
 	Disconnect() error
 
+	OrganizationDatabase
 	UserDatabase
 	TaskDatabase
+	ProjectDatabase
+	TeamDatabase
+	EnrichmentDatabase
+}
+
+// OrganizationDatabase manages the tenants, name clashes return
+// utils.ErrAlreadyExists.
+type OrganizationDatabase interface {
+	AddOrganization(ctx context.Context, o *models.Organization) error
+	GetOrganizations(ctx context.Context) ([]models.Organization, error)
 }
 
 type UserDatabase interface {
 	// get users with filters and pagination
-	GetUsers(req models.GetUsersRequest) (map[int]models.User, error)
-	GetUserByID(id int) (*models.User, error)
-	GetUserWorklogs(req *models.GetUserWorklogsRequest) ([]models.Worklog, error)
-	AddUser(u *models.User) (*models.User, error)
-	DeleteUser(id int) error
-	UpdateUser(u *models.User) error
+	GetUsers(ctx context.Context, req models.GetUsersRequest) (map[int]models.User, error)
+	GetUserByID(ctx context.Context, id int) (*models.User, error)
+	GetUserWorklogs(ctx context.Context, req *models.GetUserWorklogsRequest) ([]models.Worklog, error)
+	// GetUserWorklogGroups sums the same worklogs by req.GroupBy with a grand total
+	GetUserWorklogGroups(ctx context.Context, req *models.GetUserWorklogsRequest) (*models.WorklogReport, error)
+	// EachUserTimeEntry streams the segments of the same worklogs to fn cut
+	// at midnight, one day of a segment at a time in the order they started.
+	// An error from fn stops the stream.
+	EachUserTimeEntry(ctx context.Context, req *models.GetUserWorklogsRequest, fn func(models.TimeEntry) error) error
+	// EachUserInterval is EachUserTimeEntry with whole segments, an entry
+	// may span several days.
+	EachUserInterval(ctx context.Context, req *models.GetUserWorklogsRequest, fn func(models.TimeEntry) error) error
+	// SetCalendarTokenHash replaces the hash of the user's calendar feed
+	// token, sql.ErrNoRows is returned for an unknown user.
+	SetCalendarTokenHash(ctx context.Context, userID int, hash string) error
+	// GetCalendarTokenHash returns sql.ErrNoRows when the user has no token.
+	GetCalendarTokenHash(ctx context.Context, userID int) (string, error)
+	// SetUserPassword stores the password hash of the user, sql.ErrNoRows
+	// is returned for an unknown user.
+	SetUserPassword(ctx context.Context, userID int, hash string) error
+	// GetCredentials finds the users of a passport, one per organization
+	// at most, ordered by id. No user gives an empty list.
+	GetCredentials(ctx context.Context, passSerie, passNumber string) ([]models.Credential, error)
+	// GetPrincipal returns the organization, role and team of the user,
+	// sql.ErrNoRows for an unknown user.
+	GetPrincipal(ctx context.Context, userID int) (*models.Principal, error)
+	// SetUserRole assigns the role and manager of the user. A nil managerID
+	// removes the user from any team, an unknown one or the user itself
+	// gives utils.ErrInvalidManager.
+	SetUserRole(ctx context.Context, userID int, role models.Role, managerID *int) error
+	// AddAPIKey stores a new key of k.UserID by its hash and fills k.ID and
+	// k.CreatedAt, sql.ErrNoRows is returned for an unknown user.
+	AddAPIKey(ctx context.Context, k *models.APIKey, hash string) error
+	GetAPIKeys(ctx context.Context, userID int) ([]models.APIKey, error)
+	// DeleteAPIKey revokes a key of the user, sql.ErrNoRows if the user has no such key
+	DeleteAPIKey(ctx context.Context, userID, id int) error
+	// UseAPIKey records that the key with hash was used at now and returns
+	// its user, sql.ErrNoRows for an unknown or revoked key.
+	UseAPIKey(ctx context.Context, hash string, now time.Time) (int, error)
+	// AddUser returns utils.ErrAlreadyExists when the passport is in use
+	// in the organization
+	AddUser(ctx context.Context, u *models.User) (*models.User, error)
+	// DeleteUser and UpdateUser return sql.ErrNoRows for an unknown user
+	DeleteUser(ctx context.Context, id int) error
+	UpdateUser(ctx context.Context, u *models.User) error
 }
 
 type TaskDatabase interface {
-	AddStartTask(t *models.Task) error
-	AddEndTask(id int) error
+	// AddStartTask starts t and fills its ID and StartTime. If the user
+	// already has a running task it is stopped when stopRunning is set,
+	// otherwise utils.ErrTaskAlreadyRunning is returned. An unknown
+	// t.ProjectID gives utils.ErrProjectNotFound.
+	AddStartTask(ctx context.Context, t *models.Task, stopRunning bool) error
+	// AddEndTask stops a running or paused task, utils.ErrTaskStopped if
+	// it is stopped already
+	AddEndTask(ctx context.Context, id int) error
+	// PauseTask closes the open segment, utils.ErrTaskNotRunning if there is none
+	PauseTask(ctx context.Context, id int) error
+	// ResumeTask opens a new segment of a paused task, the user's running
+	// task is handled the same way as in AddStartTask
+	ResumeTask(ctx context.Context, id int, stopRunning bool) error
+	// GetActiveTask returns sql.ErrNoRows if the user has no running task
+	GetActiveTask(ctx context.Context, userID int) (*models.Task, error)
+	// AddTask stores a finished entry with explicit StartTime and EndTime.
+	// It returns utils.ErrInvalidInterval, utils.ErrFutureTime or
+	// utils.ErrOverlap when the entry does not fit the user's other entries.
+	AddTask(ctx context.Context, t *models.Task) error
+	// GetTaskByID returns the task with segments and tags, sql.ErrNoRows if there is none
+	GetTaskByID(ctx context.Context, id int) (*models.Task, error)
+	// UpdateTask applies upd with the same checks as AddTask
+	UpdateTask(ctx context.Context, id int, upd models.TaskUpdate) (*models.Task, error)
+	DeleteTask(ctx context.Context, id int) error
+	// GetUserConflicts lists the overlapping segments of the user,
+	// left over from the time before overlaps were rejected
+	GetUserConflicts(ctx context.Context, userID int) ([]models.Conflict, error)
+	// ImportTasks inserts the tasks in one transaction, creating missing
+	// clients, projects and tags. Conflicts and invalid intervals are added
+	// to the report. Nothing is kept for a dry run or when the report has
+	// any issue, including ones added by the caller.
+	ImportTasks(ctx context.Context, tasks []models.ImportTask, report *models.ImportReport) error
+	// SetTaskTags replaces the tags of a task, sql.ErrNoRows if there is no such task
+	SetTaskTags(ctx context.Context, taskID int, tags []string) error
+	GetTags(ctx context.Context) ([]string, error)
+}
+
+// ProjectDatabase manages clients and the projects billed to them.
+// Lookups of missing rows return sql.ErrNoRows, name clashes
+// utils.ErrAlreadyExists and deleting rows still referenced utils.ErrInUse.
+type ProjectDatabase interface {
+	AddClient(ctx context.Context, c *models.Client) error
+	GetClients(ctx context.Context) ([]models.Client, error)
+	GetClientByID(ctx context.Context, id int) (*models.Client, error)
+	UpdateClient(ctx context.Context, c *models.Client) error
+	DeleteClient(ctx context.Context, id int) error
+
+	// AddProject and UpdateProject return utils.ErrClientNotFound for an unknown client
+	AddProject(ctx context.Context, p *models.Project) error
+	GetProjects(ctx context.Context, req models.GetProjectsRequest) ([]models.Project, error)
+	GetProjectByID(ctx context.Context, id int) (*models.Project, error)
+	UpdateProject(ctx context.Context, p *models.Project) error
+	DeleteProject(ctx context.Context, id int) error
+}
+
+// TeamDatabase manages teams of users and their reports. Lookups of
+// missing rows return sql.ErrNoRows and name clashes utils.ErrAlreadyExists.
+type TeamDatabase interface {
+	// AddTeam and UpdateTeam return utils.ErrLeadNotFound for an unknown lead
+	AddTeam(ctx context.Context, t *models.Team) error
+	GetTeams(ctx context.Context) ([]models.Team, error)
+	GetTeamByID(ctx context.Context, id int) (*models.Team, error)
+	UpdateTeam(ctx context.Context, t *models.Team) error
+	DeleteTeam(ctx context.Context, id int) error
+
+	// AddTeamMember does nothing when the user is a member already,
+	// sql.ErrNoRows is returned for an unknown team or user
+	AddTeamMember(ctx context.Context, teamID, userID int) error
+	RemoveTeamMember(ctx context.Context, teamID, userID int) error
+	// GetTeamWorklogs sums the time of all members per member and per project
+	GetTeamWorklogs(ctx context.Context, teamID int, req *models.GetTeamWorklogsRequest) (*models.TeamReport, error)
+}
+
+// EnrichmentDatabase is a durable queue of users waiting for their names
+// and address from the people info service. Jobs are claimed for a lease,
+// a job of a worker that died is claimed again once its lease is over.
+type EnrichmentDatabase interface {
+	// AddPendingUser stores u as models.EnrichmentPending and queues its
+	// lookup to start right away
+	AddPendingUser(ctx context.Context, u *models.User) (*models.User, error)
+	// ClaimEnrichmentJobs returns up to limit jobs due at now, the longest
+	// waiting first, and hides them until now plus lease
+	ClaimEnrichmentJobs(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.EnrichmentJob, error)
+	// CompleteEnrichment fills in the names and address of u.Id, marks it
+	// models.EnrichmentDone and drops its job. When the user is not pending
+	// any more the job is dropped all the same and sql.ErrNoRows returned.
+	CompleteEnrichment(ctx context.Context, u *models.User) error
+	// RetryEnrichment puts the job of the user off until at
+	RetryEnrichment(ctx context.Context, userID int, at time.Time, reason string) error
+	// FailEnrichment marks the user models.EnrichmentFailed and drops its
+	// job, like CompleteEnrichment for a user not pending any more
+	FailEnrichment(ctx context.Context, userID int) error
+}
+
+// New returns the backend selected by cfg.Driver.
+func New(cfg configs.DatabaseConfig) (Database, error) {
+	switch cfg.Driver {
+	case DriverPostgres:
+		return NewPostgres(cfg), nil
+	case DriverSQLite:
+		return NewSQLite(cfg), nil
+	case DriverMemory:
+		return NewMemory(), nil
+	}
+	return nil, fmt.Errorf(utils.ErrUnknownDriver, cfg.Driver)
 }
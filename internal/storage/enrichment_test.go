@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+	"time-tracker/configs"
+	"time-tracker/internal/models"
+)
+
+const testLease = 5 * time.Minute
+
+func mustAddPendingUser(t *testing.T, ctx context.Context, db Database, serie, number string) int {
+	t.Helper()
+	u, err := db.AddPendingUser(ctx, &models.User{PassSerie: serie, PassNumber: number})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Id
+}
+
+func mustClaim(t *testing.T, db Database, now time.Time, limit int) []models.EnrichmentJob {
+	t.Helper()
+	jobs, err := db.ClaimEnrichmentJobs(context.Background(), now, testLease, limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jobs
+}
+
+// setEnrichmentStatus changes the status of the user without touching its
+// job, the way a user finished by another worker looks.
+func setEnrichmentStatus(t *testing.T, db Database, userID int, status string) {
+	t.Helper()
+	switch s := db.(type) {
+	case *Memory:
+		u := s.users[userID]
+		u.EnrichmentStatus = status
+		s.users[userID] = u
+	case *SQLite:
+		query := `UPDATE users SET enrichment_status = $1 WHERE id = $2`
+		if _, err := s.sql.Exec(query, status, userID); err != nil {
+			t.Fatal(err)
+		}
+	default:
+		t.Fatalf("cannot set the enrichment status of %T", db)
+	}
+}
+
+func TestClaimEnrichmentLease(t *testing.T) {
+	forEachStore(t, func(t *testing.T, db Database) {
+		ctx := defaultOrg()
+		first := mustAddPendingUser(t, ctx, db, "1234", "000001")
+		mustAddPendingUser(t, ctx, db, "1234", "000002")
+		now := time.Now().Add(time.Second)
+
+		jobs := mustClaim(t, db, now, 1)
+		if len(jobs) != 1 || jobs[0].UserID != first || jobs[0].Attempts != 1 {
+			t.Fatalf("first claim = %+v, want the job of user %d on attempt 1", jobs, first)
+		}
+		if jobs := mustClaim(t, db, now, 10); len(jobs) != 1 || jobs[0].UserID == first {
+			t.Fatalf("second claim = %+v, want only the other job", jobs)
+		}
+		if jobs := mustClaim(t, db, now.Add(testLease-time.Second), 10); len(jobs) != 0 {
+			t.Fatalf("claim within the lease = %+v, want none", jobs)
+		}
+		jobs = mustClaim(t, db, now.Add(testLease), 10)
+		if len(jobs) != 2 || jobs[0].UserID != first || jobs[0].Attempts != 2 || jobs[1].Attempts != 2 {
+			t.Fatalf("claim after the lease = %+v, want both jobs on attempt 2", jobs)
+		}
+	})
+}
+
+func TestRetryEnrichmentPutsJobOff(t *testing.T) {
+	forEachStore(t, func(t *testing.T, db Database) {
+		ctx := defaultOrg()
+		userID := mustAddPendingUser(t, ctx, db, "1234", "000001")
+		now := time.Now().Add(time.Second)
+		mustClaim(t, db, now, 10)
+
+		if err := db.RetryEnrichment(ctx, userID, now.Add(time.Minute), "unavailable"); err != nil {
+			t.Fatal(err)
+		}
+		if jobs := mustClaim(t, db, now.Add(time.Minute-time.Second), 10); len(jobs) != 0 {
+			t.Fatalf("claim before the retry = %+v, want none", jobs)
+		}
+		if jobs := mustClaim(t, db, now.Add(time.Minute), 10); len(jobs) != 1 || jobs[0].Attempts != 2 {
+			t.Fatalf("claim at the retry = %+v, want the job on attempt 2", jobs)
+		}
+	})
+}
+
+func TestClaimJobOfOtherWorker(t *testing.T) {
+	db := NewSQLite(configs.DatabaseConfig{
+		Driver: DriverSQLite,
+		Name:   filepath.Join(t.TempDir(), "test.db"),
+	})
+	if err := db.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Disconnect() })
+	ctx := defaultOrg()
+	userID := mustAddPendingUser(t, ctx, db, "1234", "000001")
+	now := time.Now().Add(time.Second)
+
+	// the job as read by a worker, before another one claimed it
+	stale := models.EnrichmentJob{UserID: userID, Attempts: 0}
+	if jobs := mustClaim(t, db, now, 10); len(jobs) != 1 {
+		t.Fatalf("claim = %+v, want one job", jobs)
+	}
+	claimed, err := claimJob(context.Background(), db.sql, stale, now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claimed {
+		t.Fatal("stale job claimed")
+	}
+	// the lease of the other worker is kept
+	if jobs := mustClaim(t, db, now.Add(testLease), 10); len(jobs) != 1 || jobs[0].Attempts != 2 {
+		t.Fatalf("claim after the lease = %+v, want the job on attempt 2", jobs)
+	}
+}
+
+func TestFinishEnrichmentOfUserNotPending(t *testing.T) {
+	tests := []struct {
+		name   string
+		finish func(ctx context.Context, db Database, userID int) error
+	}{
+		{"complete", func(ctx context.Context, db Database, userID int) error {
+			return db.CompleteEnrichment(ctx, &models.User{Id: userID, Surname: "Ivanov"})
+		}},
+		{"fail", func(ctx context.Context, db Database, userID int) error {
+			return db.FailEnrichment(ctx, userID)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forEachStore(t, func(t *testing.T, db Database) {
+				ctx := defaultOrg()
+				userID := mustAddPendingUser(t, ctx, db, "1234", "000001")
+				now := time.Now().Add(time.Second)
+				mustClaim(t, db, now, 10)
+				setEnrichmentStatus(t, db, userID, models.EnrichmentDone)
+
+				if err := tt.finish(ctx, db, userID); err != sql.ErrNoRows {
+					t.Fatalf("err = %v, want %v", err, sql.ErrNoRows)
+				}
+				if jobs := mustClaim(t, db, now.Add(testLease), 10); len(jobs) != 0 {
+					t.Fatalf("claim = %+v, want the job dropped", jobs)
+				}
+				u, err := db.GetUserByID(ctx, userID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if u.EnrichmentStatus != models.EnrichmentDone || u.Surname != "" {
+					t.Fatalf("user = %+v, want it left alone", u)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"sort"
+	"time"
+	"time-tracker/internal/models"
+	"time-tracker/internal/utils"
+)
+
+// checkEntry validates the bounds of a manually created entry.
+func checkEntry(start, end, now time.Time) error {
+	if !start.Before(end) {
+		return utils.ErrInvalidInterval
+	}
+	if end.After(now) {
+		return utils.ErrFutureTime
+	}
+	return nil
+}
+
+// retime applies upd to t, whose Segments must be loaded, and checks
+// that the segments still follow each other and do not reach the future.
+// It returns the IDs of the segments whose bounds changed, only they need
+// to be checked for overlaps again: a legacy overlap of an untouched
+// segment must not stop editing the description.
+func retime(t *models.Task, upd models.TaskUpdate, now time.Time) (map[int]bool, error) {
+	if upd.Desc != nil {
+		t.Desc = *upd.Desc
+	}
+	if len(t.Segments) == 0 || (upd.StartTime == nil && upd.EndTime == nil) {
+		return nil, nil
+	}
+	sort.Slice(t.Segments, func(i, j int) bool {
+		return t.Segments[i].StartTime.Before(t.Segments[j].StartTime)
+	})
+	moved := make(map[int]bool)
+	if upd.StartTime != nil {
+		first := &t.Segments[0]
+		if !first.StartTime.Equal(*upd.StartTime) {
+			first.StartTime = *upd.StartTime
+			moved[first.ID] = true
+		}
+		t.StartTime = *upd.StartTime
+	}
+	if upd.EndTime != nil {
+		end := *upd.EndTime
+		if end.After(now) {
+			return nil, utils.ErrFutureTime
+		}
+		last := &t.Segments[len(t.Segments)-1]
+		if last.EndTime == nil || !last.EndTime.Equal(end) {
+			last.EndTime = &end
+			moved[last.ID] = true
+		}
+		t.EndTime = &end
+	}
+	for n, segment := range t.Segments {
+		end := segmentEnd(segment, now)
+		if !segment.StartTime.Before(end) || segment.StartTime.After(now) {
+			return nil, utils.ErrInvalidInterval
+		}
+		if n > 0 && segment.StartTime.Before(segmentEnd(t.Segments[n-1], now)) {
+			return nil, utils.ErrInvalidInterval
+		}
+	}
+	return moved, nil
+}
+
+// segmentEnd is the end of the segment, an open one lasts until now.
+func segmentEnd(segment models.TaskSegment, now time.Time) time.Time {
+	if segment.EndTime == nil {
+		return now
+	}
+	return *segment.EndTime
+}
+
+// conflict describes the overlap of two segments, ok is false when they do not overlap.
+func conflict(a, b models.TaskSegment, now time.Time) (c models.Conflict, ok bool) {
+	start, end := a.StartTime, segmentEnd(a, now)
+	if b.StartTime.After(start) {
+		start = b.StartTime
+	}
+	if bEnd := segmentEnd(b, now); bEnd.Before(end) {
+		end = bEnd
+	}
+	if !start.Before(end) {
+		return models.Conflict{}, false
+	}
+	return models.Conflict{
+		TaskID:         a.TaskID,
+		SegmentID:      a.ID,
+		OtherTaskID:    b.TaskID,
+		OtherSegmentID: b.ID,
+		Start:          start,
+		End:            end,
+		Seconds:        int64(end.Sub(start).Seconds()),
+	}, true
+}
+
+func sortConflicts(conflicts []models.Conflict) {
+	sort.Slice(conflicts, func(i, j int) bool {
+		if !conflicts[i].Start.Equal(conflicts[j].Start) {
+			return conflicts[i].Start.Before(conflicts[j].Start)
+		}
+		return conflicts[i].SegmentID < conflicts[j].SegmentID
+	})
+}
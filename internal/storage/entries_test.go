@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"time-tracker/internal/models"
+	"time-tracker/internal/utils"
+)
+
+// moveSegments puts the segments of the task on [start, end) without any
+// check, the way rows from before the overlap check look.
+func moveSegments(t *testing.T, db Database, taskID int, start, end time.Time) {
+	t.Helper()
+	switch s := db.(type) {
+	case *Memory:
+		for id, segment := range s.segments {
+			if segment.TaskID == taskID {
+				segment.StartTime, segment.EndTime = start, &end
+				s.segments[id] = segment
+			}
+		}
+	case *SQLite:
+		query := `UPDATE task_segments SET start_time = $1, end_time = $2 WHERE task_id = $3`
+		if _, err := s.sql.Exec(query, start.UTC(), end.UTC(), taskID); err != nil {
+			t.Fatal(err)
+		}
+	default:
+		t.Fatalf("cannot move segments of %T", db)
+	}
+}
+
+func TestUpdateTaskWithLegacyOverlap(t *testing.T) {
+	day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -2)
+	at := func(hour, minute int) *time.Time {
+		v := day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+		return &v
+	}
+	desc := "edited"
+	tests := []struct {
+		name string
+		upd  models.TaskUpdate
+		err  error
+	}{
+		{"description only", models.TaskUpdate{Desc: &desc}, nil},
+		{"unchanged bounds", models.TaskUpdate{StartTime: at(10, 30), EndTime: at(11, 30)}, nil},
+		{"end still overlapping", models.TaskUpdate{EndTime: at(11, 45)}, utils.ErrOverlap},
+		{"start moved clear", models.TaskUpdate{StartTime: at(11, 0)}, nil},
+	}
+	forEachStore(t, func(t *testing.T, db Database) {
+		ctx := defaultOrg()
+		userID := mustAddUser(t, ctx, db, "1234", "567890")
+		first := models.Task{UserID: userID, StartTime: *at(10, 0), EndTime: at(11, 0)}
+		second := models.Task{UserID: userID, StartTime: *at(12, 0), EndTime: at(13, 0)}
+		for _, task := range []*models.Task{&first, &second} {
+			if err := db.AddTask(ctx, task); err != nil {
+				t.Fatal(err)
+			}
+		}
+		moveSegments(t, db, second.ID, *at(10, 30), *at(11, 30))
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := db.UpdateTask(ctx, second.ID, tt.upd)
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("UpdateTask: %v, want %v", err, tt.err)
+				}
+			})
+		}
+	})
+}
+
+func TestCheckEntry(t *testing.T) {
+	now := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		start, end time.Time
+		err        error
+	}{
+		{"past entry", now.Add(-2 * time.Hour), now.Add(-time.Hour), nil},
+		{"ending now", now.Add(-time.Hour), now, nil},
+		{"end before start", now.Add(-time.Hour), now.Add(-2 * time.Hour), utils.ErrInvalidInterval},
+		{"zero length", now.Add(-time.Hour), now.Add(-time.Hour), utils.ErrInvalidInterval},
+		{"end in the future", now.Add(-time.Hour), now.Add(time.Second), utils.ErrFutureTime},
+		{"wholly in the future", now.Add(time.Hour), now.Add(2 * time.Hour), utils.ErrFutureTime},
+	}
+	for _, tt := range tests {
+		if err := checkEntry(tt.start, tt.end, now); err != tt.err {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestRetime(t *testing.T) {
+	now := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+	at := func(hour, minute int) *time.Time {
+		v := time.Date(2024, 3, 4, hour, minute, 0, 0, time.UTC)
+		return &v
+	}
+	// a task worked 9:00-10:00, paused and worked again 10:30-11:00
+	stopped := func() models.Task {
+		return models.Task{ID: 1, StartTime: *at(9, 0), EndTime: at(11, 0), Segments: []models.TaskSegment{
+			{ID: 2, TaskID: 1, StartTime: *at(10, 30), EndTime: at(11, 0)},
+			{ID: 1, TaskID: 1, StartTime: *at(9, 0), EndTime: at(10, 0)},
+		}}
+	}
+	// a task running since 11:00
+	running := func() models.Task {
+		return models.Task{ID: 1, StartTime: *at(11, 0), Segments: []models.TaskSegment{
+			{ID: 1, TaskID: 1, StartTime: *at(11, 0)},
+		}}
+	}
+	desc := "edited"
+	tests := []struct {
+		name  string
+		task  models.Task
+		upd   models.TaskUpdate
+		moved []int
+		err   error
+	}{
+		{"description only", stopped(), models.TaskUpdate{Desc: &desc}, nil, nil},
+		{"earlier start", stopped(), models.TaskUpdate{StartTime: at(8, 0)}, []int{1}, nil},
+		{"later end", stopped(), models.TaskUpdate{EndTime: at(11, 30)}, []int{2}, nil},
+		{"unchanged bounds", stopped(), models.TaskUpdate{StartTime: at(9, 0), EndTime: at(11, 0)}, []int{}, nil},
+		{"end before start", stopped(), models.TaskUpdate{StartTime: at(11, 0), EndTime: at(10, 0)}, nil, utils.ErrInvalidInterval},
+		{"end before the last segment starts", stopped(), models.TaskUpdate{EndTime: at(10, 15)}, nil, utils.ErrInvalidInterval},
+		{"start past the first segment", stopped(), models.TaskUpdate{StartTime: at(10, 0)}, nil, utils.ErrInvalidInterval},
+		{"zero length", stopped(), models.TaskUpdate{EndTime: at(10, 30)}, nil, utils.ErrInvalidInterval},
+		{"end in the future", stopped(), models.TaskUpdate{EndTime: at(12, 1)}, nil, utils.ErrFutureTime},
+		{"running task started earlier", running(), models.TaskUpdate{StartTime: at(10, 0)}, []int{1}, nil},
+		{"running task started now", running(), models.TaskUpdate{StartTime: at(12, 0)}, nil, utils.ErrInvalidInterval},
+		{"running task started past now", running(), models.TaskUpdate{StartTime: at(13, 0)}, nil, utils.ErrInvalidInterval},
+		{"running task stopped", running(), models.TaskUpdate{EndTime: at(11, 30)}, []int{1}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := tt.task
+			moved, err := retime(&task, tt.upd, now)
+			if err != tt.err {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if len(moved) != len(tt.moved) {
+				t.Fatalf("moved = %v, want %v", moved, tt.moved)
+			}
+			for _, id := range tt.moved {
+				if !moved[id] {
+					t.Fatalf("moved = %v, want %v", moved, tt.moved)
+				}
+			}
+			if tt.upd.StartTime != nil && !task.StartTime.Equal(*tt.upd.StartTime) {
+				t.Errorf("start = %v, want %v", task.StartTime, *tt.upd.StartTime)
+			}
+			if tt.upd.EndTime != nil && (task.EndTime == nil || !task.EndTime.Equal(*tt.upd.EndTime)) {
+				t.Errorf("end = %v, want %v", task.EndTime, *tt.upd.EndTime)
+			}
+			if tt.upd.Desc != nil && task.Desc != *tt.upd.Desc {
+				t.Errorf("description = %q, want %q", task.Desc, *tt.upd.Desc)
+			}
+		})
+	}
+}
@@ -0,0 +1,426 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+	"time-tracker/internal/models"
+	"time-tracker/internal/utils"
+)
+
+// tenant holds the ids of the rows of one organization.
+type tenant struct {
+	ctx     context.Context
+	user    int
+	task    int
+	running int
+	client  int
+	project int
+	team    int
+	key     int
+}
+
+// addTenant fills org with a user tracking time on a project of a client,
+// leading a team, with an API key and a calendar token.
+func addTenant(t *testing.T, db Database, org int, serie string) tenant {
+	t.Helper()
+	ctx := WithOrg(context.Background(), org)
+	x := tenant{ctx: ctx, user: mustAddUser(t, ctx, db, serie, serie+"00")}
+
+	client := models.Client{Name: "Client " + serie}
+	if err := db.AddClient(ctx, &client); err != nil {
+		t.Fatal(err)
+	}
+	project := models.Project{ClientID: &client.ID, Name: "Project " + serie}
+	if err := db.AddProject(ctx, &project); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -2).Add(10 * time.Hour)
+	end := start.Add(time.Hour)
+	task := models.Task{UserID: x.user, ProjectID: &project.ID, Desc: "done", Tags: []string{"tag-" + serie}, StartTime: start, EndTime: &end}
+	if err := db.AddTask(ctx, &task); err != nil {
+		t.Fatal(err)
+	}
+	running := models.Task{UserID: x.user, Desc: "running"}
+	if err := db.AddStartTask(ctx, &running, false); err != nil {
+		t.Fatal(err)
+	}
+	team := models.Team{Name: "Team " + serie, LeadID: &x.user}
+	if err := db.AddTeam(ctx, &team); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AddTeamMember(ctx, team.ID, x.user); err != nil {
+		t.Fatal(err)
+	}
+	key := models.APIKey{UserID: x.user, Name: "key", Prefix: "tt_" + serie}
+	if err := db.AddAPIKey(ctx, &key, "hash-"+serie); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetCalendarTokenHash(ctx, x.user, "calendar-"+serie); err != nil {
+		t.Fatal(err)
+	}
+	x.task, x.running, x.client, x.project, x.team, x.key = task.ID, running.ID, client.ID, project.ID, team.ID, key.ID
+	return x
+}
+
+// weekOf asks for the week around the tasks of a tenant.
+func weekOf(userID int) *models.GetUserWorklogsRequest {
+	now := time.Now().UTC()
+	return &models.GetUserWorklogsRequest{UserID: userID, StartDate: now.AddDate(0, 0, -7), EndDate: now.AddDate(0, 0, 1)}
+}
+
+func TestOrganizationIsolation(t *testing.T) {
+	// every call is made by org b on the rows of org a. It returns how many
+	// rows of a it saw, err is what it must fail with, nil for lists that
+	// must come back without the rows of a.
+	tests := []struct {
+		name string
+		call func(db Database, a, b tenant) (int, error)
+		err  error
+	}{
+		// users
+		{"GetUserByID", func(db Database, a, b tenant) (int, error) {
+			_, err := db.GetUserByID(b.ctx, a.user)
+			return 0, err
+		}, sql.ErrNoRows},
+		{"GetUsers", func(db Database, a, b tenant) (int, error) {
+			users, err := db.GetUsers(b.ctx, models.GetUsersRequest{Page: 1, PageSize: 100})
+			_, seen := users[a.user]
+			return count(seen), err
+		}, nil},
+		{"GetUsers of team", func(db Database, a, b tenant) (int, error) {
+			users, err := db.GetUsers(b.ctx, models.GetUsersRequest{TeamID: &a.team, Page: 1, PageSize: 100})
+			return len(users), err
+		}, nil},
+		{"UpdateUser", func(db Database, a, b tenant) (int, error) {
+			return 0, db.UpdateUser(b.ctx, &models.User{Id: a.user, Surname: "Changed"})
+		}, sql.ErrNoRows},
+		{"DeleteUser", func(db Database, a, b tenant) (int, error) {
+			return 0, db.DeleteUser(b.ctx, a.user)
+		}, sql.ErrNoRows},
+		{"SetUserPassword", func(db Database, a, b tenant) (int, error) {
+			return 0, db.SetUserPassword(b.ctx, a.user, "hash")
+		}, sql.ErrNoRows},
+		{"SetUserRole", func(db Database, a, b tenant) (int, error) {
+			return 0, db.SetUserRole(b.ctx, a.user, models.RoleAdmin, nil)
+		}, sql.ErrNoRows},
+		{"SetUserRole with manager", func(db Database, a, b tenant) (int, error) {
+			return 0, db.SetUserRole(b.ctx, b.user, models.RoleEmployee, &a.user)
+		}, utils.ErrInvalidManager},
+
+		// calendar tokens
+		{"SetCalendarTokenHash", func(db Database, a, b tenant) (int, error) {
+			return 0, db.SetCalendarTokenHash(b.ctx, a.user, "hash")
+		}, sql.ErrNoRows},
+		{"GetCalendarTokenHash", func(db Database, a, b tenant) (int, error) {
+			_, err := db.GetCalendarTokenHash(b.ctx, a.user)
+			return 0, err
+		}, sql.ErrNoRows},
+
+		// API keys
+		{"AddAPIKey", func(db Database, a, b tenant) (int, error) {
+			return 0, db.AddAPIKey(b.ctx, &models.APIKey{UserID: a.user, Name: "stolen", Prefix: "tt_x"}, "hash-x")
+		}, sql.ErrNoRows},
+		{"GetAPIKeys", func(db Database, a, b tenant) (int, error) {
+			keys, err := db.GetAPIKeys(b.ctx, a.user)
+			return len(keys), err
+		}, nil},
+		{"DeleteAPIKey", func(db Database, a, b tenant) (int, error) {
+			return 0, db.DeleteAPIKey(b.ctx, a.user, a.key)
+		}, sql.ErrNoRows},
+		{"DeleteAPIKey as own user", func(db Database, a, b tenant) (int, error) {
+			return 0, db.DeleteAPIKey(b.ctx, b.user, a.key)
+		}, sql.ErrNoRows},
+
+		// tasks
+		{"AddTask", func(db Database, a, b tenant) (int, error) {
+			start := time.Now().UTC().AddDate(0, 0, -3)
+			end := start.Add(time.Hour)
+			return 0, userNotFound(db.AddTask(b.ctx, &models.Task{UserID: a.user, StartTime: start, EndTime: &end}), a.user)
+		}, errUserNotFound},
+		{"AddStartTask", func(db Database, a, b tenant) (int, error) {
+			return 0, userNotFound(db.AddStartTask(b.ctx, &models.Task{UserID: a.user}, true), a.user)
+		}, errUserNotFound},
+		{"AddStartTask on project", func(db Database, a, b tenant) (int, error) {
+			return 0, db.AddStartTask(b.ctx, &models.Task{UserID: b.user, ProjectID: &a.project}, true)
+		}, utils.ErrProjectNotFound},
+		{"GetTaskByID", func(db Database, a, b tenant) (int, error) {
+			_, err := db.GetTaskByID(b.ctx, a.task)
+			return 0, err
+		}, sql.ErrNoRows},
+		{"UpdateTask", func(db Database, a, b tenant) (int, error) {
+			desc := "changed"
+			_, err := db.UpdateTask(b.ctx, a.task, models.TaskUpdate{Desc: &desc})
+			return 0, err
+		}, sql.ErrNoRows},
+		{"SetTaskTags", func(db Database, a, b tenant) (int, error) {
+			return 0, db.SetTaskTags(b.ctx, a.task, []string{"changed"})
+		}, sql.ErrNoRows},
+		{"PauseTask", func(db Database, a, b tenant) (int, error) {
+			return 0, db.PauseTask(b.ctx, a.running)
+		}, sql.ErrNoRows},
+		{"ResumeTask", func(db Database, a, b tenant) (int, error) {
+			return 0, db.ResumeTask(b.ctx, a.task, true)
+		}, sql.ErrNoRows},
+		{"AddEndTask", func(db Database, a, b tenant) (int, error) {
+			return 0, db.AddEndTask(b.ctx, a.running)
+		}, sql.ErrNoRows},
+		{"GetActiveTask", func(db Database, a, b tenant) (int, error) {
+			_, err := db.GetActiveTask(b.ctx, a.user)
+			return 0, err
+		}, sql.ErrNoRows},
+		{"DeleteTask", func(db Database, a, b tenant) (int, error) {
+			return 0, db.DeleteTask(b.ctx, a.task)
+		}, sql.ErrNoRows},
+		{"GetUserConflicts", func(db Database, a, b tenant) (int, error) {
+			conflicts, err := db.GetUserConflicts(b.ctx, a.user)
+			return len(conflicts), err
+		}, nil},
+
+		// tags
+		{"GetTags", func(db Database, a, b tenant) (int, error) {
+			tags, err := db.GetTags(b.ctx)
+			seen := 0
+			for _, tag := range tags {
+				if tag == "tag-1111" {
+					seen++
+				}
+			}
+			return seen, err
+		}, nil},
+
+		// worklogs and exports
+		{"GetUserWorklogs", func(db Database, a, b tenant) (int, error) {
+			logs, err := db.GetUserWorklogs(b.ctx, weekOf(a.user))
+			return len(logs), err
+		}, nil},
+		{"GetUserWorklogGroups", func(db Database, a, b tenant) (int, error) {
+			req := weekOf(a.user)
+			req.GroupBy = "task"
+			report, err := db.GetUserWorklogGroups(b.ctx, req)
+			if err != nil {
+				return 0, err
+			}
+			return len(report.Groups) + int(report.Total.Seconds), nil
+		}, nil},
+		{"EachUserTimeEntry", func(db Database, a, b tenant) (int, error) {
+			seen := 0
+			err := db.EachUserTimeEntry(b.ctx, weekOf(a.user), func(models.TimeEntry) error {
+				seen++
+				return nil
+			})
+			return seen, err
+		}, nil},
+		{"EachUserInterval", func(db Database, a, b tenant) (int, error) {
+			seen := 0
+			err := db.EachUserInterval(b.ctx, weekOf(a.user), func(models.TimeEntry) error {
+				seen++
+				return nil
+			})
+			return seen, err
+		}, nil},
+
+		// clients
+		{"GetClients", func(db Database, a, b tenant) (int, error) {
+			clients, err := db.GetClients(b.ctx)
+			seen := 0
+			for _, c := range clients {
+				if c.ID == a.client {
+					seen++
+				}
+			}
+			return seen, err
+		}, nil},
+		{"GetClientByID", func(db Database, a, b tenant) (int, error) {
+			_, err := db.GetClientByID(b.ctx, a.client)
+			return 0, err
+		}, sql.ErrNoRows},
+		{"UpdateClient", func(db Database, a, b tenant) (int, error) {
+			return 0, db.UpdateClient(b.ctx, &models.Client{ID: a.client, Name: "Changed"})
+		}, sql.ErrNoRows},
+		{"DeleteClient", func(db Database, a, b tenant) (int, error) {
+			return 0, db.DeleteClient(b.ctx, a.client)
+		}, sql.ErrNoRows},
+
+		// projects
+		{"AddProject", func(db Database, a, b tenant) (int, error) {
+			return 0, db.AddProject(b.ctx, &models.Project{ClientID: &a.client, Name: "Stolen"})
+		}, utils.ErrClientNotFound},
+		{"GetProjects", func(db Database, a, b tenant) (int, error) {
+			projects, err := db.GetProjects(b.ctx, models.GetProjectsRequest{})
+			seen := 0
+			for _, p := range projects {
+				if p.ID == a.project {
+					seen++
+				}
+			}
+			return seen, err
+		}, nil},
+		{"GetProjects of client", func(db Database, a, b tenant) (int, error) {
+			projects, err := db.GetProjects(b.ctx, models.GetProjectsRequest{ClientID: &a.client})
+			return len(projects), err
+		}, nil},
+		{"GetProjectByID", func(db Database, a, b tenant) (int, error) {
+			_, err := db.GetProjectByID(b.ctx, a.project)
+			return 0, err
+		}, sql.ErrNoRows},
+		{"UpdateProject", func(db Database, a, b tenant) (int, error) {
+			return 0, db.UpdateProject(b.ctx, &models.Project{ID: a.project, Name: "Changed"})
+		}, sql.ErrNoRows},
+		{"UpdateProject to client", func(db Database, a, b tenant) (int, error) {
+			return 0, db.UpdateProject(b.ctx, &models.Project{ID: b.project, ClientID: &a.client, Name: "Moved"})
+		}, utils.ErrClientNotFound},
+		{"DeleteProject", func(db Database, a, b tenant) (int, error) {
+			return 0, db.DeleteProject(b.ctx, a.project)
+		}, sql.ErrNoRows},
+
+		// teams
+		{"AddTeam", func(db Database, a, b tenant) (int, error) {
+			return 0, db.AddTeam(b.ctx, &models.Team{Name: "Stolen", LeadID: &a.user})
+		}, utils.ErrLeadNotFound},
+		{"GetTeams", func(db Database, a, b tenant) (int, error) {
+			teams, err := db.GetTeams(b.ctx)
+			seen := 0
+			for _, team := range teams {
+				if team.ID == a.team {
+					seen++
+				}
+			}
+			return seen, err
+		}, nil},
+		{"GetTeamByID", func(db Database, a, b tenant) (int, error) {
+			_, err := db.GetTeamByID(b.ctx, a.team)
+			return 0, err
+		}, sql.ErrNoRows},
+		{"UpdateTeam", func(db Database, a, b tenant) (int, error) {
+			return 0, db.UpdateTeam(b.ctx, &models.Team{ID: a.team, Name: "Changed"})
+		}, sql.ErrNoRows},
+		{"UpdateTeam lead", func(db Database, a, b tenant) (int, error) {
+			return 0, db.UpdateTeam(b.ctx, &models.Team{ID: b.team, Name: "Team 2222", LeadID: &a.user})
+		}, utils.ErrLeadNotFound},
+		{"AddTeamMember to team", func(db Database, a, b tenant) (int, error) {
+			return 0, db.AddTeamMember(b.ctx, a.team, b.user)
+		}, sql.ErrNoRows},
+		{"AddTeamMember of user", func(db Database, a, b tenant) (int, error) {
+			return 0, db.AddTeamMember(b.ctx, b.team, a.user)
+		}, sql.ErrNoRows},
+		{"RemoveTeamMember", func(db Database, a, b tenant) (int, error) {
+			return 0, db.RemoveTeamMember(b.ctx, a.team, a.user)
+		}, sql.ErrNoRows},
+		{"GetTeamWorklogs", func(db Database, a, b tenant) (int, error) {
+			req := weekOf(a.user)
+			_, err := db.GetTeamWorklogs(b.ctx, a.team, &models.GetTeamWorklogsRequest{StartDate: req.StartDate, EndDate: req.EndDate})
+			return 0, err
+		}, sql.ErrNoRows},
+		{"DeleteTeam", func(db Database, a, b tenant) (int, error) {
+			return 0, db.DeleteTeam(b.ctx, a.team)
+		}, sql.ErrNoRows},
+	}
+	forEachStore(t, func(t *testing.T, db Database) {
+		other := models.Organization{Name: "Other"}
+		if err := db.AddOrganization(context.Background(), &other); err != nil {
+			t.Fatal(err)
+		}
+		a := addTenant(t, db, models.DefaultOrganizationID, "1111")
+		b := addTenant(t, db, other.ID, "2222")
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				seen, err := tt.call(db, a, b)
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("error %v, want %v", err, tt.err)
+				}
+				if seen != 0 {
+					t.Fatalf("saw %d rows of the other organization", seen)
+				}
+			})
+		}
+
+		// nothing of a changed
+		u, err := db.GetUserByID(a.ctx, a.user)
+		if err != nil || u.Surname != "Surname 111100" {
+			t.Fatalf("user %+v, error %v", u, err)
+		}
+		task, err := db.GetTaskByID(a.ctx, a.task)
+		if err != nil || task.Desc != "done" || len(task.Tags) != 1 || task.Tags[0] != "tag-1111" {
+			t.Fatalf("task %+v, error %v", task, err)
+		}
+		if active, err := db.GetActiveTask(a.ctx, a.user); err != nil || active.ID != a.running {
+			t.Fatalf("active task %+v, error %v", active, err)
+		}
+		if c, err := db.GetClientByID(a.ctx, a.client); err != nil || c.Name != "Client 1111" {
+			t.Fatalf("client %+v, error %v", c, err)
+		}
+		if p, err := db.GetProjectByID(a.ctx, a.project); err != nil || p.Name != "Project 1111" {
+			t.Fatalf("project %+v, error %v", p, err)
+		}
+		if team, err := db.GetTeamByID(a.ctx, a.team); err != nil || team.Name != "Team 1111" || len(team.Members) != 1 {
+			t.Fatalf("team %+v, error %v", team, err)
+		}
+		if keys, err := db.GetAPIKeys(a.ctx, a.user); err != nil || len(keys) != 1 {
+			t.Fatalf("api keys %+v, error %v", keys, err)
+		}
+		if hash, err := db.GetCalendarTokenHash(a.ctx, a.user); err != nil || hash != "calendar-1111" {
+			t.Fatalf("calendar token %q, error %v", hash, err)
+		}
+		if p, err := db.GetPrincipal(context.Background(), a.user); err != nil || p.Role != models.RoleEmployee {
+			t.Fatalf("principal %+v, error %v", p, err)
+		}
+	})
+}
+
+// errUserNotFound stands for the utils.ErrUserNotFound message entries
+// of a user missing from the organization fail with.
+var errUserNotFound = errors.New("user not found")
+
+func userNotFound(err error, userID int) error {
+	if err != nil && err.Error() == fmt.Sprintf(utils.ErrUserNotFound, userID) {
+		return errUserNotFound
+	}
+	return err
+}
+
+func count(seen bool) int {
+	if seen {
+		return 1
+	}
+	return 0
+}
+
+func TestPassportPerOrganization(t *testing.T) {
+	forEachStore(t, func(t *testing.T, db Database) {
+		other := models.Organization{Name: "Other"}
+		if err := db.AddOrganization(context.Background(), &other); err != nil {
+			t.Fatal(err)
+		}
+		a, b := defaultOrg(), WithOrg(context.Background(), other.ID)
+		userA := mustAddUser(t, a, db, "1234", "567890")
+		// the same person in another organization tells nothing about the first
+		userB := mustAddUser(t, b, db, "1234", "567890")
+		if _, err := db.AddUser(b, &models.User{PassSerie: "1234", PassNumber: "567890"}); err == nil {
+			t.Fatal("second user of a passport in one organization added")
+		}
+		if err := db.SetUserPassword(b, userB, "hash-b"); err != nil {
+			t.Fatal(err)
+		}
+
+		creds, err := db.GetCredentials(context.Background(), "1234", "567890")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []models.Credential{
+			{UserID: userA, OrgID: models.DefaultOrganizationID},
+			{UserID: userB, OrgID: other.ID, PasswordHash: "hash-b"},
+		}
+		if len(creds) != len(want) || creds[0] != want[0] || creds[1] != want[1] {
+			t.Fatalf("credentials = %+v, want %+v", creds, want)
+		}
+		creds, err = db.GetCredentials(context.Background(), "1234", "000000")
+		if err != nil || len(creds) != 0 {
+			t.Fatalf("credentials of an unknown passport = %+v, %v, want none", creds, err)
+		}
+	})
+}
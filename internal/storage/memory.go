@@ -0,0 +1,452 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+	"time-tracker/internal/models"
+	"time-tracker/internal/utils"
+)
+
+// Memory is an in-memory Database. It mirrors the behaviour of Postgres
+// and is meant for tests and local runs without a database server.
+type Memory struct {
+	mu sync.RWMutex
+
+	users    map[int]models.User
+	tasks    map[int]models.Task
+	segments map[int]models.TaskSegment
+	clients  map[int]models.Client
+	projects map[int]models.Project
+	teams    map[int]models.Team
+	tags     map[string]bool
+
+	orgs map[int]models.Organization
+	// userOrgs, clientOrgs, projectOrgs and teamOrgs map a row to its organization
+	userOrgs    map[int]int
+	clientOrgs  map[int]int
+	projectOrgs map[int]int
+	teamOrgs    map[int]int
+
+	calendarTokens map[int]string
+	passwords      map[int]string
+	apiKeys        map[int]memAPIKey
+	// enrichmentJobs maps a user pending enrichment to its queued lookup
+	enrichmentJobs map[int]memEnrichmentJob
+	roles          map[int]models.Role
+	// managers maps a user to their manager
+	managers map[int]int
+
+	lastOrgID     int
+	lastUserID    int
+	lastTaskID    int
+	lastSegmentID int
+	lastClientID  int
+	lastProjectID int
+	lastAPIKeyID  int
+	lastTeamID    int
+}
+
+// NewMemory returns an empty store with the default organization, the
+// same state the migrations leave a database in.
+func NewMemory() *Memory {
+	return &Memory{
+		orgs: map[int]models.Organization{
+			models.DefaultOrganizationID: {ID: models.DefaultOrganizationID, Name: "Default"},
+		},
+		userOrgs:    make(map[int]int),
+		clientOrgs:  make(map[int]int),
+		projectOrgs: make(map[int]int),
+		teamOrgs:    make(map[int]int),
+		lastOrgID:   models.DefaultOrganizationID,
+
+		users:    make(map[int]models.User),
+		tasks:    make(map[int]models.Task),
+		segments: make(map[int]models.TaskSegment),
+		clients:  make(map[int]models.Client),
+		projects: make(map[int]models.Project),
+		teams:    make(map[int]models.Team),
+		tags:     make(map[string]bool),
+
+		calendarTokens: make(map[int]string),
+		passwords:      make(map[int]string),
+		apiKeys:        make(map[int]memAPIKey),
+		enrichmentJobs: make(map[int]memEnrichmentJob),
+		roles:          make(map[int]models.Role),
+		managers:       make(map[int]int),
+	}
+}
+
+func (m *Memory) Connect() error {
+	return nil
+}
+
+func (m *Memory) Disconnect() error {
+	return nil
+}
+
+func (m *Memory) GetUsers(ctx context.Context, req models.GetUsersRequest) (map[int]models.User, error) {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ids := make([]int, 0, len(m.users))
+	for id, u := range m.users {
+		if !inOrg(m.userOrgs, id, org) {
+			continue
+		}
+		if req.PassportNumber != "" && u.PassNumber != req.PassportNumber {
+			continue
+		}
+		if req.PassSerie != "" && u.PassSerie != req.PassSerie {
+			continue
+		}
+		if req.Surname != "" && u.Surname != req.Surname {
+			continue
+		}
+		if req.Name != "" && u.Name != req.Name {
+			continue
+		}
+		if req.Patronymic != "" && u.Patronymic != req.Patronymic {
+			continue
+		}
+		if req.Address != "" && u.Address != req.Address {
+			continue
+		}
+		if req.EnrichmentStatus != "" && u.EnrichmentStatus != req.EnrichmentStatus {
+			continue
+		}
+		if req.TeamID != nil && !containsInt(m.teams[*req.TeamID].Members, id) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	users := make(map[int]models.User)
+	for _, id := range paginate(ids, req.Page, req.PageSize) {
+		users[id] = m.users[id]
+	}
+	return users, nil
+}
+
+func (m *Memory) GetUserByID(ctx context.Context, id int) (*models.User, error) {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	u, ok := m.users[id]
+	if !ok || !inOrg(m.userOrgs, id, org) {
+		return nil, sql.ErrNoRows
+	}
+	return &u, nil
+}
+
+func (m *Memory) AddUser(ctx context.Context, u *models.User) (*models.User, error) {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u.EnrichmentStatus = models.EnrichmentDone
+	if err := m.addUser(u, org); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// addUser must be called with m.mu held.
+func (m *Memory) addUser(u *models.User, org int) error {
+	// passports are unique per organization like in the users table
+	for id, existing := range m.users {
+		if existing.PassNumber == u.PassNumber && m.userOrgs[id] == org {
+			return utils.ErrAlreadyExists
+		}
+	}
+	m.lastUserID++
+	u.Id = m.lastUserID
+	m.users[u.Id] = *u
+	m.userOrgs[u.Id] = org
+	return nil
+}
+
+func (m *Memory) DeleteUser(ctx context.Context, id int) error {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !inOrg(m.userOrgs, id, org) {
+		return sql.ErrNoRows
+	}
+	for taskID, t := range m.tasks {
+		if t.UserID != id {
+			continue
+		}
+		for segmentID, segment := range m.segments {
+			if segment.TaskID == taskID {
+				delete(m.segments, segmentID)
+			}
+		}
+		delete(m.tasks, taskID)
+	}
+	delete(m.calendarTokens, id)
+	delete(m.enrichmentJobs, id)
+	delete(m.passwords, id)
+	for keyID, k := range m.apiKeys {
+		if k.UserID == id {
+			delete(m.apiKeys, keyID)
+		}
+	}
+	delete(m.roles, id)
+	delete(m.managers, id)
+	for userID, managerID := range m.managers {
+		if managerID == id {
+			delete(m.managers, userID)
+		}
+	}
+	for teamID, t := range m.teams {
+		if t.LeadID != nil && *t.LeadID == id {
+			t.LeadID = nil
+			m.teams[teamID] = t
+		}
+		m.removeMember(teamID, id)
+	}
+	delete(m.users, id)
+	delete(m.userOrgs, id)
+	return nil
+}
+
+func (m *Memory) UpdateUser(ctx context.Context, u *models.User) error {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.users[u.Id]
+	if !ok || !inOrg(m.userOrgs, u.Id, org) {
+		return sql.ErrNoRows
+	}
+	existing.Surname = u.Surname
+	existing.Name = u.Name
+	existing.Patronymic = u.Patronymic
+	existing.Address = u.Address
+	m.users[u.Id] = existing
+	return nil
+}
+
+func (m *Memory) AddStartTask(ctx context.Context, t *models.Task, stopRunning bool) error {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !inOrg(m.userOrgs, t.UserID, org) {
+		return fmt.Errorf(utils.ErrUserNotFound, t.UserID)
+	}
+	if t.ProjectID != nil && !inOrg(m.projectOrgs, *t.ProjectID, org) {
+		return utils.ErrProjectNotFound
+	}
+	now := time.Now()
+	if err := m.makeRoom(t.UserID, stopRunning, now); err != nil {
+		return err
+	}
+	m.lastTaskID++
+	t.ID = m.lastTaskID
+	t.StartTime = now
+	m.tasks[t.ID] = models.Task{
+		ID:        t.ID,
+		UserID:    t.UserID,
+		ProjectID: t.ProjectID,
+		StartTime: t.StartTime,
+		Desc:      t.Desc,
+		Tags:      m.addTags(t.Tags),
+	}
+	t.Segments = []models.TaskSegment{m.addSegment(t.ID, now)}
+	return nil
+}
+
+func (m *Memory) AddEndTask(ctx context.Context, id int) error {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.taskInOrg(id, org)
+	if !ok {
+		return sql.ErrNoRows
+	}
+	if t.EndTime != nil {
+		return utils.ErrTaskStopped
+	}
+	m.stopTask(id, time.Now())
+	return nil
+}
+
+func (m *Memory) PauseTask(ctx context.Context, id int) error {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.taskInOrg(id, org); !ok {
+		return sql.ErrNoRows
+	}
+	segment, ok := m.openSegment(id)
+	if !ok {
+		return utils.ErrTaskNotRunning
+	}
+	now := time.Now()
+	segment.EndTime = &now
+	m.segments[segment.ID] = segment
+	return nil
+}
+
+func (m *Memory) ResumeTask(ctx context.Context, id int, stopRunning bool) error {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.taskInOrg(id, org)
+	if !ok {
+		return sql.ErrNoRows
+	}
+	if t.EndTime != nil {
+		return utils.ErrTaskStopped
+	}
+	if _, ok := m.openSegment(id); ok {
+		return utils.ErrTaskAlreadyRunning
+	}
+	now := time.Now()
+	if err := m.makeRoom(t.UserID, stopRunning, now); err != nil {
+		return err
+	}
+	m.addSegment(id, now)
+	return nil
+}
+
+func (m *Memory) GetActiveTask(ctx context.Context, userID int) (*models.Task, error) {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	id, ok := m.runningTaskID(userID)
+	if !ok || !inOrg(m.userOrgs, userID, org) {
+		return nil, sql.ErrNoRows
+	}
+	t := m.tasks[id]
+	t.Segments = m.taskSegments(id)
+	return &t, nil
+}
+
+// The helpers below must be called with m.mu held.
+
+func (m *Memory) runningTaskID(userID int) (int, bool) {
+	for _, segment := range m.segments {
+		if segment.EndTime == nil && m.tasks[segment.TaskID].UserID == userID {
+			return segment.TaskID, true
+		}
+	}
+	return 0, false
+}
+
+func (m *Memory) makeRoom(userID int, stopRunning bool, now time.Time) error {
+	id, ok := m.runningTaskID(userID)
+	if !ok {
+		return nil
+	}
+	if !stopRunning {
+		return utils.ErrTaskAlreadyRunning
+	}
+	m.stopTask(id, now)
+	return nil
+}
+
+func (m *Memory) addSegment(taskID int, now time.Time) models.TaskSegment {
+	m.lastSegmentID++
+	segment := models.TaskSegment{
+		ID:        m.lastSegmentID,
+		TaskID:    taskID,
+		StartTime: now,
+	}
+	m.segments[segment.ID] = segment
+	return segment
+}
+
+func (m *Memory) openSegment(taskID int) (models.TaskSegment, bool) {
+	for _, segment := range m.segments {
+		if segment.TaskID == taskID && segment.EndTime == nil {
+			return segment, true
+		}
+	}
+	return models.TaskSegment{}, false
+}
+
+func (m *Memory) stopTask(id int, now time.Time) {
+	t, ok := m.tasks[id]
+	if !ok {
+		return
+	}
+	if segment, ok := m.openSegment(id); ok {
+		segment.EndTime = &now
+		m.segments[segment.ID] = segment
+	}
+	if t.EndTime == nil {
+		t.EndTime = &now
+		m.tasks[id] = t
+	}
+}
+
+func (m *Memory) taskSegments(taskID int) []models.TaskSegment {
+	var segments []models.TaskSegment
+	for _, segment := range m.segments {
+		if segment.TaskID == taskID {
+			segments = append(segments, segment)
+		}
+	}
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i].StartTime.Before(segments[j].StartTime)
+	})
+	return segments
+}
+
+// paginate returns the page of ids selected by a 1-based page number,
+// the same window LIMIT/OFFSET gives in GetUsers.
+func paginate(ids []int, page, pageSize int) []int {
+	offset := (page - 1) * pageSize
+	if offset < 0 || pageSize <= 0 || offset >= len(ids) {
+		return nil
+	}
+	end := offset + pageSize
+	if end > len(ids) {
+		end = len(ids)
+	}
+	return ids[offset:end]
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+	"time-tracker/internal/models"
+)
+
+// memAPIKey is an API key with the hash it is found by.
+type memAPIKey struct {
+	models.APIKey
+	hash string
+}
+
+func (m *Memory) AddAPIKey(ctx context.Context, k *models.APIKey, hash string) error {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !inOrg(m.userOrgs, k.UserID, org) {
+		return sql.ErrNoRows
+	}
+	m.lastAPIKeyID++
+	k.ID = m.lastAPIKeyID
+	k.CreatedAt = time.Now().UTC()
+	m.apiKeys[k.ID] = memAPIKey{APIKey: *k, hash: hash}
+	return nil
+}
+
+func (m *Memory) GetAPIKeys(ctx context.Context, userID int) ([]models.APIKey, error) {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := []models.APIKey{}
+	for _, k := range m.apiKeys {
+		if k.UserID == userID && inOrg(m.userOrgs, userID, org) {
+			keys = append(keys, k.APIKey)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].ID < keys[j].ID
+	})
+	return keys, nil
+}
+
+func (m *Memory) DeleteAPIKey(ctx context.Context, userID, id int) error {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k, ok := m.apiKeys[id]
+	if !ok || k.UserID != userID || !inOrg(m.userOrgs, userID, org) {
+		return sql.ErrNoRows
+	}
+	delete(m.apiKeys, id)
+	return nil
+}
+
+func (m *Memory) UseAPIKey(ctx context.Context, hash string, now time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, k := range m.apiKeys {
+		if k.hash == hash {
+			used := now.UTC()
+			k.LastUsedAt = &used
+			m.apiKeys[id] = k
+			return k.UserID, nil
+		}
+	}
+	return 0, sql.ErrNoRows
+}
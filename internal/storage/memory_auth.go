@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time-tracker/internal/models"
+	"time-tracker/internal/utils"
+)
+
+func (m *Memory) SetUserPassword(ctx context.Context, userID int, hash string) error {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !inOrg(m.userOrgs, userID, org) {
+		return sql.ErrNoRows
+	}
+	m.passwords[userID] = hash
+	return nil
+}
+
+func (m *Memory) GetCredentials(ctx context.Context, passSerie, passNumber string) ([]models.Credential, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var creds []models.Credential
+	for id, u := range m.users {
+		if u.PassSerie == passSerie && u.PassNumber == passNumber {
+			creds = append(creds, models.Credential{UserID: id, OrgID: m.userOrgs[id], PasswordHash: m.passwords[id]})
+		}
+	}
+	sort.Slice(creds, func(i, j int) bool { return creds[i].UserID < creds[j].UserID })
+	return creds, nil
+}
+
+func (m *Memory) GetPrincipal(ctx context.Context, userID int) (*models.Principal, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.users[userID]; !ok {
+		return nil, sql.ErrNoRows
+	}
+	p := models.Principal{
+		UserID: userID,
+		OrgID:  m.userOrgs[userID],
+		Role:   m.role(userID),
+		Team:   []int{},
+		Teams:  []int{},
+	}
+	for id, managerID := range m.managers {
+		if managerID == userID {
+			p.Team = append(p.Team, id)
+		}
+	}
+	for id, t := range m.teams {
+		if t.LeadID == nil || *t.LeadID != userID {
+			continue
+		}
+		p.Teams = append(p.Teams, id)
+		for _, member := range t.Members {
+			if !containsInt(p.Team, member) {
+				p.Team = append(p.Team, member)
+			}
+		}
+	}
+	sort.Ints(p.Team)
+	sort.Ints(p.Teams)
+	return &p, nil
+}
+
+func (m *Memory) SetUserRole(ctx context.Context, userID int, role models.Role, managerID *int) error {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !inOrg(m.userOrgs, userID, org) {
+		return sql.ErrNoRows
+	}
+	if managerID != nil {
+		if !inOrg(m.userOrgs, *managerID, org) || *managerID == userID {
+			return utils.ErrInvalidManager
+		}
+		m.managers[userID] = *managerID
+	} else {
+		delete(m.managers, userID)
+	}
+	m.roles[userID] = role
+	return nil
+}
+
+// role must be called with m.mu held.
+func (m *Memory) role(userID int) models.Role {
+	if role, ok := m.roles[userID]; ok {
+		return role
+	}
+	return models.RoleEmployee
+}
@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+)
+
+func (m *Memory) SetCalendarTokenHash(ctx context.Context, userID int, hash string) error {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !inOrg(m.userOrgs, userID, org) {
+		return sql.ErrNoRows
+	}
+	m.calendarTokens[userID] = hash
+	return nil
+}
+
+func (m *Memory) GetCalendarTokenHash(ctx context.Context, userID int) (string, error) {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return "", err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	hash, ok := m.calendarTokens[userID]
+	if !ok || !inOrg(m.userOrgs, userID, org) {
+		return "", sql.ErrNoRows
+	}
+	return hash, nil
+}
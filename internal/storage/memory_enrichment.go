@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+	"time-tracker/internal/models"
+)
+
+// memEnrichmentJob is a queued lookup of a user.
+type memEnrichmentJob struct {
+	attempts      int
+	nextAttemptAt time.Time
+	lastError     string
+	createdAt     time.Time
+}
+
+func (m *Memory) AddPendingUser(ctx context.Context, u *models.User) (*models.User, error) {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u.EnrichmentStatus = models.EnrichmentPending
+	if err := m.addUser(u, org); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	m.enrichmentJobs[u.Id] = memEnrichmentJob{nextAttemptAt: now, createdAt: now}
+	return u, nil
+}
+
+func (m *Memory) ClaimEnrichmentJobs(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.EnrichmentJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now = now.UTC()
+	var due []int
+	for userID, j := range m.enrichmentJobs {
+		if !j.nextAttemptAt.After(now) {
+			due = append(due, userID)
+		}
+	}
+	sort.Slice(due, func(a, b int) bool {
+		ja, jb := m.enrichmentJobs[due[a]], m.enrichmentJobs[due[b]]
+		if !ja.nextAttemptAt.Equal(jb.nextAttemptAt) {
+			return ja.nextAttemptAt.Before(jb.nextAttemptAt)
+		}
+		return due[a] < due[b]
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	jobs := []models.EnrichmentJob{}
+	for _, userID := range due {
+		j := m.enrichmentJobs[userID]
+		j.attempts++
+		j.nextAttemptAt = now.Add(lease)
+		m.enrichmentJobs[userID] = j
+
+		u := m.users[userID]
+		jobs = append(jobs, models.EnrichmentJob{
+			UserID:     userID,
+			OrgID:      m.userOrgs[userID],
+			PassSerie:  u.PassSerie,
+			PassNumber: u.PassNumber,
+			Attempts:   j.attempts,
+		})
+	}
+	return jobs, nil
+}
+
+func (m *Memory) CompleteEnrichment(ctx context.Context, u *models.User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.users[u.Id]
+	if !ok || existing.EnrichmentStatus != models.EnrichmentPending {
+		delete(m.enrichmentJobs, u.Id)
+		return sql.ErrNoRows
+	}
+	existing.Surname = u.Surname
+	existing.Name = u.Name
+	existing.Patronymic = u.Patronymic
+	existing.Address = u.Address
+	existing.EnrichmentStatus = models.EnrichmentDone
+	m.users[u.Id] = existing
+	delete(m.enrichmentJobs, u.Id)
+	u.EnrichmentStatus = models.EnrichmentDone
+	return nil
+}
+
+func (m *Memory) RetryEnrichment(ctx context.Context, userID int, at time.Time, reason string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	j, ok := m.enrichmentJobs[userID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	j.nextAttemptAt = at.UTC()
+	j.lastError = reason
+	m.enrichmentJobs[userID] = j
+	return nil
+}
+
+func (m *Memory) FailEnrichment(ctx context.Context, userID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userID]
+	if !ok || u.EnrichmentStatus != models.EnrichmentPending {
+		delete(m.enrichmentJobs, userID)
+		return sql.ErrNoRows
+	}
+	u.EnrichmentStatus = models.EnrichmentFailed
+	m.users[userID] = u
+	delete(m.enrichmentJobs, userID)
+	return nil
+}
@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+	"time-tracker/internal/models"
+	"time-tracker/internal/utils"
+)
+
+func (m *Memory) AddTask(ctx context.Context, t *models.Task) error {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if err := checkEntry(t.StartTime, *t.EndTime, now); err != nil {
+		return err
+	}
+	if !inOrg(m.userOrgs, t.UserID, org) {
+		return fmt.Errorf(utils.ErrUserNotFound, t.UserID)
+	}
+	if t.ProjectID != nil && !inOrg(m.projectOrgs, *t.ProjectID, org) {
+		return utils.ErrProjectNotFound
+	}
+	if m.overlaps(t.UserID, 0, t.StartTime, *t.EndTime, now) {
+		return utils.ErrOverlap
+	}
+	m.addEntry(t)
+	return nil
+}
+
+// addEntry stores a checked finished task with a single segment,
+// it must be called with m.mu held.
+func (m *Memory) addEntry(t *models.Task) {
+	m.lastTaskID++
+	t.ID = m.lastTaskID
+	end := *t.EndTime
+	m.tasks[t.ID] = models.Task{
+		ID:        t.ID,
+		UserID:    t.UserID,
+		ProjectID: t.ProjectID,
+		StartTime: t.StartTime,
+		EndTime:   &end,
+		Desc:      t.Desc,
+		Tags:      m.addTags(t.Tags),
+	}
+	segment := m.addSegment(t.ID, t.StartTime)
+	segment.EndTime = &end
+	m.segments[segment.ID] = segment
+	t.Segments = []models.TaskSegment{segment}
+}
+
+func (m *Memory) GetTaskByID(ctx context.Context, id int) (*models.Task, error) {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	t, ok := m.taskInOrg(id, org)
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	t.Segments = m.taskSegments(id)
+	return &t, nil
+}
+
+func (m *Memory) UpdateTask(ctx context.Context, id int, upd models.TaskUpdate) (*models.Task, error) {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.taskInOrg(id, org)
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	t.Segments = m.taskSegments(id)
+	now := time.Now()
+	moved, err := retime(&t, upd, now)
+	if err != nil {
+		return nil, err
+	}
+	for _, segment := range t.Segments {
+		if moved[segment.ID] && m.overlaps(t.UserID, t.ID, segment.StartTime, segmentEnd(segment, now), now) {
+			return nil, utils.ErrOverlap
+		}
+	}
+	for _, segment := range t.Segments {
+		m.segments[segment.ID] = segment
+	}
+	stored := t
+	stored.Segments = nil
+	m.tasks[id] = stored
+	return &t, nil
+}
+
+func (m *Memory) DeleteTask(ctx context.Context, id int) error {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.taskInOrg(id, org); !ok {
+		return sql.ErrNoRows
+	}
+	for segmentID, segment := range m.segments {
+		if segment.TaskID == id {
+			delete(m.segments, segmentID)
+		}
+	}
+	delete(m.tasks, id)
+	return nil
+}
+
+// overlaps reports whether [start, end) intersects a segment of another
+// task of the user, it must be called with m.mu held.
+func (m *Memory) overlaps(userID, taskID int, start, end, now time.Time) bool {
+	for _, segment := range m.segments {
+		if segment.TaskID == taskID || m.tasks[segment.TaskID].UserID != userID {
+			continue
+		}
+		if segment.StartTime.Before(end) && segmentEnd(segment, now).After(start) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Memory) GetUserConflicts(ctx context.Context, userID int) ([]models.Conflict, error) {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var segments []models.TaskSegment
+	for _, segment := range m.segments {
+		if m.tasks[segment.TaskID].UserID == userID && inOrg(m.userOrgs, userID, org) {
+			segments = append(segments, segment)
+		}
+	}
+	now := time.Now()
+	conflicts := []models.Conflict{}
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i].ID < segments[j].ID
+	})
+	for i, a := range segments {
+		for _, b := range segments[i+1:] {
+			if c, ok := conflict(a, b, now); ok {
+				conflicts = append(conflicts, c)
+			}
+		}
+	}
+	sortConflicts(conflicts)
+	return conflicts, nil
+}
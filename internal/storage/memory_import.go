@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+	"time-tracker/internal/models"
+	"time-tracker/internal/utils"
+)
+
+func (m *Memory) ImportTasks(ctx context.Context, tasks []models.ImportTask, report *models.ImportReport) error {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// the import works on the live maps, a copy stands in for a rollback
+	saved := m.snapshot()
+	knownTags := m.orgTags(org)
+	now := time.Now()
+	for _, it := range tasks {
+		if err := checkEntry(it.StartTime, it.EndTime, now); err != nil {
+			report.Invalid = append(report.Invalid, models.ImportIssue{Line: it.Line, User: it.User, Reason: err.Error()})
+			continue
+		}
+		if !inOrg(m.userOrgs, it.UserID, org) {
+			m.restore(saved)
+			return fmt.Errorf(utils.ErrUserNotFound, it.UserID)
+		}
+		if m.overlaps(it.UserID, 0, it.StartTime, it.EndTime, now) {
+			report.Conflicts = append(report.Conflicts, models.ImportIssue{Line: it.Line, User: it.User, Reason: utils.ErrOverlap.Error()})
+			continue
+		}
+		for _, tag := range it.Tags {
+			if !knownTags[tag] {
+				knownTags[tag] = true
+				report.NewTags = append(report.NewTags, tag)
+			}
+		}
+		end := it.EndTime
+		m.addEntry(&models.Task{
+			UserID:    it.UserID,
+			ProjectID: m.importProject(org, it.Client, it.Project, report),
+			StartTime: it.StartTime,
+			EndTime:   &end,
+			Desc:      it.Desc,
+			Tags:      it.Tags,
+		})
+		report.Tasks++
+	}
+
+	if report.DryRun || !report.OK() {
+		m.restore(saved)
+		return nil
+	}
+	report.Imported = true
+	return nil
+}
+
+// importProject must be called with m.mu held.
+func (m *Memory) importProject(org int, clientName, name string, report *models.ImportReport) *int {
+	if name == "" {
+		return nil
+	}
+	var clientID *int
+	if clientName != "" {
+		for id, c := range m.clients {
+			if c.Name == clientName && inOrg(m.clientOrgs, id, org) {
+				clientID = &id
+				break
+			}
+		}
+		if clientID == nil {
+			m.lastClientID++
+			id := m.lastClientID
+			m.clients[id] = models.Client{ID: id, Name: clientName}
+			m.clientOrgs[id] = org
+			clientID = &id
+			report.NewClients = append(report.NewClients, clientName)
+		}
+	}
+	for id, p := range m.projects {
+		if p.Name == name && sameClient(p.ClientID, clientID) && inOrg(m.projectOrgs, id, org) {
+			return &id
+		}
+	}
+	m.lastProjectID++
+	id := m.lastProjectID
+	m.projects[id] = models.Project{ID: id, ClientID: clientID, Name: name}
+	m.projectOrgs[id] = org
+	report.NewProjects = append(report.NewProjects, projectLabel(clientName, name))
+	return &id
+}
+
+// snapshot copies the state changed by an import.
+func (m *Memory) snapshot() *Memory {
+	return &Memory{
+		tasks:         maps.Clone(m.tasks),
+		segments:      maps.Clone(m.segments),
+		clients:       maps.Clone(m.clients),
+		projects:      maps.Clone(m.projects),
+		clientOrgs:    maps.Clone(m.clientOrgs),
+		projectOrgs:   maps.Clone(m.projectOrgs),
+		tags:          maps.Clone(m.tags),
+		lastTaskID:    m.lastTaskID,
+		lastSegmentID: m.lastSegmentID,
+		lastClientID:  m.lastClientID,
+		lastProjectID: m.lastProjectID,
+	}
+}
+
+func (m *Memory) restore(saved *Memory) {
+	m.tasks, m.segments = saved.tasks, saved.segments
+	m.clients, m.projects, m.tags = saved.clients, saved.projects, saved.tags
+	m.clientOrgs, m.projectOrgs = saved.clientOrgs, saved.projectOrgs
+	m.lastTaskID, m.lastSegmentID = saved.lastTaskID, saved.lastSegmentID
+	m.lastClientID, m.lastProjectID = saved.lastClientID, saved.lastProjectID
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"time-tracker/internal/models"
+	"time-tracker/internal/utils"
+)
+
+func (m *Memory) AddOrganization(ctx context.Context, o *models.Organization) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.orgs {
+		if existing.Name == o.Name {
+			return utils.ErrAlreadyExists
+		}
+	}
+	m.lastOrgID++
+	o.ID = m.lastOrgID
+	m.orgs[o.ID] = *o
+	return nil
+}
+
+func (m *Memory) GetOrganizations(ctx context.Context) ([]models.Organization, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	orgs := make([]models.Organization, 0, len(m.orgs))
+	for _, o := range m.orgs {
+		orgs = append(orgs, o)
+	}
+	sort.Slice(orgs, func(i, j int) bool {
+		return orgs[i].ID < orgs[j].ID
+	})
+	return orgs, nil
+}
+
+// The helpers below must be called with m.mu held.
+
+// inOrg reports whether the row id of a table, described by the map of
+// its rows to their organization, belongs to org.
+func inOrg(owners map[int]int, id, org int) bool {
+	owner, ok := owners[id]
+	return ok && owner == org
+}
+
+// taskInOrg returns the task when its user belongs to org.
+func (m *Memory) taskInOrg(id, org int) (models.Task, bool) {
+	t, ok := m.tasks[id]
+	return t, ok && inOrg(m.userOrgs, t.UserID, org)
+}
@@ -0,0 +1,238 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time-tracker/internal/models"
+	"time-tracker/internal/utils"
+)
+
+func (m *Memory) AddClient(ctx context.Context, c *models.Client) error {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.clientNameTaken(c, org) {
+		return utils.ErrAlreadyExists
+	}
+	m.lastClientID++
+	c.ID = m.lastClientID
+	m.clients[c.ID] = *c
+	m.clientOrgs[c.ID] = org
+	return nil
+}
+
+func (m *Memory) GetClients(ctx context.Context) ([]models.Client, error) {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	clients := make([]models.Client, 0, len(m.clients))
+	for _, c := range m.clients {
+		if inOrg(m.clientOrgs, c.ID, org) {
+			clients = append(clients, c)
+		}
+	}
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].Name < clients[j].Name
+	})
+	return clients, nil
+}
+
+func (m *Memory) GetClientByID(ctx context.Context, id int) (*models.Client, error) {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c, ok := m.clients[id]
+	if !ok || !inOrg(m.clientOrgs, id, org) {
+		return nil, sql.ErrNoRows
+	}
+	return &c, nil
+}
+
+func (m *Memory) UpdateClient(ctx context.Context, c *models.Client) error {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !inOrg(m.clientOrgs, c.ID, org) {
+		return sql.ErrNoRows
+	}
+	if m.clientNameTaken(c, org) {
+		return utils.ErrAlreadyExists
+	}
+	m.clients[c.ID] = *c
+	return nil
+}
+
+func (m *Memory) DeleteClient(ctx context.Context, id int) error {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !inOrg(m.clientOrgs, id, org) {
+		return sql.ErrNoRows
+	}
+	for _, p := range m.projects {
+		if p.ClientID != nil && *p.ClientID == id {
+			return utils.ErrInUse
+		}
+	}
+	delete(m.clients, id)
+	delete(m.clientOrgs, id)
+	return nil
+}
+
+func (m *Memory) AddProject(ctx context.Context, p *models.Project) error {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkProject(p, org); err != nil {
+		return err
+	}
+	m.lastProjectID++
+	p.ID = m.lastProjectID
+	m.projects[p.ID] = *p
+	m.projectOrgs[p.ID] = org
+	return nil
+}
+
+func (m *Memory) GetProjects(ctx context.Context, req models.GetProjectsRequest) ([]models.Project, error) {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	projects := []models.Project{}
+	for _, p := range m.projects {
+		if !inOrg(m.projectOrgs, p.ID, org) {
+			continue
+		}
+		if req.ClientID != nil && (p.ClientID == nil || *p.ClientID != *req.ClientID) {
+			continue
+		}
+		projects = append(projects, p)
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		return projects[i].Name < projects[j].Name
+	})
+	return projects, nil
+}
+
+func (m *Memory) GetProjectByID(ctx context.Context, id int) (*models.Project, error) {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	p, ok := m.projects[id]
+	if !ok || !inOrg(m.projectOrgs, id, org) {
+		return nil, sql.ErrNoRows
+	}
+	return &p, nil
+}
+
+func (m *Memory) UpdateProject(ctx context.Context, p *models.Project) error {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !inOrg(m.projectOrgs, p.ID, org) {
+		return sql.ErrNoRows
+	}
+	if err := m.checkProject(p, org); err != nil {
+		return err
+	}
+	m.projects[p.ID] = *p
+	return nil
+}
+
+func (m *Memory) DeleteProject(ctx context.Context, id int) error {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !inOrg(m.projectOrgs, id, org) {
+		return sql.ErrNoRows
+	}
+	for _, t := range m.tasks {
+		if t.ProjectID != nil && *t.ProjectID == id {
+			return utils.ErrInUse
+		}
+	}
+	delete(m.projects, id)
+	delete(m.projectOrgs, id)
+	return nil
+}
+
+// The helpers below must be called with m.mu held.
+
+func (m *Memory) clientNameTaken(c *models.Client, org int) bool {
+	for _, existing := range m.clients {
+		if existing.ID != c.ID && existing.Name == c.Name && inOrg(m.clientOrgs, existing.ID, org) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkProject mirrors the constraints of the projects table.
+func (m *Memory) checkProject(p *models.Project, org int) error {
+	if p.ClientID != nil && !inOrg(m.clientOrgs, *p.ClientID, org) {
+		return utils.ErrClientNotFound
+	}
+	for _, existing := range m.projects {
+		if existing.ID != p.ID && existing.Name == p.Name && sameClient(existing.ClientID, p.ClientID) &&
+			inOrg(m.projectOrgs, existing.ID, org) {
+			return utils.ErrAlreadyExists
+		}
+	}
+	return nil
+}
+
+// billedTo reports whether the task belongs to a project of the client.
+func (m *Memory) billedTo(t models.Task, clientID int) bool {
+	if t.ProjectID == nil {
+		return false
+	}
+	p := m.projects[*t.ProjectID]
+	return p.ClientID != nil && *p.ClientID == clientID
+}
+
+func sameClient(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
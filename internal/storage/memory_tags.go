@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+)
+
+func (m *Memory) SetTaskTags(ctx context.Context, taskID int, tags []string) error {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.taskInOrg(taskID, org)
+	if !ok {
+		return sql.ErrNoRows
+	}
+	t.Tags = m.addTags(tags)
+	m.tasks[taskID] = t
+	return nil
+}
+
+func (m *Memory) GetTags(ctx context.Context) ([]string, error) {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tags := []string{}
+	for tag := range m.orgTags(org) {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+// orgTags returns the tags the tasks of org use, it must be called with
+// m.mu held.
+func (m *Memory) orgTags(org int) map[string]bool {
+	tags := make(map[string]bool)
+	for _, t := range m.tasks {
+		if !inOrg(m.userOrgs, t.UserID, org) {
+			continue
+		}
+		for _, tag := range t.Tags {
+			tags[tag] = true
+		}
+	}
+	return tags
+}
+
+// addTags remembers the tags and returns a copy of them for a task,
+// it must be called with m.mu held.
+func (m *Memory) addTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	copied := make([]string, len(tags))
+	copy(copied, tags)
+	sort.Strings(copied)
+	for _, tag := range copied {
+		m.tags[tag] = true
+	}
+	return copied
+}
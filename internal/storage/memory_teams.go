@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time-tracker/internal/models"
+	"time-tracker/internal/utils"
+)
+
+func (m *Memory) AddTeam(ctx context.Context, t *models.Team) error {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkTeam(t, org); err != nil {
+		return err
+	}
+	m.lastTeamID++
+	t.ID = m.lastTeamID
+	t.Members = []int{}
+	m.teams[t.ID] = *t
+	m.teamOrgs[t.ID] = org
+	return nil
+}
+
+func (m *Memory) GetTeams(ctx context.Context) ([]models.Team, error) {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	teams := make([]models.Team, 0, len(m.teams))
+	for _, t := range m.teams {
+		if !inOrg(m.teamOrgs, t.ID, org) {
+			continue
+		}
+		t.Members = append([]int{}, t.Members...)
+		teams = append(teams, t)
+	}
+	sort.Slice(teams, func(i, j int) bool {
+		return teams[i].Name < teams[j].Name
+	})
+	return teams, nil
+}
+
+func (m *Memory) GetTeamByID(ctx context.Context, id int) (*models.Team, error) {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	t, ok := m.teams[id]
+	if !ok || !inOrg(m.teamOrgs, id, org) {
+		return nil, sql.ErrNoRows
+	}
+	t.Members = append([]int{}, t.Members...)
+	return &t, nil
+}
+
+func (m *Memory) UpdateTeam(ctx context.Context, t *models.Team) error {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.teams[t.ID]
+	if !ok || !inOrg(m.teamOrgs, t.ID, org) {
+		return sql.ErrNoRows
+	}
+	if err := m.checkTeam(t, org); err != nil {
+		return err
+	}
+	existing.Name = t.Name
+	existing.LeadID = t.LeadID
+	m.teams[t.ID] = existing
+	return nil
+}
+
+func (m *Memory) DeleteTeam(ctx context.Context, id int) error {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !inOrg(m.teamOrgs, id, org) {
+		return sql.ErrNoRows
+	}
+	delete(m.teams, id)
+	delete(m.teamOrgs, id)
+	return nil
+}
+
+func (m *Memory) AddTeamMember(ctx context.Context, teamID, userID int) error {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.teams[teamID]
+	if !ok || !inOrg(m.teamOrgs, teamID, org) {
+		return sql.ErrNoRows
+	}
+	if !inOrg(m.userOrgs, userID, org) {
+		return sql.ErrNoRows
+	}
+	if containsInt(t.Members, userID) {
+		return nil
+	}
+	t.Members = append(append([]int{}, t.Members...), userID)
+	sort.Ints(t.Members)
+	m.teams[teamID] = t
+	return nil
+}
+
+func (m *Memory) RemoveTeamMember(ctx context.Context, teamID, userID int) error {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.teams[teamID]
+	if !ok || !inOrg(m.teamOrgs, teamID, org) || !containsInt(t.Members, userID) {
+		return sql.ErrNoRows
+	}
+	m.removeMember(teamID, userID)
+	return nil
+}
+
+func (m *Memory) GetTeamWorklogs(ctx context.Context, teamID int, req *models.GetTeamWorklogsRequest) (*models.TeamReport, error) {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	t, ok := m.teams[teamID]
+	if !ok || !inOrg(m.teamOrgs, teamID, org) {
+		return nil, sql.ErrNoRows
+	}
+	members := make([]memberIntervals, 0, len(t.Members))
+	for _, id := range t.Members {
+		members = append(members, memberIntervals{
+			user: m.users[id],
+			intervals: m.intervals(org, &models.GetUserWorklogsRequest{
+				UserID:    id,
+				StartDate: req.StartDate,
+				EndDate:   req.EndDate,
+			}),
+		})
+	}
+	return teamReport(teamID, req, members), nil
+}
+
+// The helpers below must be called with m.mu held.
+
+// checkTeam applies the constraints of the teams table within org.
+func (m *Memory) checkTeam(t *models.Team, org int) error {
+	for id, existing := range m.teams {
+		if id != t.ID && existing.Name == t.Name && inOrg(m.teamOrgs, id, org) {
+			return utils.ErrAlreadyExists
+		}
+	}
+	if t.LeadID != nil && !inOrg(m.userOrgs, *t.LeadID, org) {
+		return utils.ErrLeadNotFound
+	}
+	return nil
+}
+
+func (m *Memory) removeMember(teamID, userID int) {
+	t := m.teams[teamID]
+	members := make([]int, 0, len(t.Members))
+	for _, id := range t.Members {
+		if id != userID {
+			members = append(members, id)
+		}
+	}
+	t.Members = members
+	m.teams[teamID] = t
+}
+
+func containsInt(ids []int, id int) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
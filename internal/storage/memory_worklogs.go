@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"time-tracker/internal/models"
+)
+
+func (m *Memory) GetUserWorklogs(ctx context.Context, req *models.GetUserWorklogsRequest) ([]models.Worklog, error) {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return worklogs(m.intervals(org, req)), nil
+}
+
+func (m *Memory) GetUserWorklogGroups(ctx context.Context, req *models.GetUserWorklogsRequest) (*models.WorklogReport, error) {
+	loc, err := req.Location()
+	if err != nil {
+		return nil, err
+	}
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return worklogReport(m.intervals(org, req), req.GroupBy, loc), nil
+}
+
+func (m *Memory) EachUserTimeEntry(ctx context.Context, req *models.GetUserWorklogsRequest, fn func(models.TimeEntry) error) error {
+	loc, err := req.Location()
+	if err != nil {
+		return err
+	}
+	intervals, err := m.sortedIntervals(ctx, req)
+	if err != nil {
+		return err
+	}
+	for _, i := range intervals {
+		for _, e := range timeEntries(i, req.UserID, loc) {
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Memory) EachUserInterval(ctx context.Context, req *models.GetUserWorklogsRequest, fn func(models.TimeEntry) error) error {
+	loc, err := req.Location()
+	if err != nil {
+		return err
+	}
+	intervals, err := m.sortedIntervals(ctx, req)
+	if err != nil {
+		return err
+	}
+	for _, i := range intervals {
+		if err := fn(timeEntry(i, req.UserID, loc)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortedIntervals copies the intervals in the order they started so fn
+// runs without m.mu held.
+func (m *Memory) sortedIntervals(ctx context.Context, req *models.GetUserWorklogsRequest) ([]interval, error) {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	intervals := m.intervals(org, req)
+	m.mu.RUnlock()
+
+	sort.Slice(intervals, func(i, j int) bool {
+		if !intervals[i].start.Equal(intervals[j].start) {
+			return intervals[i].start.Before(intervals[j].start)
+		}
+		return intervals[i].segmentID < intervals[j].segmentID
+	})
+	return intervals, nil
+}
+
+// intervals must be called with m.mu held.
+func (m *Memory) intervals(org int, req *models.GetUserWorklogsRequest) []interval {
+	now := reportNow()
+	var intervals []interval
+	for _, segment := range m.segments {
+		t := m.tasks[segment.TaskID]
+		if t.UserID != req.UserID || !inOrg(m.userOrgs, t.UserID, org) {
+			continue
+		}
+		if req.ProjectID != nil && (t.ProjectID == nil || *t.ProjectID != *req.ProjectID) {
+			continue
+		}
+		if req.ClientID != nil && !m.billedTo(t, *req.ClientID) {
+			continue
+		}
+		if len(req.Tags) > 0 && !hasAnyTag(t.Tags, req.Tags) {
+			continue
+		}
+		if len(req.ExcludeTags) > 0 && hasAnyTag(t.Tags, req.ExcludeTags) {
+			continue
+		}
+		i := interval{
+			segmentID: segment.ID,
+			taskID:    t.ID,
+			projectID: t.ProjectID,
+			project:   m.projectName(t.ProjectID),
+			desc:      t.Desc,
+			tags:      t.Tags,
+			start:     segment.StartTime,
+		}
+		if fitInterval(&i, segment.EndTime, now, req.StartDate, req.EndDate) {
+			intervals = append(intervals, i)
+		}
+	}
+	return intervals
+}
+
+func hasAnyTag(tags, wanted []string) bool {
+	for _, tag := range tags {
+		for _, w := range wanted {
+			if tag == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (m *Memory) projectName(id *int) string {
+	if id == nil {
+		return ""
+	}
+	return m.projects[*id].Name
+}
@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"context"
+	"time-tracker/internal/utils"
+)
+
+type orgKey struct{}
+
+// WithOrg scopes the storage calls made with ctx to one organization.
+// Rows of other organizations look like missing rows. Only the lookups
+// authenticating a request work without it.
+func WithOrg(ctx context.Context, orgID int) context.Context {
+	return context.WithValue(ctx, orgKey{}, orgID)
+}
+
+// orgOf returns the organization ctx is scoped to. A call without one
+// fails rather than see every organization.
+func orgOf(ctx context.Context) (int, error) {
+	id, ok := ctx.Value(orgKey{}).(int)
+	if !ok || id <= 0 {
+		return 0, utils.ErrNoOrganization
+	}
+	return id, nil
+}
@@ -0,0 +1,246 @@
+package storage
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+	"time-tracker/internal/models"
+	"time-tracker/internal/utils"
+)
+
+func TestClients(t *testing.T) {
+	forEachStore(t, func(t *testing.T, db Database) {
+		ctx := defaultOrg()
+		client := models.Client{Name: "Acme"}
+		if err := db.AddClient(ctx, &client); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.AddClient(ctx, &models.Client{Name: "Acme"}); err != utils.ErrAlreadyExists {
+			t.Fatalf("duplicate name: err = %v, want %v", err, utils.ErrAlreadyExists)
+		}
+		other := models.Client{Name: "Globex"}
+		if err := db.AddClient(ctx, &other); err != nil {
+			t.Fatal(err)
+		}
+
+		client.Name = "Acme Corp"
+		if err := db.UpdateClient(ctx, &client); err != nil {
+			t.Fatal(err)
+		}
+		got, err := db.GetClientByID(ctx, client.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *got != client {
+			t.Fatalf("client = %+v, want %+v", *got, client)
+		}
+		if err := db.UpdateClient(ctx, &models.Client{ID: other.ID, Name: "Acme Corp"}); err != utils.ErrAlreadyExists {
+			t.Fatalf("rename onto another client: err = %v, want %v", err, utils.ErrAlreadyExists)
+		}
+		if err := db.UpdateClient(ctx, &models.Client{ID: other.ID + 100, Name: "Nobody"}); err != sql.ErrNoRows {
+			t.Fatalf("update of an unknown client: err = %v, want %v", err, sql.ErrNoRows)
+		}
+		clients, err := db.GetClients(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []models.Client{client, other}; !reflect.DeepEqual(clients, want) {
+			t.Fatalf("clients = %+v, want %+v", clients, want)
+		}
+
+		project := models.Project{Name: "Website", ClientID: &client.ID}
+		if err := db.AddProject(ctx, &project); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.DeleteClient(ctx, client.ID); err != utils.ErrInUse {
+			t.Fatalf("delete of a billed client: err = %v, want %v", err, utils.ErrInUse)
+		}
+		if err := db.DeleteClient(ctx, other.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.GetClientByID(ctx, other.ID); err != sql.ErrNoRows {
+			t.Fatalf("deleted client: err = %v, want %v", err, sql.ErrNoRows)
+		}
+		if err := db.DeleteClient(ctx, other.ID); err != sql.ErrNoRows {
+			t.Fatalf("second delete: err = %v, want %v", err, sql.ErrNoRows)
+		}
+	})
+}
+
+func TestProjects(t *testing.T) {
+	forEachStore(t, func(t *testing.T, db Database) {
+		ctx := defaultOrg()
+		client := models.Client{Name: "Acme"}
+		if err := db.AddClient(ctx, &client); err != nil {
+			t.Fatal(err)
+		}
+		missing := client.ID + 100
+
+		billed := models.Project{Name: "Website", ClientID: &client.ID}
+		internal := models.Project{Name: "Hiring"}
+		for _, p := range []*models.Project{&billed, &internal} {
+			if err := db.AddProject(ctx, p); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := db.AddProject(ctx, &models.Project{Name: "Website", ClientID: &client.ID}); err != utils.ErrAlreadyExists {
+			t.Fatalf("duplicate name: err = %v, want %v", err, utils.ErrAlreadyExists)
+		}
+		// names are unique per client
+		sameName := models.Project{Name: "Hiring", ClientID: &client.ID}
+		if err := db.AddProject(ctx, &sameName); err != nil {
+			t.Fatalf("name of a project without the client: %v", err)
+		}
+		if err := db.AddProject(ctx, &models.Project{Name: "Shop", ClientID: &missing}); err != utils.ErrClientNotFound {
+			t.Fatalf("unknown client: err = %v, want %v", err, utils.ErrClientNotFound)
+		}
+
+		projects, err := db.GetProjects(ctx, models.GetProjectsRequest{ClientID: &client.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []models.Project{sameName, billed}; !reflect.DeepEqual(projects, want) {
+			t.Fatalf("projects of the client = %+v, want %+v", projects, want)
+		}
+		projects, err = db.GetProjects(ctx, models.GetProjectsRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(projects) != 3 {
+			t.Fatalf("projects = %+v, want all three", projects)
+		}
+
+		internal.ClientID = &client.ID
+		internal.Name = "Recruiting"
+		if err := db.UpdateProject(ctx, &internal); err != nil {
+			t.Fatal(err)
+		}
+		got, err := db.GetProjectByID(ctx, internal.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(*got, internal) {
+			t.Fatalf("project = %+v, want %+v", *got, internal)
+		}
+		if err := db.UpdateProject(ctx, &models.Project{ID: internal.ID, Name: "Website", ClientID: &client.ID}); err != utils.ErrAlreadyExists {
+			t.Fatalf("rename onto another project: err = %v, want %v", err, utils.ErrAlreadyExists)
+		}
+		if err := db.UpdateProject(ctx, &models.Project{ID: internal.ID, Name: "Recruiting", ClientID: &missing}); err != utils.ErrClientNotFound {
+			t.Fatalf("move to an unknown client: err = %v, want %v", err, utils.ErrClientNotFound)
+		}
+
+		userID := mustAddUser(t, ctx, db, "1234", "567890")
+		task := models.Task{UserID: userID, ProjectID: &billed.ID}
+		if err := db.AddStartTask(ctx, &task, false); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.AddStartTask(ctx, &models.Task{UserID: userID, ProjectID: &missing}, true); err != utils.ErrProjectNotFound {
+			t.Fatalf("task of an unknown project: err = %v, want %v", err, utils.ErrProjectNotFound)
+		}
+		if err := db.DeleteProject(ctx, billed.ID); err != utils.ErrInUse {
+			t.Fatalf("delete of a project with tasks: err = %v, want %v", err, utils.ErrInUse)
+		}
+		if err := db.DeleteProject(ctx, internal.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.GetProjectByID(ctx, internal.ID); err != sql.ErrNoRows {
+			t.Fatalf("deleted project: err = %v, want %v", err, sql.ErrNoRows)
+		}
+		if err := db.DeleteProject(ctx, internal.ID); err != sql.ErrNoRows {
+			t.Fatalf("second delete: err = %v, want %v", err, sql.ErrNoRows)
+		}
+	})
+}
+
+func TestWorklogProjectFilters(t *testing.T) {
+	day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -2)
+	forEachStore(t, func(t *testing.T, db Database) {
+		ctx := defaultOrg()
+		userID := mustAddUser(t, ctx, db, "1234", "567890")
+		acme, globex := models.Client{Name: "Acme"}, models.Client{Name: "Globex"}
+		for _, c := range []*models.Client{&acme, &globex} {
+			if err := db.AddClient(ctx, c); err != nil {
+				t.Fatal(err)
+			}
+		}
+		website := models.Project{Name: "Website", ClientID: &acme.ID}
+		shop := models.Project{Name: "Shop", ClientID: &acme.ID}
+		portal := models.Project{Name: "Portal", ClientID: &globex.ID}
+		internal := models.Project{Name: "Hiring"}
+		for _, p := range []*models.Project{&website, &shop, &portal, &internal} {
+			if err := db.AddProject(ctx, p); err != nil {
+				t.Fatal(err)
+			}
+		}
+		tasks := map[string]*int{"website": &website.ID, "shop": &shop.ID, "portal": &portal.ID, "hiring": &internal.ID, "none": nil}
+		ids := make(map[int]string)
+		hour := 0
+		for _, desc := range []string{"website", "shop", "portal", "hiring", "none"} {
+			start, end := day.Add(time.Duration(hour)*time.Hour), day.Add(time.Duration(hour+1)*time.Hour)
+			task := models.Task{UserID: userID, ProjectID: tasks[desc], Desc: desc, StartTime: start, EndTime: &end}
+			if err := db.AddTask(ctx, &task); err != nil {
+				t.Fatal(err)
+			}
+			ids[task.ID] = desc
+			hour++
+		}
+
+		tests := []struct {
+			name      string
+			projectID *int
+			clientID  *int
+			want      []string
+		}{
+			{"no filter", nil, nil, []string{"hiring", "none", "portal", "shop", "website"}},
+			{"project", &shop.ID, nil, []string{"shop"}},
+			{"client", nil, &acme.ID, []string{"shop", "website"}},
+			{"project of the client", &website.ID, &acme.ID, []string{"website"}},
+			{"project of another client", &portal.ID, &acme.ID, nil},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				worklogs, err := db.GetUserWorklogs(ctx, &models.GetUserWorklogsRequest{
+					UserID:    userID,
+					StartDate: day,
+					EndDate:   day.AddDate(0, 0, 1),
+					ProjectID: tt.projectID,
+					ClientID:  tt.clientID,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				var got []string
+				for _, w := range worklogs {
+					got = append(got, ids[w.TaskID])
+				}
+				if len(got) != len(tt.want) {
+					t.Fatalf("worklogs of %v, want %v", got, tt.want)
+				}
+				seen := make(map[string]bool)
+				for _, desc := range got {
+					seen[desc] = true
+				}
+				for _, desc := range tt.want {
+					if !seen[desc] {
+						t.Fatalf("worklogs of %v, want %v", got, tt.want)
+					}
+				}
+			})
+		}
+
+		report, err := db.GetUserWorklogGroups(ctx, &models.GetUserWorklogsRequest{
+			UserID:    userID,
+			StartDate: day,
+			EndDate:   day.AddDate(0, 0, 1),
+			ClientID:  &acme.ID,
+			GroupBy:   models.GroupByProject,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Total.Seconds != 2*3600 || len(report.Groups) != 2 {
+			t.Fatalf("report = %+v, want an hour of each Acme project", report)
+		}
+	})
+}
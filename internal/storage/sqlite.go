@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"time-tracker/configs"
+	"time-tracker/internal/utils"
+	"time-tracker/migrations"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQLite keeps the whole database in the file named by DB_NAME.
+type SQLite struct {
+	sqlStore
+	cfg configs.DatabaseConfig
+}
+
+func NewSQLite(cfg configs.DatabaseConfig) *SQLite {
+	return &SQLite{
+		sqlStore: sqlStore{timeout: cfg.QueryTimeout},
+		cfg:      cfg,
+	}
+}
+
+func (s *SQLite) connectionString() string {
+	return "file:" + s.cfg.Name + "?_foreign_keys=on&_busy_timeout=5000"
+}
+
+func (s *SQLite) Connect() error {
+	db, err := sql.Open(DriverSQLite, s.connectionString())
+	if err != nil {
+		return errors.New(utils.ErrCantOpenDB)
+	}
+	if db.Ping() != nil {
+		return errors.New(utils.ErrCantPingDB)
+	}
+	// sqlite allows a single writer, extra connections only end up in SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+	migrations.Up("sqlite", DriverSQLite+"://"+s.cfg.Name)
+	s.sql = db
+	return nil
+}
+
+func (s *SQLite) Disconnect() error {
+	return s.sql.Close()
+}
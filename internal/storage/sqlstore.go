@@ -0,0 +1,634 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"time-tracker/internal/models"
+	"time-tracker/internal/utils"
+
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+// sqlStore holds the queries shared by the database/sql backends.
+// The queries stick to SQL understood by both Postgres and SQLite.
+type sqlStore struct {
+	sql *sql.DB
+	// upper bound for a single query, zero means no limit
+	timeout time.Duration
+}
+
+// withTimeout bounds ctx by the configured query timeout.
+func (s *sqlStore) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, s.timeout)
+}
+
+func (s *sqlStore) GetUsers(ctx context.Context, req models.GetUsersRequest) (map[int]models.User, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		SELECT id, passport_number, pass_serie, surname, name, patronymic, address, enrichment_status 
+		FROM users 
+		WHERE org_id = $1
+	`
+	params := []interface{}{org}
+	paramCounter := 2
+
+	if req.PassportNumber != "" {
+		query += fmt.Sprintf(" AND passport_number = $%d", paramCounter)
+		params = append(params, req.PassportNumber)
+		paramCounter++
+	}
+	if req.PassSerie != "" {
+		query += fmt.Sprintf(" AND pass_serie = $%d", paramCounter)
+		params = append(params, req.PassSerie)
+		paramCounter++
+	}
+	if req.Surname != "" {
+		query += fmt.Sprintf(" AND surname = $%d", paramCounter)
+		params = append(params, req.Surname)
+		paramCounter++
+	}
+	if req.Name != "" {
+		query += fmt.Sprintf(" AND name = $%d", paramCounter)
+		params = append(params, req.Name)
+		paramCounter++
+	}
+	if req.Patronymic != "" {
+		query += fmt.Sprintf(" AND patronymic = $%d", paramCounter)
+		params = append(params, req.Patronymic)
+		paramCounter++
+	}
+	if req.Address != "" {
+		query += fmt.Sprintf(" AND address = $%d", paramCounter)
+		params = append(params, req.Address)
+		paramCounter++
+	}
+	if req.EnrichmentStatus != "" {
+		query += fmt.Sprintf(" AND enrichment_status = $%d", paramCounter)
+		params = append(params, req.EnrichmentStatus)
+		paramCounter++
+	}
+	if req.TeamID != nil {
+		query += fmt.Sprintf(" AND id IN (SELECT user_id FROM team_members WHERE team_id = $%d)", paramCounter)
+		params = append(params, *req.TeamID)
+		paramCounter++
+	}
+	// pagination
+	offset := (req.Page - 1) * req.PageSize
+	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", paramCounter, paramCounter+1)
+	params = append(params, req.PageSize, offset)
+
+	rows, err := s.sql.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, fmt.Errorf(utils.ErrQuery, query, params, err)
+	}
+	defer rows.Close()
+
+	users := make(map[int]models.User)
+	for rows.Next() {
+		var id int
+		var surname, name, patronymic, address, passportNumber, passportSerie, enrichmentStatus string
+		if err := rows.Scan(&id, &passportNumber, &passportSerie, &surname, &name, &patronymic, &address, &enrichmentStatus); err != nil {
+			return nil, fmt.Errorf(utils.ErrScanRow, query, params, err)
+		}
+		user := models.User{
+			Id:         id,
+			PassNumber: passportNumber,
+			PassSerie:  passportSerie,
+			Surname:    surname,
+			Name:       name,
+			Patronymic: patronymic,
+			Address:    address,
+
+			EnrichmentStatus: enrichmentStatus,
+		}
+		users[id] = user
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(utils.ErrRowIteration, query, params, err)
+	}
+	return users, nil
+}
+
+func (s *sqlStore) GetUserByID(ctx context.Context, id int) (*models.User, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var user models.User
+	query := `
+		SELECT id, passport_number, pass_serie, surname, name, patronymic, address, enrichment_status 
+		FROM users 
+		WHERE id = $1 AND org_id = $2
+	`
+	row := s.sql.QueryRowContext(ctx, query, id, org)
+	err = row.Scan(&user.Id, &user.PassNumber, &user.PassSerie, &user.Surname, &user.Name, &user.Patronymic, &user.Address, &user.EnrichmentStatus)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (s *sqlStore) AddUser(ctx context.Context, u *models.User) (*models.User, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	u.EnrichmentStatus = models.EnrichmentDone
+	if err := addUser(ctx, s.sql, u, org); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// addUser inserts u into org with the enrichment status of u and fills u.Id.
+func addUser(ctx context.Context, q querier, u *models.User, org int) error {
+	query := `
+		INSERT INTO users (passport_number, pass_serie, name, surname, patronymic, address, enrichment_status, org_id) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`
+	err := q.QueryRowContext(ctx, query, u.PassNumber, u.PassSerie, u.Name, u.Surname, u.Patronymic, u.Address, u.EnrichmentStatus, org).Scan(&u.Id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return utils.ErrAlreadyExists
+		}
+		return fmt.Errorf(utils.ErrQuery, query, u, err)
+	}
+	return nil
+}
+
+func (s *sqlStore) DeleteUser(ctx context.Context, id int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s.GetUserByID(ctx, id)
+	if err == sql.ErrNoRows {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf(utils.ErrQuery, "GetUserByID(id)", id, err)
+	}
+	tx, err := s.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf(utils.ErrBeginTx, err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	query := `
+		DELETE FROM calendar_tokens
+		WHERE user_id = $1
+	`
+	_, err = tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf(utils.ErrQuery, query, id, err)
+	}
+
+	query = `
+		DELETE FROM api_keys
+		WHERE user_id = $1
+	`
+	_, err = tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf(utils.ErrQuery, query, id, err)
+	}
+
+	query = `
+		DELETE FROM enrichment_jobs
+		WHERE user_id = $1
+	`
+	_, err = tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf(utils.ErrQuery, query, id, err)
+	}
+
+	query = `
+		DELETE FROM task_tags 
+		WHERE task_id IN (SELECT id FROM tasks WHERE user_id = $1)
+	`
+	_, err = tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf(utils.ErrQuery, query, id, err)
+	}
+
+	query = `
+		DELETE FROM task_segments 
+		WHERE user_id = $1
+	`
+	_, err = tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf(utils.ErrQuery, query, id, err)
+	}
+
+	query = `
+		DELETE FROM tasks 
+		WHERE user_id = $1
+	`
+	_, err = tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf(utils.ErrQuery, query, id, err)
+	}
+
+	query = `
+		DELETE FROM users 
+		WHERE id = $1
+	`
+	_, err = tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf(utils.ErrQuery, query, id, err)
+	}
+
+	return tx.Commit()
+}
+
+func (s *sqlStore) UpdateUser(ctx context.Context, u *models.User) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	query := `
+		UPDATE users 
+		SET surname = $1, name = $2, patronymic = $3, address = $4 
+		WHERE id = $5 AND org_id = $6
+	`
+	res, err := s.sql.ExecContext(ctx, query, u.Surname, u.Name, u.Patronymic, u.Address, u.Id, org)
+	if err != nil {
+		return fmt.Errorf(utils.ErrQuery, query, u, err)
+	}
+	return mustAffect(res, query, u)
+}
+
+func (s *sqlStore) AddStartTask(ctx context.Context, t *models.Task, stopRunning bool) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf(utils.ErrBeginTx, err)
+	}
+	defer tx.Rollback()
+
+	if err := userExists(ctx, tx, t.UserID); err != nil {
+		return err
+	}
+	if t.ProjectID != nil {
+		if err := projectExists(ctx, tx, *t.ProjectID); err != nil {
+			return err
+		}
+	}
+	now := time.Now().UTC()
+	if err := makeRoom(ctx, tx, t.UserID, stopRunning, now); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO tasks (user_id, project_id, description, start_time) 
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+	err = tx.QueryRowContext(ctx, query, t.UserID, t.ProjectID, t.Desc, now).Scan(&t.ID)
+	if err != nil {
+		return fmt.Errorf(utils.ErrQuery, query, t, err)
+	}
+	segment, err := addSegment(ctx, tx, t.ID, t.UserID, now)
+	if err != nil {
+		return err
+	}
+	if err := setTags(ctx, tx, t.ID, t.Tags); err != nil {
+		return err
+	}
+	t.StartTime = now
+	t.Segments = []models.TaskSegment{*segment}
+	return tx.Commit()
+}
+
+func (s *sqlStore) AddEndTask(ctx context.Context, id int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf(utils.ErrBeginTx, err)
+	}
+	defer tx.Rollback()
+
+	_, stopped, err := taskState(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	if stopped {
+		return utils.ErrTaskStopped
+	}
+	if err := stopTask(ctx, tx, id, time.Now().UTC()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *sqlStore) PauseTask(ctx context.Context, id int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf(utils.ErrBeginTx, err)
+	}
+	defer tx.Rollback()
+
+	if _, _, err := taskState(ctx, tx, id); err != nil {
+		return err
+	}
+	query := `
+		UPDATE task_segments 
+		SET end_time = $1 WHERE task_id = $2 AND end_time IS NULL
+	`
+	res, err := tx.ExecContext(ctx, query, time.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf(utils.ErrQuery, query, id, err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf(utils.ErrQuery, query, id, err)
+	} else if n == 0 {
+		return utils.ErrTaskNotRunning
+	}
+	return tx.Commit()
+}
+
+func (s *sqlStore) ResumeTask(ctx context.Context, id int, stopRunning bool) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf(utils.ErrBeginTx, err)
+	}
+	defer tx.Rollback()
+
+	userID, stopped, err := taskState(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	if stopped {
+		return utils.ErrTaskStopped
+	}
+	runningID, err := runningTaskID(ctx, tx, userID)
+	if err != nil {
+		return err
+	}
+	if runningID == id {
+		return utils.ErrTaskAlreadyRunning
+	}
+	now := time.Now().UTC()
+	if err := makeRoom(ctx, tx, userID, stopRunning, now); err != nil {
+		return err
+	}
+	if _, err := addSegment(ctx, tx, id, userID, now); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *sqlStore) GetActiveTask(ctx context.Context, userID int) (*models.Task, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var t models.Task
+	query := `
+		SELECT t.id, t.user_id, t.project_id, t.description, t.start_time 
+		FROM tasks t
+		JOIN task_segments s ON s.task_id = t.id
+		JOIN users u ON u.id = t.user_id
+		WHERE s.user_id = $1 AND s.end_time IS NULL AND u.org_id = $2
+	`
+	err = s.sql.QueryRowContext(ctx, query, userID, org).Scan(&t.ID, &t.UserID, &t.ProjectID, &t.Desc, &t.StartTime)
+	if err != nil {
+		return nil, err
+	}
+	t.Segments, err = taskSegments(ctx, s.sql, t.ID)
+	if err != nil {
+		return nil, err
+	}
+	t.Tags, err = taskTags(ctx, s.sql, t.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// querier is what *sql.DB and *sql.Tx have in common.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// runningTaskID returns the id of the user's task with an open segment, 0 if none.
+func runningTaskID(ctx context.Context, q querier, userID int) (int, error) {
+	query := `
+		SELECT task_id 
+		FROM task_segments 
+		WHERE user_id = $1 AND end_time IS NULL
+	`
+	var id int
+	err := q.QueryRowContext(ctx, query, userID).Scan(&id)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, fmt.Errorf(utils.ErrQuery, query, userID, err)
+	}
+	return id, nil
+}
+
+// makeRoom lets the user start tracking another task: the running one is
+// stopped when stopRunning is set, otherwise utils.ErrTaskAlreadyRunning is returned.
+func makeRoom(ctx context.Context, q querier, userID int, stopRunning bool, now time.Time) error {
+	runningID, err := runningTaskID(ctx, q, userID)
+	if err != nil || runningID == 0 {
+		return err
+	}
+	if !stopRunning {
+		return utils.ErrTaskAlreadyRunning
+	}
+	return stopTask(ctx, q, runningID, now)
+}
+
+// projectExists returns utils.ErrProjectNotFound for a project id unknown
+// in the organization of ctx.
+func projectExists(ctx context.Context, q querier, id int) error {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	query := `
+		SELECT id 
+		FROM projects 
+		WHERE id = $1 AND org_id = $2
+	`
+	err = q.QueryRowContext(ctx, query, id, org).Scan(&id)
+	if err == sql.ErrNoRows {
+		return utils.ErrProjectNotFound
+	}
+	if err != nil {
+		return fmt.Errorf(utils.ErrQuery, query, id, err)
+	}
+	return nil
+}
+
+// taskState returns the owner of the task and whether it has been stopped,
+// sql.ErrNoRows for a task outside the organization of ctx.
+func taskState(ctx context.Context, q querier, id int) (userID int, stopped bool, err error) {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	query := `
+		SELECT t.user_id, t.end_time IS NOT NULL 
+		FROM tasks t
+		JOIN users u ON u.id = t.user_id
+		WHERE t.id = $1 AND u.org_id = $2
+	`
+	err = q.QueryRowContext(ctx, query, id, org).Scan(&userID, &stopped)
+	if err == sql.ErrNoRows {
+		return 0, false, err
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf(utils.ErrQuery, query, id, err)
+	}
+	return userID, stopped, nil
+}
+
+func addSegment(ctx context.Context, q querier, taskID, userID int, now time.Time) (*models.TaskSegment, error) {
+	query := `
+		INSERT INTO task_segments (task_id, user_id, start_time) 
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`
+	segment := &models.TaskSegment{TaskID: taskID, StartTime: now}
+	err := q.QueryRowContext(ctx, query, taskID, userID, now).Scan(&segment.ID)
+	if err != nil {
+		// a concurrent start won the race for the running slot
+		if isUniqueViolation(err) {
+			return nil, utils.ErrTaskAlreadyRunning
+		}
+		if isExclusionViolation(err) {
+			return nil, utils.ErrOverlap
+		}
+		return nil, fmt.Errorf(utils.ErrQuery, query, segment, err)
+	}
+	return segment, nil
+}
+
+// stopTask closes the open segment of the task, if any, and marks it stopped.
+func stopTask(ctx context.Context, q querier, id int, now time.Time) error {
+	query := `
+		UPDATE task_segments 
+		SET end_time = $1 WHERE task_id = $2 AND end_time IS NULL
+	`
+	if _, err := q.ExecContext(ctx, query, now, id); err != nil {
+		return fmt.Errorf(utils.ErrQuery, query, id, err)
+	}
+	query = `
+		UPDATE tasks 
+		SET end_time = $1 WHERE id = $2 AND end_time IS NULL
+	`
+	if _, err := q.ExecContext(ctx, query, now, id); err != nil {
+		return fmt.Errorf(utils.ErrQuery, query, id, err)
+	}
+	return nil
+}
+
+func taskSegments(ctx context.Context, q querier, taskID int) ([]models.TaskSegment, error) {
+	query := `
+		SELECT id, task_id, start_time, end_time 
+		FROM task_segments 
+		WHERE task_id = $1
+		ORDER BY start_time
+	`
+	rows, err := q.QueryContext(ctx, query, taskID)
+	if err != nil {
+		return nil, fmt.Errorf(utils.ErrQuery, query, taskID, err)
+	}
+	defer rows.Close()
+
+	var segments []models.TaskSegment
+	for rows.Next() {
+		var segment models.TaskSegment
+		if err := rows.Scan(&segment.ID, &segment.TaskID, &segment.StartTime, &segment.EndTime); err != nil {
+			return nil, fmt.Errorf(utils.ErrScanRow, query, taskID, err)
+		}
+		segments = append(segments, segment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(utils.ErrRowIteration, query, taskID, err)
+	}
+	return segments, nil
+}
+
+// isUniqueViolation reports whether err comes from a unique index,
+// whichever driver returned it.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23505"
+	}
+	var liteErr sqlite3.Error
+	if errors.As(err, &liteErr) {
+		return liteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+	}
+	return false
+}
+
+// isForeignKeyViolation reports whether err comes from a foreign key,
+// whichever driver returned it.
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23503"
+	}
+	var liteErr sqlite3.Error
+	if errors.As(err, &liteErr) {
+		return liteErr.ExtendedCode == sqlite3.ErrConstraintForeignKey
+	}
+	return false
+}
+
+// isExclusionViolation reports whether err comes from an exclusion
+// constraint, only Postgres has them.
+func isExclusionViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23P01"
+}
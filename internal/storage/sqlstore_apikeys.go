@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"time-tracker/internal/models"
+	"time-tracker/internal/utils"
+)
+
+func (s *sqlStore) AddAPIKey(ctx context.Context, k *models.APIKey, hash string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := userInOrg(ctx, s.sql, k.UserID); err != nil {
+		return err
+	}
+	k.CreatedAt = time.Now().UTC()
+	query := `
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+	err := s.sql.QueryRowContext(ctx, query, k.UserID, k.Name, k.Prefix, hash, k.CreatedAt).Scan(&k.ID)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return sql.ErrNoRows
+		}
+		return fmt.Errorf(utils.ErrQuery, query, k, err)
+	}
+	return nil
+}
+
+func (s *sqlStore) GetAPIKeys(ctx context.Context, userID int) ([]models.APIKey, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		SELECT k.id, k.user_id, k.name, k.prefix, k.created_at, k.last_used_at
+		FROM api_keys k
+		JOIN users u ON u.id = k.user_id
+		WHERE k.user_id = $1 AND u.org_id = $2
+		ORDER BY k.id
+	`
+	rows, err := s.sql.QueryContext(ctx, query, userID, org)
+	if err != nil {
+		return nil, fmt.Errorf(utils.ErrQuery, query, userID, err)
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		var k models.APIKey
+		if err := rows.Scan(&k.ID, &k.UserID, &k.Name, &k.Prefix, &k.CreatedAt, &k.LastUsedAt); err != nil {
+			return nil, fmt.Errorf(utils.ErrScanRow, query, userID, err)
+		}
+		keys = append(keys, k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(utils.ErrRowIteration, query, userID, err)
+	}
+	return keys, nil
+}
+
+func (s *sqlStore) DeleteAPIKey(ctx context.Context, userID, id int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	query := `
+		DELETE FROM api_keys
+		WHERE user_id = $1 AND id = $2
+			AND user_id IN (SELECT id FROM users WHERE org_id = $3)
+	`
+	res, err := s.sql.ExecContext(ctx, query, userID, id, org)
+	if err != nil {
+		return fmt.Errorf(utils.ErrQuery, query, id, err)
+	}
+	return mustAffect(res, query, id)
+}
+
+func (s *sqlStore) UseAPIKey(ctx context.Context, hash string, now time.Time) (int, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var userID int
+	query := `
+		UPDATE api_keys
+		SET last_used_at = $1
+		WHERE key_hash = $2
+		RETURNING user_id
+	`
+	err := s.sql.QueryRowContext(ctx, query, now.UTC(), hash).Scan(&userID)
+	if err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
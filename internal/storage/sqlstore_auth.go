@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time-tracker/internal/models"
+	"time-tracker/internal/utils"
+)
+
+func (s *sqlStore) SetUserPassword(ctx context.Context, userID int, hash string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	query := `
+		UPDATE users
+		SET password_hash = $1
+		WHERE id = $2 AND org_id = $3
+	`
+	res, err := s.sql.ExecContext(ctx, query, hash, userID, org)
+	if err != nil {
+		return fmt.Errorf(utils.ErrQuery, query, userID, err)
+	}
+	return mustAffect(res, query, userID)
+}
+
+func (s *sqlStore) GetCredentials(ctx context.Context, passSerie, passNumber string) ([]models.Credential, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT id, org_id, password_hash
+		FROM users
+		WHERE pass_serie = $1 AND passport_number = $2
+		ORDER BY id
+	`
+	params := []string{passSerie, passNumber}
+	rows, err := s.sql.QueryContext(ctx, query, passSerie, passNumber)
+	if err != nil {
+		return nil, fmt.Errorf(utils.ErrQuery, query, params, err)
+	}
+	defer rows.Close()
+
+	var creds []models.Credential
+	for rows.Next() {
+		var c models.Credential
+		var hash sql.NullString
+		if err := rows.Scan(&c.UserID, &c.OrgID, &hash); err != nil {
+			return nil, fmt.Errorf(utils.ErrScanRow, query, params, err)
+		}
+		c.PasswordHash = hash.String
+		creds = append(creds, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(utils.ErrRowIteration, query, params, err)
+	}
+	return creds, nil
+}
+
+func (s *sqlStore) GetPrincipal(ctx context.Context, userID int) (*models.Principal, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	p := models.Principal{UserID: userID}
+	query := `
+		SELECT role, org_id
+		FROM users
+		WHERE id = $1
+	`
+	err := s.sql.QueryRowContext(ctx, query, userID).Scan(&p.Role, &p.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	query = `
+		SELECT id
+		FROM users
+		WHERE manager_id = $1
+		UNION
+		SELECT m.user_id
+		FROM team_members m
+		JOIN teams t ON t.id = m.team_id
+		WHERE t.lead_id = $1
+		ORDER BY 1
+	`
+	if p.Team, err = s.ids(ctx, query, userID); err != nil {
+		return nil, err
+	}
+
+	query = `
+		SELECT id
+		FROM teams
+		WHERE lead_id = $1
+		ORDER BY id
+	`
+	if p.Teams, err = s.ids(ctx, query, userID); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ids runs a query selecting a single id column.
+func (s *sqlStore) ids(ctx context.Context, query string, params ...interface{}) ([]int, error) {
+	rows, err := s.sql.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, fmt.Errorf(utils.ErrQuery, query, params, err)
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf(utils.ErrScanRow, query, params, err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(utils.ErrRowIteration, query, params, err)
+	}
+	return ids, nil
+}
+
+func (s *sqlStore) SetUserRole(ctx context.Context, userID int, role models.Role, managerID *int) error {
+	if managerID != nil && *managerID == userID {
+		return utils.ErrInvalidManager
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	if managerID != nil {
+		err := userInOrg(ctx, s.sql, *managerID)
+		if err == sql.ErrNoRows {
+			return utils.ErrInvalidManager
+		}
+		if err != nil {
+			return err
+		}
+	}
+	query := `
+		UPDATE users
+		SET role = $1, manager_id = $2
+		WHERE id = $3 AND org_id = $4
+	`
+	res, err := s.sql.ExecContext(ctx, query, role, managerID, userID, org)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return utils.ErrInvalidManager
+		}
+		return fmt.Errorf(utils.ErrQuery, query, userID, err)
+	}
+	return mustAffect(res, query, userID)
+}
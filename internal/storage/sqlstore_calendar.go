@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time-tracker/internal/utils"
+)
+
+func (s *sqlStore) SetCalendarTokenHash(ctx context.Context, userID int, hash string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := userInOrg(ctx, s.sql, userID); err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO calendar_tokens (user_id, token_hash)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET token_hash = EXCLUDED.token_hash
+	`
+	if _, err := s.sql.ExecContext(ctx, query, userID, hash); err != nil {
+		if isForeignKeyViolation(err) {
+			return sql.ErrNoRows
+		}
+		return fmt.Errorf(utils.ErrQuery, query, userID, err)
+	}
+	return nil
+}
+
+func (s *sqlStore) GetCalendarTokenHash(ctx context.Context, userID int) (string, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	org, err := orgOf(ctx)
+	if err != nil {
+		return "", err
+	}
+	var hash string
+	query := `
+		SELECT c.token_hash
+		FROM calendar_tokens c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.user_id = $1 AND u.org_id = $2
+	`
+	err = s.sql.QueryRowContext(ctx, query, userID, org).Scan(&hash)
+	if err != nil {
+		return "", err
+	}
+	return hash, nil
+}
@@ -0,0 +1,215 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"time-tracker/internal/models"
+	"time-tracker/internal/utils"
+)
+
+func (s *sqlStore) AddPendingUser(ctx context.Context, u *models.User) (*models.User, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := s.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf(utils.ErrBeginTx, err)
+	}
+	defer tx.Rollback()
+
+	u.EnrichmentStatus = models.EnrichmentPending
+	if err := addUser(ctx, tx, u, org); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	query := `
+		INSERT INTO enrichment_jobs (user_id, next_attempt_at, created_at)
+		VALUES ($1, $2, $3)
+	`
+	if _, err := tx.ExecContext(ctx, query, u.Id, now, now); err != nil {
+		return nil, fmt.Errorf(utils.ErrQuery, query, u.Id, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (s *sqlStore) ClaimEnrichmentJobs(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.EnrichmentJob, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf(utils.ErrBeginTx, err)
+	}
+	defer tx.Rollback()
+
+	now = now.UTC()
+	query := `
+		SELECT j.user_id, u.org_id, u.pass_serie, u.passport_number, j.attempts
+		FROM enrichment_jobs j
+		JOIN users u ON u.id = j.user_id
+		WHERE j.next_attempt_at <= $1
+		ORDER BY j.next_attempt_at, j.user_id
+		LIMIT $2
+	`
+	rows, err := tx.QueryContext(ctx, query, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf(utils.ErrQuery, query, now, err)
+	}
+	var due []models.EnrichmentJob
+	for rows.Next() {
+		var j models.EnrichmentJob
+		if err := rows.Scan(&j.UserID, &j.OrgID, &j.PassSerie, &j.PassNumber, &j.Attempts); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf(utils.ErrScanRow, query, now, err)
+		}
+		due = append(due, j)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(utils.ErrRowIteration, query, now, err)
+	}
+
+	jobs := []models.EnrichmentJob{}
+	for _, j := range due {
+		claimed, err := claimJob(ctx, tx, j, now.Add(lease))
+		if err != nil {
+			return nil, err
+		}
+		if claimed {
+			j.Attempts++
+			jobs = append(jobs, j)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (s *sqlStore) CompleteEnrichment(ctx context.Context, u *models.User) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf(utils.ErrBeginTx, err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE users
+		SET surname = $1, name = $2, patronymic = $3, address = $4, enrichment_status = $5
+		WHERE id = $6 AND enrichment_status = $7
+	`
+	res, err := tx.ExecContext(ctx, query, u.Surname, u.Name, u.Patronymic, u.Address, models.EnrichmentDone, u.Id, models.EnrichmentPending)
+	if err != nil {
+		return fmt.Errorf(utils.ErrQuery, query, u, err)
+	}
+	// a job of a user that is not pending any more is dropped too, it
+	// would be claimed for ever
+	pending := mustAffect(res, query, u)
+	if pending != nil && pending != sql.ErrNoRows {
+		return pending
+	}
+	if err := dropEnrichmentJob(ctx, tx, u.Id); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if pending != nil {
+		return pending
+	}
+	u.EnrichmentStatus = models.EnrichmentDone
+	return nil
+}
+
+func (s *sqlStore) RetryEnrichment(ctx context.Context, userID int, at time.Time, reason string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		UPDATE enrichment_jobs
+		SET next_attempt_at = $1, last_error = $2
+		WHERE user_id = $3
+	`
+	res, err := s.sql.ExecContext(ctx, query, at.UTC(), reason, userID)
+	if err != nil {
+		return fmt.Errorf(utils.ErrQuery, query, userID, err)
+	}
+	return mustAffect(res, query, userID)
+}
+
+func (s *sqlStore) FailEnrichment(ctx context.Context, userID int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf(utils.ErrBeginTx, err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE users
+		SET enrichment_status = $1
+		WHERE id = $2 AND enrichment_status = $3
+	`
+	res, err := tx.ExecContext(ctx, query, models.EnrichmentFailed, userID, models.EnrichmentPending)
+	if err != nil {
+		return fmt.Errorf(utils.ErrQuery, query, userID, err)
+	}
+	pending := mustAffect(res, query, userID)
+	if pending != nil && pending != sql.ErrNoRows {
+		return pending
+	}
+	if err := dropEnrichmentJob(ctx, tx, userID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return pending
+}
+
+// claimJob hides the job j was read from until the lease ends and counts
+// the attempt. attempts is the version of a job: one claimed by another
+// worker since it was read is left to that worker and claimed is false.
+func claimJob(ctx context.Context, q querier, j models.EnrichmentJob, until time.Time) (claimed bool, err error) {
+	query := `
+		UPDATE enrichment_jobs
+		SET attempts = attempts + 1, next_attempt_at = $1
+		WHERE user_id = $2 AND attempts = $3
+	`
+	res, err := q.ExecContext(ctx, query, until, j.UserID, j.Attempts)
+	if err != nil {
+		return false, fmt.Errorf(utils.ErrQuery, query, j, err)
+	}
+	if err := mustAffect(res, query, j); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func dropEnrichmentJob(ctx context.Context, q querier, userID int) error {
+	query := `
+		DELETE FROM enrichment_jobs
+		WHERE user_id = $1
+	`
+	if _, err := q.ExecContext(ctx, query, userID); err != nil {
+		return fmt.Errorf(utils.ErrQuery, query, userID, err)
+	}
+	return nil
+}
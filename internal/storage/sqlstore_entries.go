@@ -0,0 +1,259 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"time-tracker/internal/models"
+	"time-tracker/internal/utils"
+)
+
+func (s *sqlStore) AddTask(ctx context.Context, t *models.Task) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := checkEntry(t.StartTime, *t.EndTime, time.Now()); err != nil {
+		return err
+	}
+	start, end := t.StartTime.UTC(), t.EndTime.UTC()
+
+	tx, err := s.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf(utils.ErrBeginTx, err)
+	}
+	defer tx.Rollback()
+
+	if err := userExists(ctx, tx, t.UserID); err != nil {
+		return err
+	}
+	if t.ProjectID != nil {
+		if err := projectExists(ctx, tx, *t.ProjectID); err != nil {
+			return err
+		}
+	}
+	if err := checkOverlap(ctx, tx, t.UserID, 0, start, end); err != nil {
+		return err
+	}
+
+	if err := insertEntry(ctx, tx, t, start, end); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertEntry inserts a finished task with a single segment and its tags.
+func insertEntry(ctx context.Context, q querier, t *models.Task, start, end time.Time) error {
+	query := `
+		INSERT INTO tasks (user_id, project_id, description, start_time, end_time)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+	err := q.QueryRowContext(ctx, query, t.UserID, t.ProjectID, t.Desc, start, end).Scan(&t.ID)
+	if err != nil {
+		return fmt.Errorf(utils.ErrQuery, query, t, err)
+	}
+	segment := models.TaskSegment{TaskID: t.ID, StartTime: start, EndTime: &end}
+	query = `
+		INSERT INTO task_segments (task_id, user_id, start_time, end_time)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+	err = q.QueryRowContext(ctx, query, t.ID, t.UserID, start, end).Scan(&segment.ID)
+	if err != nil {
+		if isExclusionViolation(err) {
+			return utils.ErrOverlap
+		}
+		return fmt.Errorf(utils.ErrQuery, query, segment, err)
+	}
+	if err := setTags(ctx, q, t.ID, t.Tags); err != nil {
+		return err
+	}
+	t.StartTime, t.EndTime = start, &end
+	t.Segments = []models.TaskSegment{segment}
+	return nil
+}
+
+func (s *sqlStore) GetTaskByID(ctx context.Context, id int) (*models.Task, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return getTask(ctx, s.sql, id)
+}
+
+func (s *sqlStore) UpdateTask(ctx context.Context, id int, upd models.TaskUpdate) (*models.Task, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf(utils.ErrBeginTx, err)
+	}
+	defer tx.Rollback()
+
+	t, err := getTask(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	moved, err := retime(t, upd, now)
+	if err != nil {
+		return nil, err
+	}
+	for n := range t.Segments {
+		segment := &t.Segments[n]
+		segment.StartTime = segment.StartTime.UTC()
+		if segment.EndTime != nil {
+			end := segment.EndTime.UTC()
+			segment.EndTime = &end
+		}
+		if !moved[segment.ID] {
+			continue
+		}
+		err := checkOverlap(ctx, tx, t.UserID, t.ID, segment.StartTime, segmentEnd(*segment, now))
+		if err != nil {
+			return nil, err
+		}
+		query := `
+			UPDATE task_segments
+			SET start_time = $1, end_time = $2
+			WHERE id = $3
+		`
+		if _, err := tx.ExecContext(ctx, query, segment.StartTime, segment.EndTime, segment.ID); err != nil {
+			if isExclusionViolation(err) {
+				return nil, utils.ErrOverlap
+			}
+			return nil, fmt.Errorf(utils.ErrQuery, query, segment, err)
+		}
+	}
+	t.StartTime = t.StartTime.UTC()
+	if t.EndTime != nil {
+		end := t.EndTime.UTC()
+		t.EndTime = &end
+	}
+	query := `
+		UPDATE tasks
+		SET description = $1, start_time = $2, end_time = $3
+		WHERE id = $4
+	`
+	if _, err := tx.ExecContext(ctx, query, t.Desc, t.StartTime, t.EndTime, t.ID); err != nil {
+		return nil, fmt.Errorf(utils.ErrQuery, query, t, err)
+	}
+	return t, tx.Commit()
+}
+
+func (s *sqlStore) DeleteTask(ctx context.Context, id int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf(utils.ErrBeginTx, err)
+	}
+	defer tx.Rollback()
+
+	if _, _, err := taskState(ctx, tx, id); err != nil {
+		return err
+	}
+	for _, query := range []string{
+		`DELETE FROM task_tags WHERE task_id = $1`,
+		`DELETE FROM task_segments WHERE task_id = $1`,
+		`DELETE FROM tasks WHERE id = $1`,
+	} {
+		if _, err := tx.ExecContext(ctx, query, id); err != nil {
+			return fmt.Errorf(utils.ErrQuery, query, id, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// getTask loads the task with its segments and tags, sql.ErrNoRows if there
+// is none in the organization of ctx.
+func getTask(ctx context.Context, q querier, id int) (*models.Task, error) {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var t models.Task
+	query := `
+		SELECT t.id, t.user_id, t.project_id, t.description, t.start_time, t.end_time
+		FROM tasks t
+		JOIN users u ON u.id = t.user_id
+		WHERE t.id = $1 AND u.org_id = $2
+	`
+	err = q.QueryRowContext(ctx, query, id, org).Scan(&t.ID, &t.UserID, &t.ProjectID, &t.Desc, &t.StartTime, &t.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	t.Segments, err = taskSegments(ctx, q, id)
+	if err != nil {
+		return nil, err
+	}
+	t.Tags, err = taskTags(ctx, q, id)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// checkOverlap returns utils.ErrOverlap when [start, end) intersects a segment
+// of another task of the user. Open segments last until now.
+func checkOverlap(ctx context.Context, q querier, userID, taskID int, start, end time.Time) error {
+	query := `
+		SELECT COUNT(*)
+		FROM task_segments
+		WHERE user_id = $1 AND task_id <> $2
+			AND start_time < $3 AND (end_time IS NULL OR end_time > $4)
+	`
+	var n int
+	err := q.QueryRowContext(ctx, query, userID, taskID, end, start).Scan(&n)
+	if err != nil {
+		return fmt.Errorf(utils.ErrQuery, query, []interface{}{userID, taskID, start, end}, err)
+	}
+	if n > 0 {
+		return utils.ErrOverlap
+	}
+	return nil
+}
+
+func (s *sqlStore) GetUserConflicts(ctx context.Context, userID int) ([]models.Conflict, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	query := `
+		SELECT a.id, a.task_id, a.start_time, a.end_time, b.id, b.task_id, b.start_time, b.end_time
+		FROM task_segments a
+		JOIN task_segments b ON b.user_id = a.user_id AND a.id < b.id
+		JOIN users u ON u.id = a.user_id
+		WHERE a.user_id = $1
+			AND a.start_time < COALESCE(b.end_time, $2)
+			AND b.start_time < COALESCE(a.end_time, $2)
+			AND u.org_id = $3
+	`
+	rows, err := s.sql.QueryContext(ctx, query, userID, now, org)
+	if err != nil {
+		return nil, fmt.Errorf(utils.ErrQuery, query, userID, err)
+	}
+	defer rows.Close()
+
+	conflicts := []models.Conflict{}
+	for rows.Next() {
+		var a, b models.TaskSegment
+		err := rows.Scan(&a.ID, &a.TaskID, &a.StartTime, &a.EndTime, &b.ID, &b.TaskID, &b.StartTime, &b.EndTime)
+		if err != nil {
+			return nil, fmt.Errorf(utils.ErrScanRow, query, userID, err)
+		}
+		if c, ok := conflict(a, b, now); ok {
+			conflicts = append(conflicts, c)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(utils.ErrRowIteration, query, userID, err)
+	}
+	sortConflicts(conflicts)
+	return conflicts, nil
+}
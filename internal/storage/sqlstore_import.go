@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"time-tracker/internal/models"
+	"time-tracker/internal/utils"
+)
+
+func (s *sqlStore) ImportTasks(ctx context.Context, tasks []models.ImportTask, report *models.ImportReport) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf(utils.ErrBeginTx, err)
+	}
+	// a dry run or an import with issues is rolled back
+	defer tx.Rollback()
+
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	knownTags, err := tagNames(ctx, tx, org)
+	if err != nil {
+		return err
+	}
+	projects := make(map[[2]string]*int)
+	now := time.Now()
+	for _, it := range tasks {
+		if err := checkEntry(it.StartTime, it.EndTime, now); err != nil {
+			report.Invalid = append(report.Invalid, models.ImportIssue{Line: it.Line, User: it.User, Reason: err.Error()})
+			continue
+		}
+		start, end := it.StartTime.UTC(), it.EndTime.UTC()
+		if err := userExists(ctx, tx, it.UserID); err != nil {
+			return err
+		}
+		if err := checkOverlap(ctx, tx, it.UserID, 0, start, end); err != nil {
+			if err == utils.ErrOverlap {
+				report.Conflicts = append(report.Conflicts, models.ImportIssue{Line: it.Line, User: it.User, Reason: err.Error()})
+				continue
+			}
+			return err
+		}
+
+		key := [2]string{it.Client, it.Project}
+		projectID, ok := projects[key]
+		if !ok {
+			projectID, err = importProject(ctx, tx, org, it.Client, it.Project, report)
+			if err != nil {
+				return err
+			}
+			projects[key] = projectID
+		}
+		for _, tag := range it.Tags {
+			if !knownTags[tag] {
+				knownTags[tag] = true
+				report.NewTags = append(report.NewTags, tag)
+			}
+		}
+		t := &models.Task{UserID: it.UserID, ProjectID: projectID, Desc: it.Desc, Tags: it.Tags}
+		if err := insertEntry(ctx, tx, t, start, end); err != nil {
+			return err
+		}
+		report.Tasks++
+	}
+
+	if report.DryRun || !report.OK() {
+		return nil
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	report.Imported = true
+	return nil
+}
+
+// importProject finds the project of the organization by its name and the
+// name of its client, creating both when missing. No project name means no
+// project.
+func importProject(ctx context.Context, q querier, org int, clientName, name string, report *models.ImportReport) (*int, error) {
+	if name == "" {
+		return nil, nil
+	}
+	var clientID *int
+	if clientName != "" {
+		var id int
+		query := `
+			SELECT id
+			FROM clients
+			WHERE name = $1 AND org_id = $2
+		`
+		err := q.QueryRowContext(ctx, query, clientName, org).Scan(&id)
+		if err == sql.ErrNoRows {
+			query = `
+				INSERT INTO clients (name, org_id)
+				VALUES ($1, $2)
+				RETURNING id
+			`
+			err = q.QueryRowContext(ctx, query, clientName, org).Scan(&id)
+			report.NewClients = append(report.NewClients, clientName)
+		}
+		if err != nil {
+			return nil, fmt.Errorf(utils.ErrQuery, query, clientName, err)
+		}
+		clientID = &id
+	}
+
+	var id int
+	query := `
+		SELECT id
+		FROM projects
+		WHERE COALESCE(client_id, 0) = COALESCE($1, 0) AND name = $2 AND org_id = $3
+	`
+	err := q.QueryRowContext(ctx, query, clientID, name, org).Scan(&id)
+	if err == sql.ErrNoRows {
+		query = `
+			INSERT INTO projects (client_id, name, org_id)
+			VALUES ($1, $2, $3)
+			RETURNING id
+		`
+		err = q.QueryRowContext(ctx, query, clientID, name, org).Scan(&id)
+		report.NewProjects = append(report.NewProjects, projectLabel(clientName, name))
+	}
+	if err != nil {
+		return nil, fmt.Errorf(utils.ErrQuery, query, name, err)
+	}
+	return &id, nil
+}
+
+// tagNames returns the tags the tasks of the organization use.
+func tagNames(ctx context.Context, q querier, org int) (map[string]bool, error) {
+	query := `
+		SELECT DISTINCT tg.name
+		FROM tags tg
+		JOIN task_tags tt ON tt.tag_id = tg.id
+		JOIN tasks t ON t.id = tt.task_id
+		JOIN users u ON u.id = t.user_id
+		WHERE u.org_id = $1
+	`
+	rows, err := q.QueryContext(ctx, query, org)
+	if err != nil {
+		return nil, fmt.Errorf(utils.ErrQuery, query, nil, err)
+	}
+	defer rows.Close()
+
+	names := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf(utils.ErrScanRow, query, nil, err)
+		}
+		names[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(utils.ErrRowIteration, query, nil, err)
+	}
+	return names, nil
+}
+
+// projectLabel names a project together with its client in reports.
+func projectLabel(clientName, name string) string {
+	if clientName == "" {
+		return name
+	}
+	return clientName + " / " + name
+}
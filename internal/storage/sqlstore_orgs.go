@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time-tracker/internal/models"
+	"time-tracker/internal/utils"
+)
+
+func (s *sqlStore) AddOrganization(ctx context.Context, o *models.Organization) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO organizations (name)
+		VALUES ($1)
+		RETURNING id
+	`
+	err := s.sql.QueryRowContext(ctx, query, o.Name).Scan(&o.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return utils.ErrAlreadyExists
+		}
+		return fmt.Errorf(utils.ErrQuery, query, o, err)
+	}
+	return nil
+}
+
+func (s *sqlStore) GetOrganizations(ctx context.Context) ([]models.Organization, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT id, name
+		FROM organizations
+		ORDER BY id
+	`
+	rows, err := s.sql.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf(utils.ErrQuery, query, nil, err)
+	}
+	defer rows.Close()
+
+	orgs := []models.Organization{}
+	for rows.Next() {
+		var o models.Organization
+		if err := rows.Scan(&o.ID, &o.Name); err != nil {
+			return nil, fmt.Errorf(utils.ErrScanRow, query, nil, err)
+		}
+		orgs = append(orgs, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(utils.ErrRowIteration, query, nil, err)
+	}
+	return orgs, nil
+}
+
+// userInOrg returns sql.ErrNoRows unless the user belongs to the
+// organization of ctx.
+func userInOrg(ctx context.Context, q querier, userID int) error {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	query := `
+		SELECT id
+		FROM users
+		WHERE id = $1 AND org_id = $2
+	`
+	err = q.QueryRowContext(ctx, query, userID, org).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf(utils.ErrQuery, query, userID, err)
+	}
+	return nil
+}
+
+// userExists is userInOrg for the owner of new tasks, reported the way the
+// memory store reports an unknown user.
+func userExists(ctx context.Context, q querier, userID int) error {
+	err := userInOrg(ctx, q, userID)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf(utils.ErrUserNotFound, userID)
+	}
+	return err
+}
@@ -0,0 +1,311 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time-tracker/internal/models"
+	"time-tracker/internal/utils"
+)
+
+func (s *sqlStore) AddClient(ctx context.Context, c *models.Client) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO clients (name, org_id)
+		VALUES ($1, $2)
+		RETURNING id
+	`
+	err = s.sql.QueryRowContext(ctx, query, c.Name, org).Scan(&c.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return utils.ErrAlreadyExists
+		}
+		return fmt.Errorf(utils.ErrQuery, query, c, err)
+	}
+	return nil
+}
+
+func (s *sqlStore) GetClients(ctx context.Context) ([]models.Client, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		SELECT id, name
+		FROM clients
+		WHERE org_id = $1
+		ORDER BY name
+	`
+	rows, err := s.sql.QueryContext(ctx, query, org)
+	if err != nil {
+		return nil, fmt.Errorf(utils.ErrQuery, query, nil, err)
+	}
+	defer rows.Close()
+
+	clients := []models.Client{}
+	for rows.Next() {
+		var c models.Client
+		if err := rows.Scan(&c.ID, &c.Name); err != nil {
+			return nil, fmt.Errorf(utils.ErrScanRow, query, nil, err)
+		}
+		clients = append(clients, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(utils.ErrRowIteration, query, nil, err)
+	}
+	return clients, nil
+}
+
+func (s *sqlStore) GetClientByID(ctx context.Context, id int) (*models.Client, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var c models.Client
+	query := `
+		SELECT id, name
+		FROM clients
+		WHERE id = $1 AND org_id = $2
+	`
+	err = s.sql.QueryRowContext(ctx, query, id, org).Scan(&c.ID, &c.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (s *sqlStore) UpdateClient(ctx context.Context, c *models.Client) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	query := `
+		UPDATE clients
+		SET name = $1
+		WHERE id = $2 AND org_id = $3
+	`
+	res, err := s.sql.ExecContext(ctx, query, c.Name, c.ID, org)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return utils.ErrAlreadyExists
+		}
+		return fmt.Errorf(utils.ErrQuery, query, c, err)
+	}
+	return mustAffect(res, query, c)
+}
+
+func (s *sqlStore) DeleteClient(ctx context.Context, id int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	query := `
+		DELETE FROM clients
+		WHERE id = $1 AND org_id = $2
+	`
+	res, err := s.sql.ExecContext(ctx, query, id, org)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return utils.ErrInUse
+		}
+		return fmt.Errorf(utils.ErrQuery, query, id, err)
+	}
+	return mustAffect(res, query, id)
+}
+
+func (s *sqlStore) AddProject(ctx context.Context, p *models.Project) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	if err := clientExists(ctx, s.sql, p.ClientID); err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO projects (client_id, name, org_id)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`
+	err = s.sql.QueryRowContext(ctx, query, p.ClientID, p.Name, org).Scan(&p.ID)
+	if err != nil {
+		return projectError(err, query, p)
+	}
+	return nil
+}
+
+func (s *sqlStore) GetProjects(ctx context.Context, req models.GetProjectsRequest) ([]models.Project, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		SELECT id, client_id, name
+		FROM projects
+		WHERE org_id = $1
+	`
+	params := []interface{}{org}
+	if req.ClientID != nil {
+		query += " AND client_id = $2"
+		params = append(params, *req.ClientID)
+	}
+	query += " ORDER BY name"
+
+	rows, err := s.sql.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, fmt.Errorf(utils.ErrQuery, query, params, err)
+	}
+	defer rows.Close()
+
+	projects := []models.Project{}
+	for rows.Next() {
+		var p models.Project
+		if err := rows.Scan(&p.ID, &p.ClientID, &p.Name); err != nil {
+			return nil, fmt.Errorf(utils.ErrScanRow, query, params, err)
+		}
+		projects = append(projects, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(utils.ErrRowIteration, query, params, err)
+	}
+	return projects, nil
+}
+
+func (s *sqlStore) GetProjectByID(ctx context.Context, id int) (*models.Project, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var p models.Project
+	query := `
+		SELECT id, client_id, name
+		FROM projects
+		WHERE id = $1 AND org_id = $2
+	`
+	err = s.sql.QueryRowContext(ctx, query, id, org).Scan(&p.ID, &p.ClientID, &p.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (s *sqlStore) UpdateProject(ctx context.Context, p *models.Project) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	if err := clientExists(ctx, s.sql, p.ClientID); err != nil {
+		return err
+	}
+	query := `
+		UPDATE projects
+		SET client_id = $1, name = $2
+		WHERE id = $3 AND org_id = $4
+	`
+	res, err := s.sql.ExecContext(ctx, query, p.ClientID, p.Name, p.ID, org)
+	if err != nil {
+		return projectError(err, query, p)
+	}
+	return mustAffect(res, query, p)
+}
+
+func (s *sqlStore) DeleteProject(ctx context.Context, id int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	query := `
+		DELETE FROM projects
+		WHERE id = $1 AND org_id = $2
+	`
+	res, err := s.sql.ExecContext(ctx, query, id, org)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return utils.ErrInUse
+		}
+		return fmt.Errorf(utils.ErrQuery, query, id, err)
+	}
+	return mustAffect(res, query, id)
+}
+
+// clientExists returns utils.ErrClientNotFound unless the optional client
+// belongs to the organization of ctx. The foreign key alone would accept
+// the client of another organization.
+func clientExists(ctx context.Context, q querier, id *int) error {
+	if id == nil {
+		return nil
+	}
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	var found int
+	query := `
+		SELECT id
+		FROM clients
+		WHERE id = $1 AND org_id = $2
+	`
+	err = q.QueryRowContext(ctx, query, *id, org).Scan(&found)
+	if err == sql.ErrNoRows {
+		return utils.ErrClientNotFound
+	}
+	if err != nil {
+		return fmt.Errorf(utils.ErrQuery, query, *id, err)
+	}
+	return nil
+}
+
+// projectError maps constraint violations of projects to storage errors.
+func projectError(err error, query string, p *models.Project) error {
+	switch {
+	case isUniqueViolation(err):
+		return utils.ErrAlreadyExists
+	case isForeignKeyViolation(err):
+		return utils.ErrClientNotFound
+	}
+	return fmt.Errorf(utils.ErrQuery, query, p, err)
+}
+
+// mustAffect turns an UPDATE or DELETE that matched nothing into sql.ErrNoRows.
+func mustAffect(res sql.Result, query string, params interface{}) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf(utils.ErrQuery, query, params, err)
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
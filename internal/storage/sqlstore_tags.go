@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time-tracker/internal/utils"
+)
+
+func (s *sqlStore) SetTaskTags(ctx context.Context, taskID int, tags []string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := s.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf(utils.ErrBeginTx, err)
+	}
+	defer tx.Rollback()
+
+	if _, _, err := taskState(ctx, tx, taskID); err != nil {
+		return err
+	}
+	if err := setTags(ctx, tx, taskID, tags); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *sqlStore) GetTags(ctx context.Context) ([]string, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// tags are shared, an organization sees the ones its tasks use
+	query := `
+		SELECT DISTINCT tg.name
+		FROM tags tg
+		JOIN task_tags tt ON tt.tag_id = tg.id
+		JOIN tasks t ON t.id = tt.task_id
+		JOIN users u ON u.id = t.user_id
+		WHERE u.org_id = $1
+		ORDER BY tg.name
+	`
+	rows, err := s.sql.QueryContext(ctx, query, org)
+	if err != nil {
+		return nil, fmt.Errorf(utils.ErrQuery, query, nil, err)
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, fmt.Errorf(utils.ErrScanRow, query, nil, err)
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(utils.ErrRowIteration, query, nil, err)
+	}
+	return tags, nil
+}
+
+// setTags replaces the tags of the task, creating the missing ones.
+func setTags(ctx context.Context, q querier, taskID int, tags []string) error {
+	query := `
+		DELETE FROM task_tags
+		WHERE task_id = $1
+	`
+	if _, err := q.ExecContext(ctx, query, taskID); err != nil {
+		return fmt.Errorf(utils.ErrQuery, query, taskID, err)
+	}
+	for _, tag := range tags {
+		query = `
+			INSERT INTO tags (name)
+			VALUES ($1)
+			ON CONFLICT (name) DO NOTHING
+		`
+		if _, err := q.ExecContext(ctx, query, tag); err != nil {
+			return fmt.Errorf(utils.ErrQuery, query, tag, err)
+		}
+		query = `
+			INSERT INTO task_tags (task_id, tag_id)
+			SELECT $1, id FROM tags WHERE name = $2
+		`
+		if _, err := q.ExecContext(ctx, query, taskID, tag); err != nil {
+			return fmt.Errorf(utils.ErrQuery, query, tag, err)
+		}
+	}
+	return nil
+}
+
+func taskTags(ctx context.Context, q querier, taskID int) ([]string, error) {
+	query := `
+		SELECT tg.name
+		FROM task_tags tt
+		JOIN tags tg ON tg.id = tt.tag_id
+		WHERE tt.task_id = $1
+		ORDER BY tg.name
+	`
+	rows, err := q.QueryContext(ctx, query, taskID)
+	if err != nil {
+		return nil, fmt.Errorf(utils.ErrQuery, query, taskID, err)
+	}
+	defer rows.Close()
+
+	var tags []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, fmt.Errorf(utils.ErrScanRow, query, taskID, err)
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(utils.ErrRowIteration, query, taskID, err)
+	}
+	return tags, nil
+}
+
+// userTaskTags returns the tags of every task of the user by task id.
+func userTaskTags(ctx context.Context, q querier, userID int) (map[int][]string, error) {
+	query := `
+		SELECT tt.task_id, tg.name
+		FROM task_tags tt
+		JOIN tags tg ON tg.id = tt.tag_id
+		JOIN tasks t ON t.id = tt.task_id
+		WHERE t.user_id = $1
+		ORDER BY tg.name
+	`
+	rows, err := q.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf(utils.ErrQuery, query, userID, err)
+	}
+	defer rows.Close()
+
+	tags := make(map[int][]string)
+	for rows.Next() {
+		var taskID int
+		var tag string
+		if err := rows.Scan(&taskID, &tag); err != nil {
+			return nil, fmt.Errorf(utils.ErrScanRow, query, userID, err)
+		}
+		tags[taskID] = append(tags[taskID], tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(utils.ErrRowIteration, query, userID, err)
+	}
+	return tags, nil
+}
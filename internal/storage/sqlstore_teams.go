@@ -0,0 +1,386 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"time-tracker/internal/models"
+	"time-tracker/internal/utils"
+)
+
+func (s *sqlStore) AddTeam(ctx context.Context, t *models.Team) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	if err := leadInOrg(ctx, s.sql, t.LeadID); err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO teams (name, lead_id, org_id)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`
+	err = s.sql.QueryRowContext(ctx, query, t.Name, t.LeadID, org).Scan(&t.ID)
+	if err != nil {
+		return teamError(err, query, t)
+	}
+	t.Members = []int{}
+	return nil
+}
+
+func (s *sqlStore) GetTeams(ctx context.Context) ([]models.Team, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		SELECT id, name, lead_id
+		FROM teams
+		WHERE org_id = $1
+		ORDER BY name
+	`
+	rows, err := s.sql.QueryContext(ctx, query, org)
+	if err != nil {
+		return nil, fmt.Errorf(utils.ErrQuery, query, nil, err)
+	}
+	defer rows.Close()
+
+	teams := []models.Team{}
+	for rows.Next() {
+		var t models.Team
+		if err := rows.Scan(&t.ID, &t.Name, &t.LeadID); err != nil {
+			return nil, fmt.Errorf(utils.ErrScanRow, query, nil, err)
+		}
+		teams = append(teams, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(utils.ErrRowIteration, query, nil, err)
+	}
+	rows.Close()
+
+	members, err := s.teamMemberIDs(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+	for i := range teams {
+		teams[i].Members = members[teams[i].ID]
+		if teams[i].Members == nil {
+			teams[i].Members = []int{}
+		}
+	}
+	return teams, nil
+}
+
+func (s *sqlStore) GetTeamByID(ctx context.Context, id int) (*models.Team, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	org, err := orgOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var t models.Team
+	query := `
+		SELECT id, name, lead_id
+		FROM teams
+		WHERE id = $1 AND org_id = $2
+	`
+	err = s.sql.QueryRowContext(ctx, query, id, org).Scan(&t.ID, &t.Name, &t.LeadID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.teamMembers(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	t.Members = []int{}
+	for _, u := range members {
+		t.Members = append(t.Members, u.Id)
+	}
+	return &t, nil
+}
+
+func (s *sqlStore) UpdateTeam(ctx context.Context, t *models.Team) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	if err := leadInOrg(ctx, s.sql, t.LeadID); err != nil {
+		return err
+	}
+	query := `
+		UPDATE teams
+		SET name = $1, lead_id = $2
+		WHERE id = $3 AND org_id = $4
+	`
+	res, err := s.sql.ExecContext(ctx, query, t.Name, t.LeadID, t.ID, org)
+	if err != nil {
+		return teamError(err, query, t)
+	}
+	return mustAffect(res, query, t)
+}
+
+func (s *sqlStore) DeleteTeam(ctx context.Context, id int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	// the members go with the team
+	query := `
+		DELETE FROM teams
+		WHERE id = $1 AND org_id = $2
+	`
+	res, err := s.sql.ExecContext(ctx, query, id, org)
+	if err != nil {
+		return fmt.Errorf(utils.ErrQuery, query, id, err)
+	}
+	return mustAffect(res, query, id)
+}
+
+func (s *sqlStore) AddTeamMember(ctx context.Context, teamID, userID int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := teamInOrg(ctx, s.sql, teamID); err != nil {
+		return err
+	}
+	if err := userInOrg(ctx, s.sql, userID); err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO team_members (team_id, user_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`
+	_, err := s.sql.ExecContext(ctx, query, teamID, userID)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return sql.ErrNoRows
+		}
+		return fmt.Errorf(utils.ErrQuery, query, []int{teamID, userID}, err)
+	}
+	return nil
+}
+
+func (s *sqlStore) RemoveTeamMember(ctx context.Context, teamID, userID int) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := teamInOrg(ctx, s.sql, teamID); err != nil {
+		return err
+	}
+	query := `
+		DELETE FROM team_members
+		WHERE team_id = $1 AND user_id = $2
+	`
+	res, err := s.sql.ExecContext(ctx, query, teamID, userID)
+	if err != nil {
+		return fmt.Errorf(utils.ErrQuery, query, []int{teamID, userID}, err)
+	}
+	return mustAffect(res, query, []int{teamID, userID})
+}
+
+// GetTeamWorklogs reads the segments of all members at once, the whole
+// report shares one query timeout.
+func (s *sqlStore) GetTeamWorklogs(ctx context.Context, teamID int, req *models.GetTeamWorklogsRequest) (*models.TeamReport, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := teamInOrg(ctx, s.sql, teamID); err != nil {
+		return nil, err
+	}
+	members, err := s.teamMembers(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, 0, len(members))
+	for _, u := range members {
+		ids = append(ids, u.Id)
+	}
+	intervals, err := s.membersIntervals(ctx, ids, req)
+	if err != nil {
+		return nil, err
+	}
+	reports := make([]memberIntervals, 0, len(members))
+	for _, u := range members {
+		reports = append(reports, memberIntervals{user: u, intervals: intervals[u.Id]})
+	}
+	return teamReport(teamID, req, reports), nil
+}
+
+// membersIntervals loads the segments of the users within the report
+// window, grouped by user.
+func (s *sqlStore) membersIntervals(ctx context.Context, userIDs []int, req *models.GetTeamWorklogsRequest) (map[int][]interval, error) {
+	intervals := make(map[int][]interval)
+	if len(userIDs) == 0 {
+		return intervals, nil
+	}
+	placeholders := make([]string, len(userIDs))
+	params := []interface{}{req.StartDate.UTC(), req.EndDate.UTC()}
+	for i, id := range userIDs {
+		placeholders[i] = fmt.Sprintf("$%d", i+3)
+		params = append(params, id)
+	}
+	query := `
+		SELECT s.user_id, s.id, t.id, t.project_id, p.name, t.description, s.start_time, s.end_time
+		FROM task_segments s
+		JOIN tasks t ON t.id = s.task_id
+		LEFT JOIN projects p ON p.id = t.project_id
+		WHERE (s.end_time IS NULL OR s.end_time > $1) AND s.start_time < $2
+			AND s.user_id IN (` + strings.Join(placeholders, ", ") + `)
+		ORDER BY s.start_time, s.id
+	`
+	rows, err := s.sql.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, fmt.Errorf(utils.ErrQuery, query, params, err)
+	}
+	defer rows.Close()
+
+	now := reportNow().UTC()
+	for rows.Next() {
+		var userID int
+		var i interval
+		var project *string
+		var end *time.Time
+		if err := rows.Scan(&userID, &i.segmentID, &i.taskID, &i.projectID, &project, &i.desc, &i.start, &end); err != nil {
+			return nil, fmt.Errorf(utils.ErrScanRow, query, params, err)
+		}
+		if project != nil {
+			i.project = *project
+		}
+		if fitInterval(&i, end, now, req.StartDate, req.EndDate) {
+			intervals[userID] = append(intervals[userID], i)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(utils.ErrRowIteration, query, params, err)
+	}
+	return intervals, nil
+}
+
+// teamMembers loads the names of the members of a team within the query
+// timeout.
+func (s *sqlStore) teamMembers(ctx context.Context, teamID int) ([]models.User, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT u.id, COALESCE(u.surname, ''), COALESCE(u.name, ''), COALESCE(u.patronymic, '')
+		FROM team_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.team_id = $1
+		ORDER BY u.id
+	`
+	rows, err := s.sql.QueryContext(ctx, query, teamID)
+	if err != nil {
+		return nil, fmt.Errorf(utils.ErrQuery, query, teamID, err)
+	}
+	defer rows.Close()
+
+	var members []models.User
+	for rows.Next() {
+		var u models.User
+		if err := rows.Scan(&u.Id, &u.Surname, &u.Name, &u.Patronymic); err != nil {
+			return nil, fmt.Errorf(utils.ErrScanRow, query, teamID, err)
+		}
+		members = append(members, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(utils.ErrRowIteration, query, teamID, err)
+	}
+	return members, nil
+}
+
+// teamMemberIDs maps every team of the organization to the ids of its
+// members, within the query timeout.
+func (s *sqlStore) teamMemberIDs(ctx context.Context, org int) (map[int][]int, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT m.team_id, m.user_id
+		FROM team_members m
+		JOIN teams t ON t.id = m.team_id
+		WHERE t.org_id = $1
+		ORDER BY m.team_id, m.user_id
+	`
+	rows, err := s.sql.QueryContext(ctx, query, org)
+	if err != nil {
+		return nil, fmt.Errorf(utils.ErrQuery, query, nil, err)
+	}
+	defer rows.Close()
+
+	members := make(map[int][]int)
+	for rows.Next() {
+		var teamID, userID int
+		if err := rows.Scan(&teamID, &userID); err != nil {
+			return nil, fmt.Errorf(utils.ErrScanRow, query, nil, err)
+		}
+		members[teamID] = append(members[teamID], userID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(utils.ErrRowIteration, query, nil, err)
+	}
+	return members, nil
+}
+
+// teamInOrg returns sql.ErrNoRows unless the team belongs to the
+// organization of ctx.
+func teamInOrg(ctx context.Context, q querier, teamID int) error {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	query := `
+		SELECT id
+		FROM teams
+		WHERE id = $1 AND org_id = $2
+	`
+	err = q.QueryRowContext(ctx, query, teamID, org).Scan(&teamID)
+	if err == sql.ErrNoRows {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf(utils.ErrQuery, query, teamID, err)
+	}
+	return nil
+}
+
+// leadInOrg returns utils.ErrLeadNotFound unless the optional lead belongs
+// to the organization of ctx.
+func leadInOrg(ctx context.Context, q querier, leadID *int) error {
+	if leadID == nil {
+		return nil
+	}
+	err := userInOrg(ctx, q, *leadID)
+	if err == sql.ErrNoRows {
+		return utils.ErrLeadNotFound
+	}
+	return err
+}
+
+// teamError maps constraint violations of teams to storage errors.
+func teamError(err error, query string, t *models.Team) error {
+	switch {
+	case isUniqueViolation(err):
+		return utils.ErrAlreadyExists
+	case isForeignKeyViolation(err):
+		return utils.ErrLeadNotFound
+	}
+	return fmt.Errorf(utils.ErrQuery, query, t, err)
+}
@@ -0,0 +1,222 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"time-tracker/internal/models"
+	"time-tracker/internal/utils"
+)
+
+func (s *sqlStore) GetUserWorklogs(ctx context.Context, req *models.GetUserWorklogsRequest) ([]models.Worklog, error) {
+	intervals, err := s.intervals(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return worklogs(intervals), nil
+}
+
+func (s *sqlStore) GetUserWorklogGroups(ctx context.Context, req *models.GetUserWorklogsRequest) (*models.WorklogReport, error) {
+	loc, err := req.Location()
+	if err != nil {
+		return nil, err
+	}
+	intervals, err := s.intervals(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return worklogReport(intervals, req.GroupBy, loc), nil
+}
+
+func (s *sqlStore) EachUserTimeEntry(ctx context.Context, req *models.GetUserWorklogsRequest, fn func(models.TimeEntry) error) error {
+	loc, err := req.Location()
+	if err != nil {
+		return err
+	}
+	return s.eachInterval(ctx, req, func(i interval) error {
+		for _, e := range timeEntries(i, req.UserID, loc) {
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *sqlStore) EachUserInterval(ctx context.Context, req *models.GetUserWorklogsRequest, fn func(models.TimeEntry) error) error {
+	loc, err := req.Location()
+	if err != nil {
+		return err
+	}
+	return s.eachInterval(ctx, req, func(i interval) error {
+		return fn(timeEntry(i, req.UserID, loc))
+	})
+}
+
+// intervals loads the segments matching the report filters.
+func (s *sqlStore) intervals(ctx context.Context, req *models.GetUserWorklogsRequest) ([]interval, error) {
+	var intervals []interval
+	err := s.eachInterval(ctx, req, func(i interval) error {
+		intervals = append(intervals, i)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return intervals, nil
+}
+
+// intervalPageSize is how many segments eachInterval reads per query.
+var intervalPageSize = 500
+
+// eachInterval calls fn for the segments matching the report filters in
+// the order they started, without loading them all at once. The segments
+// are read a page at a time, each query within the query timeout and with
+// no rows open while fn runs, so a slow client of a long export neither
+// hits the timeout nor holds the connection SQLite has only one of.
+func (s *sqlStore) eachInterval(ctx context.Context, req *models.GetUserWorklogsRequest, fn func(interval) error) error {
+	org, err := orgOf(ctx)
+	if err != nil {
+		return err
+	}
+	tags, err := s.userTaskTags(ctx, req.UserID)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		SELECT s.id, t.id, t.project_id, p.name, t.description, s.start_time, s.end_time
+		FROM task_segments s
+		JOIN tasks t ON t.id = s.task_id
+		LEFT JOIN projects p ON p.id = t.project_id
+		JOIN users u ON u.id = s.user_id
+		WHERE s.user_id = $1 AND (s.end_time IS NULL OR s.end_time > $2) AND s.start_time < $3
+			AND u.org_id = $4
+	`
+	now := reportNow().UTC()
+	params := []interface{}{req.UserID, req.StartDate.UTC(), req.EndDate.UTC(), org}
+	paramCounter := 5
+
+	if req.ProjectID != nil {
+		query += fmt.Sprintf(" AND t.project_id = $%d", paramCounter)
+		params = append(params, *req.ProjectID)
+		paramCounter++
+	}
+	if req.ClientID != nil {
+		query += fmt.Sprintf(" AND p.client_id = $%d", paramCounter)
+		params = append(params, *req.ClientID)
+		paramCounter++
+	}
+	if len(req.Tags) > 0 {
+		query += fmt.Sprintf(" AND t.id IN (%s)", taggedTasksQuery(len(req.Tags), paramCounter))
+		for _, tag := range req.Tags {
+			params = append(params, tag)
+		}
+		paramCounter += len(req.Tags)
+	}
+	if len(req.ExcludeTags) > 0 {
+		query += fmt.Sprintf(" AND t.id NOT IN (%s)", taggedTasksQuery(len(req.ExcludeTags), paramCounter))
+		for _, tag := range req.ExcludeTags {
+			params = append(params, tag)
+		}
+		paramCounter += len(req.ExcludeTags)
+	}
+
+	// the next page starts after the last segment of the previous one
+	pageQuery := query + fmt.Sprintf(`
+		AND (s.start_time > $%d OR (s.start_time = $%d AND s.id > $%d))
+		ORDER BY s.start_time, s.id
+		LIMIT $%d`, paramCounter, paramCounter+1, paramCounter+2, paramCounter+3)
+	firstQuery := query + fmt.Sprintf(`
+		ORDER BY s.start_time, s.id
+		LIMIT $%d`, paramCounter)
+
+	// the full slice expression makes every page append to a copy
+	params = params[:len(params):len(params)]
+	var after *storedInterval
+	for {
+		var page []storedInterval
+		var err error
+		if after == nil {
+			page, err = s.intervalPage(ctx, firstQuery, append(params, intervalPageSize))
+		} else {
+			page, err = s.intervalPage(ctx, pageQuery, append(params, after.start, after.start, after.segmentID, intervalPageSize))
+		}
+		if err != nil {
+			return err
+		}
+		for _, stored := range page {
+			i := stored.interval
+			i.tags = tags[i.taskID]
+			if !fitInterval(&i, stored.storedEnd, now, req.StartDate, req.EndDate) {
+				continue
+			}
+			if err := fn(i); err != nil {
+				return err
+			}
+		}
+		if len(page) < intervalPageSize {
+			return nil
+		}
+		after = &page[len(page)-1]
+	}
+}
+
+// storedInterval is a segment as read, before fitInterval.
+type storedInterval struct {
+	interval
+	// storedEnd is nil for an open segment
+	storedEnd *time.Time
+}
+
+// intervalPage reads a page of segments.
+func (s *sqlStore) intervalPage(ctx context.Context, query string, params []interface{}) ([]storedInterval, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := s.sql.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, fmt.Errorf(utils.ErrQuery, query, params, err)
+	}
+	defer rows.Close()
+
+	var page []storedInterval
+	for rows.Next() {
+		var i storedInterval
+		var project *string
+		if err := rows.Scan(&i.segmentID, &i.taskID, &i.projectID, &project, &i.desc, &i.start, &i.storedEnd); err != nil {
+			return nil, fmt.Errorf(utils.ErrScanRow, query, params, err)
+		}
+		if project != nil {
+			i.project = *project
+		}
+		page = append(page, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(utils.ErrRowIteration, query, params, err)
+	}
+	return page, nil
+}
+
+// userTaskTags reads the tags of the user's tasks within the query timeout.
+func (s *sqlStore) userTaskTags(ctx context.Context, userID int) (map[int][]string, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return userTaskTags(ctx, s.sql, userID)
+}
+
+// taggedTasksQuery selects the ids of tasks having any of n tags
+// bound to the parameters starting at $first.
+func taggedTasksQuery(n, first int) string {
+	placeholders := make([]string, n)
+	for i := range placeholders {
+		placeholders[i] = fmt.Sprintf("$%d", first+i)
+	}
+	return `
+		SELECT tt.task_id
+		FROM task_tags tt
+		JOIN tags tg ON tg.id = tt.tag_id
+		WHERE tg.name IN (` + strings.Join(placeholders, ", ") + `)`
+}
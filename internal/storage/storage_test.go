@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"time-tracker/configs"
+	"time-tracker/internal/models"
+)
+
+func TestMain(m *testing.M) {
+	// the migrations are found relative to the repository root
+	if err := os.Chdir("../.."); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// forEachStore runs test against an empty store of every driver usable
+// without a server, SQLite lives in a temporary file.
+func forEachStore(t *testing.T, test func(t *testing.T, db Database)) {
+	for _, driver := range []string{DriverMemory, DriverSQLite} {
+		t.Run(driver, func(t *testing.T) {
+			db, err := New(configs.DatabaseConfig{
+				Driver: driver,
+				Name:   filepath.Join(t.TempDir(), "test.db"),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := db.Connect(); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { db.Disconnect() })
+			test(t, db)
+		})
+	}
+}
+
+// addUser stores a user with the passport in the organization of ctx.
+func mustAddUser(t *testing.T, ctx context.Context, db Database, serie, number string) int {
+	t.Helper()
+	u, err := db.AddUser(ctx, &models.User{PassSerie: serie, PassNumber: number, Surname: "Surname " + number, Name: "Name " + number})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Id
+}
+
+func defaultOrg() context.Context {
+	return WithOrg(context.Background(), models.DefaultOrganizationID)
+}
+
+func TestQueriesHonourContext(t *testing.T) {
+	expired, cancel := context.WithDeadline(defaultOrg(), time.Now().Add(-time.Second))
+	defer cancel()
+	cancelled, cancel := context.WithCancel(defaultOrg())
+	cancel()
+
+	for _, tt := range []struct {
+		name    string
+		ctx     context.Context
+		timeout time.Duration
+		want    error
+	}{
+		{"cancelled request", cancelled, 0, context.Canceled},
+		{"expired request", expired, 0, context.DeadlineExceeded},
+		{"query timeout", defaultOrg(), time.Nanosecond, context.DeadlineExceeded},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := New(configs.DatabaseConfig{
+				Driver:       DriverSQLite,
+				Name:         filepath.Join(t.TempDir(), "test.db"),
+				QueryTimeout: tt.timeout,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := db.Connect(); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { db.Disconnect() })
+
+			if _, err := db.GetUsers(tt.ctx, models.GetUsersRequest{Page: 1, PageSize: 10}); !errors.Is(err, tt.want) {
+				t.Errorf("GetUsers: err = %v, want %v", err, tt.want)
+			}
+			if _, err := db.AddUser(tt.ctx, &models.User{PassSerie: "1234", PassNumber: "567890"}); !errors.Is(err, tt.want) {
+				t.Errorf("AddUser: err = %v, want %v", err, tt.want)
+			}
+			task := models.Task{UserID: 1}
+			if err := db.AddStartTask(tt.ctx, &task, false); !errors.Is(err, tt.want) {
+				t.Errorf("AddStartTask: err = %v, want %v", err, tt.want)
+			}
+			req := &models.GetUserWorklogsRequest{UserID: 1, StartDate: time.Now().Add(-time.Hour), EndDate: time.Now()}
+			if _, err := db.GetUserWorklogs(tt.ctx, req); !errors.Is(err, tt.want) {
+				t.Errorf("GetUserWorklogs: err = %v, want %v", err, tt.want)
+			}
+
+			// nothing was written past the aborted queries
+			if tt.timeout == 0 {
+				users, err := db.GetUsers(defaultOrg(), models.GetUsersRequest{Page: 1, PageSize: 10})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(users) != 0 {
+					t.Fatalf("users = %+v, want none", users)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package storage
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+	"time-tracker/internal/models"
+	"time-tracker/internal/utils"
+)
+
+func TestTeams(t *testing.T) {
+	forEachStore(t, func(t *testing.T, db Database) {
+		ctx := defaultOrg()
+		leadID := mustAddUser(t, ctx, db, "1234", "567890")
+		missing := leadID + 100
+
+		team := models.Team{Name: "Backend", LeadID: &leadID}
+		if err := db.AddTeam(ctx, &team); err != nil {
+			t.Fatal(err)
+		}
+		if team.ID == 0 || team.Members == nil {
+			t.Fatalf("team = %+v, want an id and no members", team)
+		}
+		if err := db.AddTeam(ctx, &models.Team{Name: "Backend"}); err != utils.ErrAlreadyExists {
+			t.Fatalf("duplicate name: err = %v, want %v", err, utils.ErrAlreadyExists)
+		}
+		if err := db.AddTeam(ctx, &models.Team{Name: "Frontend", LeadID: &missing}); err != utils.ErrLeadNotFound {
+			t.Fatalf("unknown lead: err = %v, want %v", err, utils.ErrLeadNotFound)
+		}
+
+		team.Name = "Platform"
+		team.LeadID = nil
+		if err := db.UpdateTeam(ctx, &team); err != nil {
+			t.Fatal(err)
+		}
+		got, err := db.GetTeamByID(ctx, team.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := (models.Team{ID: team.ID, Name: "Platform", Members: []int{}}); !reflect.DeepEqual(*got, want) {
+			t.Fatalf("team = %+v, want %+v", *got, want)
+		}
+		if err := db.UpdateTeam(ctx, &models.Team{ID: team.ID, Name: "Platform", LeadID: &missing}); err != utils.ErrLeadNotFound {
+			t.Fatalf("update to an unknown lead: err = %v, want %v", err, utils.ErrLeadNotFound)
+		}
+		if err := db.UpdateTeam(ctx, &models.Team{ID: team.ID + 100, Name: "Nobody"}); err != sql.ErrNoRows {
+			t.Fatalf("update of an unknown team: err = %v, want %v", err, sql.ErrNoRows)
+		}
+
+		other := models.Team{Name: "Design"}
+		if err := db.AddTeam(ctx, &other); err != nil {
+			t.Fatal(err)
+		}
+		teams, err := db.GetTeams(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(teams) != 2 || teams[0].Name != "Design" || teams[1].Name != "Platform" {
+			t.Fatalf("teams = %+v, want Design and Platform by name", teams)
+		}
+
+		if err := db.DeleteTeam(ctx, team.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.GetTeamByID(ctx, team.ID); err != sql.ErrNoRows {
+			t.Fatalf("deleted team: err = %v, want %v", err, sql.ErrNoRows)
+		}
+		if err := db.DeleteTeam(ctx, team.ID); err != sql.ErrNoRows {
+			t.Fatalf("second delete: err = %v, want %v", err, sql.ErrNoRows)
+		}
+	})
+}
+
+func TestTeamMembers(t *testing.T) {
+	forEachStore(t, func(t *testing.T, db Database) {
+		ctx := defaultOrg()
+		first := mustAddUser(t, ctx, db, "1234", "567890")
+		second := mustAddUser(t, ctx, db, "1234", "567891")
+		outsider := mustAddUser(t, ctx, db, "1234", "567892")
+		team := models.Team{Name: "Backend"}
+		if err := db.AddTeam(ctx, &team); err != nil {
+			t.Fatal(err)
+		}
+
+		members := func() []int {
+			t.Helper()
+			got, err := db.GetTeamByID(ctx, team.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return got.Members
+		}
+		teamUsers := func() []int {
+			t.Helper()
+			users, err := db.GetUsers(ctx, models.GetUsersRequest{TeamID: &team.ID, Page: 1, PageSize: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []int
+			for _, id := range []int{first, second, outsider} {
+				if _, ok := users[id]; ok {
+					ids = append(ids, id)
+				}
+			}
+			if len(ids) != len(users) {
+				t.Fatalf("users = %v, want only the members", users)
+			}
+			return ids
+		}
+
+		for _, id := range []int{second, first, second} {
+			if err := db.AddTeamMember(ctx, team.ID, id); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got, want := members(), []int{first, second}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("members = %v, want %v added once each", got, want)
+		}
+		if got, want := teamUsers(), []int{first, second}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("users of the team = %v, want %v", got, want)
+		}
+		if err := db.AddTeamMember(ctx, team.ID, outsider+100); err != sql.ErrNoRows {
+			t.Fatalf("unknown user: err = %v, want %v", err, sql.ErrNoRows)
+		}
+		if err := db.AddTeamMember(ctx, team.ID+100, first); err != sql.ErrNoRows {
+			t.Fatalf("unknown team: err = %v, want %v", err, sql.ErrNoRows)
+		}
+
+		if err := db.RemoveTeamMember(ctx, team.ID, first); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := members(), []int{second}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("members = %v, want %v", got, want)
+		}
+		if got, want := teamUsers(), []int{second}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("users of the team = %v, want %v", got, want)
+		}
+		if err := db.RemoveTeamMember(ctx, team.ID, outsider); err != sql.ErrNoRows {
+			t.Fatalf("removing a non member: err = %v, want %v", err, sql.ErrNoRows)
+		}
+
+		// the memberships go with the team, the users stay
+		if err := db.DeleteTeam(ctx, team.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.GetUserByID(ctx, second); err != nil {
+			t.Fatalf("member of the deleted team: %v", err)
+		}
+	})
+}
+
+func TestTeamWorklogs(t *testing.T) {
+	const h = time.Hour
+	day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -3)
+	forEachStore(t, func(t *testing.T, db Database) {
+		ctx := defaultOrg()
+		first := mustAddUser(t, ctx, db, "1234", "567890")
+		second := mustAddUser(t, ctx, db, "1234", "567891")
+		idle := mustAddUser(t, ctx, db, "1234", "567892")
+		outsider := mustAddUser(t, ctx, db, "1234", "567893")
+		team := models.Team{Name: "Backend"}
+		if err := db.AddTeam(ctx, &team); err != nil {
+			t.Fatal(err)
+		}
+		for _, id := range []int{first, second, idle} {
+			if err := db.AddTeamMember(ctx, team.ID, id); err != nil {
+				t.Fatal(err)
+			}
+		}
+		project := models.Project{Name: "Billing"}
+		if err := db.AddProject(ctx, &project); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, task := range []struct {
+			userID     int
+			projectID  *int
+			start, end time.Duration
+		}{
+			{first, &project.ID, 9 * h, 12 * h},
+			{first, nil, 13 * h, 14 * h},
+			{second, &project.ID, 10 * h, 12 * h},
+			// half of it lies before the window
+			{second, nil, -1 * h, 1 * h},
+			{outsider, &project.ID, 9 * h, 17 * h},
+		} {
+			end := day.Add(task.end)
+			add := models.Task{UserID: task.userID, ProjectID: task.projectID, StartTime: day.Add(task.start), EndTime: &end}
+			if err := db.AddTask(ctx, &add); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		report, err := db.GetTeamWorklogs(ctx, team.ID, &models.GetTeamWorklogsRequest{StartDate: day, EndDate: day.AddDate(0, 0, 1)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		hours := func(n int) models.Duration { return models.NewDuration(time.Duration(n) * h) }
+		wantMembers := []models.MemberTotal{
+			{UserID: first, Name: "Surname 567890 Name 567890", Duration: hours(4)},
+			{UserID: second, Name: "Surname 567891 Name 567891", Duration: hours(3)},
+			{UserID: idle, Name: "Surname 567892 Name 567892", Duration: hours(0)},
+		}
+		if !reflect.DeepEqual(report.Members, wantMembers) {
+			t.Errorf("members = %+v, want %+v", report.Members, wantMembers)
+		}
+		wantProjects := []models.ProjectTotal{
+			{ProjectID: &project.ID, Project: "Billing", Duration: hours(5)},
+			{Project: "", Duration: hours(2)},
+		}
+		if !reflect.DeepEqual(report.Projects, wantProjects) {
+			t.Errorf("projects = %+v, want %+v", report.Projects, wantProjects)
+		}
+		if report.Total != hours(7) || report.TeamID != team.ID {
+			t.Errorf("report = %+v, want 7h of team %d", report, team.ID)
+		}
+
+		if _, err := db.GetTeamWorklogs(ctx, team.ID+100, &models.GetTeamWorklogsRequest{StartDate: day, EndDate: day.AddDate(0, 0, 1)}); err != sql.ErrNoRows {
+			t.Fatalf("unknown team: err = %v, want %v", err, sql.ErrNoRows)
+		}
+	})
+}
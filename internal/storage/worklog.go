@@ -0,0 +1,263 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+	"time-tracker/internal/models"
+)
+
+// reportNow is the clock the reports count running segments up to.
+var reportNow = time.Now
+
+// interval is a segment of a task as seen by the reports, clipped to
+// the report window. A running segment ends now.
+type interval struct {
+	segmentID  int
+	taskID     int
+	projectID  *int
+	project    string
+	desc       string
+	tags       []string
+	start, end time.Time
+	running    bool
+}
+
+// fitInterval sets the end of the interval from the end of its segment and
+// clips it to [from, to). It reports whether anything is left.
+func fitInterval(i *interval, end *time.Time, now, from, to time.Time) bool {
+	if end != nil {
+		i.end = *end
+	} else {
+		i.running = true
+		i.end = now
+	}
+	if i.start.Before(from) {
+		i.start = from
+	}
+	if i.end.After(to) {
+		i.end = to
+	}
+	return i.start.Before(i.end)
+}
+
+// worklogs sums the intervals of every task into report rows, longest first.
+func worklogs(intervals []interval) []models.Worklog {
+	totals := make(map[int]time.Duration)
+	tasks := make(map[int]interval)
+	running := make(map[int]bool)
+	for _, i := range intervals {
+		totals[i.taskID] += i.end.Sub(i.start)
+		tasks[i.taskID] = i
+		running[i.taskID] = running[i.taskID] || i.running
+	}
+
+	worklogs := make([]models.Worklog, 0, len(totals))
+	for id, d := range totals {
+		worklogs = append(worklogs, models.Worklog{
+			TaskID:    id,
+			ProjectID: tasks[id].projectID,
+			Desc:      tasks[id].desc,
+			Tags:      tasks[id].tags,
+			Running:   running[id],
+			Duration:  models.NewDuration(d),
+		})
+	}
+	sort.Slice(worklogs, func(i, j int) bool {
+		if worklogs[i].Seconds != worklogs[j].Seconds {
+			return worklogs[i].Seconds > worklogs[j].Seconds
+		}
+		return worklogs[i].TaskID < worklogs[j].TaskID
+	})
+	return worklogs
+}
+
+// worklogReport sums the intervals by the groupBy key. Calendar buckets
+// come in calendar order, the other groups longest first.
+func worklogReport(intervals []interval, groupBy string, loc *time.Location) *models.WorklogReport {
+	totals := make(map[string]time.Duration)
+	var total time.Duration
+	for _, i := range intervals {
+		total += i.end.Sub(i.start)
+		for _, part := range splitByBucket(i, groupBy, loc) {
+			for _, key := range groupKeys(part, groupBy, loc) {
+				totals[key] += part.end.Sub(part.start)
+			}
+		}
+	}
+
+	groups := make([]models.WorklogGroup, 0, len(totals))
+	for key, d := range totals {
+		groups = append(groups, models.WorklogGroup{
+			Key:      key,
+			Duration: models.NewDuration(d),
+		})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if !isCalendarGroup(groupBy) && groups[i].Seconds != groups[j].Seconds {
+			return groups[i].Seconds > groups[j].Seconds
+		}
+		return groups[i].Key < groups[j].Key
+	})
+	return &models.WorklogReport{
+		GroupBy:  groupBy,
+		TimeZone: loc.String(),
+		Groups:   groups,
+		Total:    models.NewDuration(total),
+	}
+}
+
+func groupKeys(i interval, groupBy string, loc *time.Location) []string {
+	switch groupBy {
+	case models.GroupByTag:
+		if len(i.tags) == 0 {
+			return []string{""}
+		}
+		return i.tags
+	case models.GroupByDay:
+		return []string{i.start.In(loc).Format("2006-01-02")}
+	case models.GroupByWeek:
+		year, week := i.start.In(loc).ISOWeek()
+		return []string{fmt.Sprintf("%d-W%02d", year, week)}
+	case models.GroupByMonth:
+		return []string{i.start.In(loc).Format("2006-01")}
+	case models.GroupByTask:
+		return []string{strconv.Itoa(i.taskID)}
+	case models.GroupByProject:
+		if i.projectID == nil {
+			return []string{""}
+		}
+		return []string{strconv.Itoa(*i.projectID)}
+	}
+	return nil
+}
+
+func isCalendarGroup(groupBy string) bool {
+	return groupBy == models.GroupByDay || groupBy == models.GroupByWeek || groupBy == models.GroupByMonth
+}
+
+// splitByBucket cuts the interval at the calendar bucket boundaries in loc
+// so every part lies within a single bucket.
+func splitByBucket(i interval, groupBy string, loc *time.Location) []interval {
+	if !isCalendarGroup(groupBy) {
+		return []interval{i}
+	}
+	var parts []interval
+	for {
+		next := nextBucket(i.start.In(loc), groupBy)
+		if !next.Before(i.end) {
+			return append(parts, i)
+		}
+		part := i
+		part.end = next
+		parts = append(parts, part)
+		i.start = next
+	}
+}
+
+// nextBucket returns the start of the calendar bucket after the one of t.
+func nextBucket(t time.Time, groupBy string) time.Time {
+	year, month, day := t.Date()
+	switch groupBy {
+	case models.GroupByWeek:
+		// ISO weeks start on Monday
+		sinceMonday := (int(t.Weekday()) + 6) % 7
+		return time.Date(year, month, day-sinceMonday+7, 0, 0, 0, 0, t.Location())
+	case models.GroupByMonth:
+		return time.Date(year, month+1, 1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Date(year, month, day+1, 0, 0, 0, 0, t.Location())
+}
+
+// timeEntries cuts the interval at midnight of loc into export rows.
+func timeEntries(i interval, userID int, loc *time.Location) []models.TimeEntry {
+	parts := splitByBucket(i, models.GroupByDay, loc)
+	entries := make([]models.TimeEntry, 0, len(parts))
+	for _, part := range parts {
+		entries = append(entries, timeEntry(part, userID, loc))
+	}
+	return entries
+}
+
+func timeEntry(i interval, userID int, loc *time.Location) models.TimeEntry {
+	return models.TimeEntry{
+		Date:      i.start.In(loc).Format("2006-01-02"),
+		SegmentID: i.segmentID,
+		TaskID:    i.taskID,
+		UserID:    userID,
+		ProjectID: i.projectID,
+		Project:   i.project,
+		Desc:      i.desc,
+		Tags:      i.tags,
+		StartTime: i.start.In(loc),
+		EndTime:   i.end.In(loc),
+		Running:   i.running,
+		Duration:  models.NewDuration(i.end.Sub(i.start)),
+	}
+}
+
+// memberIntervals are the intervals of one team member.
+type memberIntervals struct {
+	user      models.User
+	intervals []interval
+}
+
+// teamReport sums the intervals of the members per member and per
+// project, longest first. Members without time are listed too.
+func teamReport(teamID int, req *models.GetTeamWorklogsRequest, members []memberIntervals) *models.TeamReport {
+	report := &models.TeamReport{
+		TeamID:    teamID,
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+		Members:   make([]models.MemberTotal, 0, len(members)),
+		Projects:  []models.ProjectTotal{},
+	}
+	projects := make(map[int]time.Duration)
+	names := make(map[int]string)
+	var total time.Duration
+	for _, m := range members {
+		var d time.Duration
+		for _, i := range m.intervals {
+			d += i.end.Sub(i.start)
+			// 0 stands for tasks without a project, ids start at 1
+			key := 0
+			if i.projectID != nil {
+				key = *i.projectID
+			}
+			projects[key] += i.end.Sub(i.start)
+			names[key] = i.project
+		}
+		total += d
+		report.Members = append(report.Members, models.MemberTotal{
+			UserID:   m.user.Id,
+			Name:     m.user.FullName(),
+			Duration: models.NewDuration(d),
+		})
+	}
+	for key, d := range projects {
+		p := models.ProjectTotal{Project: names[key], Duration: models.NewDuration(d)}
+		if key != 0 {
+			id := key
+			p.ProjectID = &id
+		}
+		report.Projects = append(report.Projects, p)
+	}
+
+	sort.Slice(report.Members, func(i, j int) bool {
+		a, b := report.Members[i], report.Members[j]
+		if a.Seconds != b.Seconds {
+			return a.Seconds > b.Seconds
+		}
+		return a.UserID < b.UserID
+	})
+	sort.Slice(report.Projects, func(i, j int) bool {
+		a, b := report.Projects[i], report.Projects[j]
+		if a.Seconds != b.Seconds {
+			return a.Seconds > b.Seconds
+		}
+		return a.ProjectID != nil && (b.ProjectID == nil || *a.ProjectID < *b.ProjectID)
+	})
+	report.Total = models.NewDuration(total)
+	return report
+}
@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"testing"
+	"time"
+	"time-tracker/internal/models"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestNextBucket(t *testing.T) {
+	berlin := mustLoadLocation(t, "Europe/Berlin")
+	tests := []struct {
+		name    string
+		t       time.Time
+		groupBy string
+		want    time.Time
+	}{
+		{"day", time.Date(2024, 3, 4, 15, 0, 0, 0, time.UTC), models.GroupByDay, time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)},
+		{"midnight starts the next day", time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), models.GroupByDay, time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)},
+		{"23 hour day", time.Date(2024, 3, 31, 1, 0, 0, 0, berlin), models.GroupByDay, time.Date(2024, 4, 1, 0, 0, 0, 0, berlin)},
+		{"25 hour day", time.Date(2024, 10, 27, 1, 0, 0, 0, berlin), models.GroupByDay, time.Date(2024, 10, 28, 0, 0, 0, 0, berlin)},
+		{"week from Monday", time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), models.GroupByWeek, time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)},
+		{"week from Sunday", time.Date(2024, 3, 10, 23, 0, 0, 0, time.UTC), models.GroupByWeek, time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)},
+		{"week across the year", time.Date(2020, 12, 31, 12, 0, 0, 0, time.UTC), models.GroupByWeek, time.Date(2021, 1, 4, 0, 0, 0, 0, time.UTC)},
+		{"week across the DST change", time.Date(2024, 3, 28, 12, 0, 0, 0, berlin), models.GroupByWeek, time.Date(2024, 4, 1, 0, 0, 0, 0, berlin)},
+		{"end of January", time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC), models.GroupByMonth, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"leap February", time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC), models.GroupByMonth, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"December", time.Date(2024, 12, 31, 23, 0, 0, 0, time.UTC), models.GroupByMonth, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextBucket(tt.t, tt.groupBy); !got.Equal(tt.want) {
+				t.Errorf("nextBucket(%v, %s) = %v, want %v", tt.t, tt.groupBy, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorklogReportBuckets(t *testing.T) {
+	berlin := mustLoadLocation(t, "Europe/Berlin")
+	type group struct {
+		key string
+		d   time.Duration
+	}
+	tests := []struct {
+		name       string
+		groupBy    string
+		loc        *time.Location
+		start, end time.Time
+		want       []group
+	}{
+		{
+			name:    "within a day",
+			groupBy: models.GroupByDay,
+			loc:     time.UTC,
+			start:   time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC),
+			end:     time.Date(2024, 3, 4, 17, 30, 0, 0, time.UTC),
+			want:    []group{{"2024-03-04", 8*time.Hour + 30*time.Minute}},
+		},
+		{
+			name:    "across midnight",
+			groupBy: models.GroupByDay,
+			loc:     time.UTC,
+			start:   time.Date(2024, 3, 4, 22, 0, 0, 0, time.UTC),
+			end:     time.Date(2024, 3, 5, 6, 0, 0, 0, time.UTC),
+			want:    []group{{"2024-03-04", 2 * time.Hour}, {"2024-03-05", 6 * time.Hour}},
+		},
+		{
+			name:    "midnight of the report time zone",
+			groupBy: models.GroupByDay,
+			loc:     berlin,
+			start:   time.Date(2024, 3, 4, 22, 0, 0, 0, time.UTC),
+			end:     time.Date(2024, 3, 5, 6, 0, 0, 0, time.UTC),
+			want:    []group{{"2024-03-04", time.Hour}, {"2024-03-05", 7 * time.Hour}},
+		},
+		{
+			name:    "23 hour day",
+			groupBy: models.GroupByDay,
+			loc:     berlin,
+			start:   time.Date(2024, 3, 30, 12, 0, 0, 0, berlin),
+			end:     time.Date(2024, 4, 1, 12, 0, 0, 0, berlin),
+			want:    []group{{"2024-03-30", 12 * time.Hour}, {"2024-03-31", 23 * time.Hour}, {"2024-04-01", 12 * time.Hour}},
+		},
+		{
+			name:    "25 hour day",
+			groupBy: models.GroupByDay,
+			loc:     berlin,
+			start:   time.Date(2024, 10, 27, 0, 0, 0, 0, berlin),
+			end:     time.Date(2024, 10, 28, 1, 0, 0, 0, berlin),
+			want:    []group{{"2024-10-27", 25 * time.Hour}, {"2024-10-28", time.Hour}},
+		},
+		{
+			name:    "ISO week 53",
+			groupBy: models.GroupByWeek,
+			loc:     time.UTC,
+			start:   time.Date(2020, 12, 31, 10, 0, 0, 0, time.UTC),
+			end:     time.Date(2021, 1, 4, 10, 0, 0, 0, time.UTC),
+			want:    []group{{"2020-W53", 3*24*time.Hour + 14*time.Hour}, {"2021-W01", 10 * time.Hour}},
+		},
+		{
+			name:    "week 1 starting in December",
+			groupBy: models.GroupByWeek,
+			loc:     time.UTC,
+			start:   time.Date(2024, 12, 29, 20, 0, 0, 0, time.UTC),
+			end:     time.Date(2024, 12, 30, 2, 0, 0, 0, time.UTC),
+			want:    []group{{"2024-W52", 4 * time.Hour}, {"2025-W01", 2 * time.Hour}},
+		},
+		{
+			name:    "week across the DST change",
+			groupBy: models.GroupByWeek,
+			loc:     berlin,
+			start:   time.Date(2024, 3, 31, 0, 0, 0, 0, berlin),
+			end:     time.Date(2024, 4, 1, 1, 0, 0, 0, berlin),
+			want:    []group{{"2024-W13", 23 * time.Hour}, {"2024-W14", time.Hour}},
+		},
+		{
+			name:    "months across a leap February",
+			groupBy: models.GroupByMonth,
+			loc:     time.UTC,
+			start:   time.Date(2024, 1, 31, 20, 0, 0, 0, time.UTC),
+			end:     time.Date(2024, 3, 1, 4, 0, 0, 0, time.UTC),
+			want:    []group{{"2024-01", 4 * time.Hour}, {"2024-02", 29 * 24 * time.Hour}, {"2024-03", 4 * time.Hour}},
+		},
+		{
+			name:    "month of the report time zone",
+			groupBy: models.GroupByMonth,
+			loc:     berlin,
+			start:   time.Date(2024, 4, 30, 23, 0, 0, 0, time.UTC),
+			end:     time.Date(2024, 5, 1, 1, 0, 0, 0, time.UTC),
+			want:    []group{{"2024-05", 2 * time.Hour}},
+		},
+		{
+			name:    "new year",
+			groupBy: models.GroupByMonth,
+			loc:     time.UTC,
+			start:   time.Date(2024, 12, 31, 23, 0, 0, 0, time.UTC),
+			end:     time.Date(2025, 1, 1, 1, 0, 0, 0, time.UTC),
+			want:    []group{{"2024-12", time.Hour}, {"2025-01", time.Hour}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := interval{taskID: 1, start: tt.start, end: tt.end}
+			report := worklogReport([]interval{i}, tt.groupBy, tt.loc)
+			if report.Total.Seconds != int64(tt.end.Sub(tt.start)/time.Second) {
+				t.Errorf("total = %+v, want %v", report.Total, tt.end.Sub(tt.start))
+			}
+			if len(report.Groups) != len(tt.want) {
+				t.Fatalf("groups = %+v, want %v", report.Groups, tt.want)
+			}
+			for n, g := range report.Groups {
+				if g.Key != tt.want[n].key || g.Seconds != int64(tt.want[n].d/time.Second) {
+					t.Errorf("group %d = %+v, want %s of %v", n, g, tt.want[n].key, tt.want[n].d)
+				}
+			}
+		})
+	}
+}
+
+func TestTimeEntriesSplitAtMidnight(t *testing.T) {
+	berlin := mustLoadLocation(t, "Europe/Berlin")
+	i := interval{taskID: 1, start: time.Date(2024, 3, 30, 22, 0, 0, 0, berlin), end: time.Date(2024, 4, 1, 2, 0, 0, 0, berlin)}
+	entries := timeEntries(i, 1, berlin)
+	want := []struct {
+		date string
+		d    time.Duration
+	}{{"2024-03-30", 2 * time.Hour}, {"2024-03-31", 23 * time.Hour}, {"2024-04-01", 2 * time.Hour}}
+	if len(entries) != len(want) {
+		t.Fatalf("entries = %+v, want %v", entries, want)
+	}
+	for n, e := range entries {
+		if e.Date != want[n].date || e.Seconds != int64(want[n].d/time.Second) {
+			t.Errorf("entry %d is %s of %ds, want %s of %v", n, e.Date, e.Seconds, want[n].date, want[n].d)
+		}
+	}
+}
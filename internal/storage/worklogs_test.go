@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"testing"
+	"time"
+	"time-tracker/internal/models"
+)
+
+func TestEachUserIntervalPages(t *testing.T) {
+	page := intervalPageSize
+	intervalPageSize = 2
+	t.Cleanup(func() { intervalPageSize = page })
+
+	day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -2)
+	at := func(hour int) *time.Time {
+		v := day.Add(time.Duration(hour) * time.Hour)
+		return &v
+	}
+	forEachStore(t, func(t *testing.T, db Database) {
+		ctx := defaultOrg()
+		userID := mustAddUser(t, ctx, db, "1234", "567890")
+		var ids []int
+		for hour := 1; hour <= 5; hour++ {
+			task := models.Task{UserID: userID, StartTime: *at(hour), EndTime: at(hour + 1)}
+			if err := db.AddTask(ctx, &task); err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, task.ID)
+		}
+		// the second and third segment start together across a page boundary
+		moveSegments(t, db, ids[2], *at(2), *at(3))
+
+		req := &models.GetUserWorklogsRequest{UserID: userID, StartDate: day, EndDate: day.AddDate(0, 0, 1)}
+		var got []int
+		err := db.EachUserInterval(ctx, req, func(e models.TimeEntry) error {
+			got = append(got, e.TaskID)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []int{ids[0], ids[1], ids[2], ids[3], ids[4]}
+		if len(got) != len(want) {
+			t.Fatalf("got tasks %v, want %v", got, want)
+		}
+		for n := range want {
+			if got[n] != want[n] {
+				t.Fatalf("got tasks %v, want %v", got, want)
+			}
+		}
+	})
+}
+
+// stopClock makes the reports count running segments up to at.
+func stopClock(t *testing.T, at time.Time) {
+	now := reportNow
+	reportNow = func() time.Time { return at }
+	t.Cleanup(func() { reportNow = now })
+}
+
+func TestReportClipsToWindow(t *testing.T) {
+	const h = time.Hour
+	// times are offsets from midnight some days ago, or from the start of
+	// the running task
+	tests := []struct {
+		name       string
+		start, end time.Duration
+		running    bool
+		from, to   time.Duration
+		now        time.Duration
+		want       time.Duration
+	}{
+		{name: "night shift into the window", start: -2 * h, end: 6 * h, from: 0, to: 24 * h, want: 6 * h},
+		{name: "night shift out of the window", start: -2 * h, end: 6 * h, from: -24 * h, to: 0, want: 2 * h},
+		{name: "night shift within a short window", start: -2 * h, end: 6 * h, from: -1 * h, to: 1 * h, want: 2 * h},
+		{name: "spanning the whole window", start: 8 * h, end: 18 * h, from: 10 * h, to: 12 * h, want: 2 * h},
+		{name: "running clipped to now", running: true, from: -1 * h, to: 24 * h, now: 45 * time.Minute, want: 45 * time.Minute},
+		{name: "running clipped to the window", running: true, from: -1 * h, to: 30 * time.Minute, now: 45 * time.Minute, want: 30 * time.Minute},
+		{name: "before the window", start: -30 * h, end: -26 * h, from: 0, to: 24 * h},
+		{name: "ending when the window starts", start: -4 * h, end: 0, from: 0, to: 24 * h},
+		{name: "starting when the window ends", start: 24 * h, end: 25 * h, from: 0, to: 24 * h},
+		{name: "running after the window", running: true, from: -2 * h, to: -1 * h, now: h},
+	}
+	day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -5)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forEachStore(t, func(t *testing.T, db Database) {
+				ctx := defaultOrg()
+				userID := mustAddUser(t, ctx, db, "1234", "567890")
+				base := day
+				if tt.running {
+					task := models.Task{UserID: userID}
+					if err := db.AddStartTask(ctx, &task, false); err != nil {
+						t.Fatal(err)
+					}
+					stored, err := db.GetTaskByID(ctx, task.ID)
+					if err != nil {
+						t.Fatal(err)
+					}
+					base = stored.StartTime
+				} else {
+					end := base.Add(tt.end)
+					task := models.Task{UserID: userID, StartTime: base.Add(tt.start), EndTime: &end}
+					if err := db.AddTask(ctx, &task); err != nil {
+						t.Fatal(err)
+					}
+				}
+				stopClock(t, base.Add(tt.now))
+
+				req := &models.GetUserWorklogsRequest{UserID: userID, StartDate: base.Add(tt.from), EndDate: base.Add(tt.to)}
+				worklogs, err := db.GetUserWorklogs(ctx, req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if tt.want == 0 {
+					if len(worklogs) != 0 {
+						t.Fatalf("worklogs = %+v, want none", worklogs)
+					}
+					return
+				}
+				if len(worklogs) != 1 {
+					t.Fatalf("worklogs = %+v, want one", worklogs)
+				}
+				if w := worklogs[0]; w.Seconds != int64(tt.want/time.Second) || w.Running != tt.running {
+					t.Errorf("worklog = %+v, want %v running %v", w, tt.want, tt.running)
+				}
+				report, err := db.GetUserWorklogGroups(ctx, &models.GetUserWorklogsRequest{
+					UserID: userID, StartDate: req.StartDate, EndDate: req.EndDate, GroupBy: models.GroupByDay,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if report.Total.Seconds != int64(tt.want/time.Second) {
+					t.Errorf("report total = %+v, want %v", report.Total, tt.want)
+				}
+			})
+		})
+	}
+}
+
+func TestRunningTaskCountsUpToNow(t *testing.T) {
+	forEachStore(t, func(t *testing.T, db Database) {
+		ctx := defaultOrg()
+		userID := mustAddUser(t, ctx, db, "1234", "567890")
+		task := models.Task{UserID: userID, Desc: "running"}
+		if err := db.AddStartTask(ctx, &task, false); err != nil {
+			t.Fatal(err)
+		}
+		stored, err := db.GetTaskByID(ctx, task.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stopClock(t, stored.StartTime.Add(90*time.Minute+59*time.Second))
+
+		req := &models.GetUserWorklogsRequest{
+			UserID:    userID,
+			StartDate: stored.StartTime.Add(-time.Hour),
+			EndDate:   stored.StartTime.Add(24 * time.Hour),
+		}
+		worklogs, err := db.GetUserWorklogs(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := models.Worklog{TaskID: task.ID, Desc: "running", Running: true, Duration: models.Duration{Seconds: 5459, Hours: 1, Minutes: 30}}
+		if len(worklogs) != 1 || worklogs[0].TaskID != want.TaskID || worklogs[0].Running != want.Running || worklogs[0].Duration != want.Duration {
+			t.Fatalf("worklogs = %+v, want %+v", worklogs, want)
+		}
+	})
+}
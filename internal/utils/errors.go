@@ -1,17 +1,44 @@
 package utils
 
+import "errors"
+
 const (
 	ErrNoUsersFound = `no users found`
 
-	ErrQuery   = "failed to execute query: %v with params: %+v, error: %v"
-	ErrScanRow = "failed to scan row for query: %v with params: %+v, error: %v"
+	ErrUserNotFound = "user with id %d not found"
+
+	ErrQuery   = "failed to execute query: %v with params: %+v, error: %w"
+	ErrScanRow = "failed to scan row for query: %v with params: %+v, error: %w"
 
-	ErrRowIteration = "row iteration error for query: %v with params: %+v, error: %v"
+	ErrRowIteration = "row iteration error for query: %v with params: %+v, error: %w"
 
-	ErrBeginTx = "cant begin tx: %v"
+	ErrBeginTx = "cant begin tx: %w"
 )
 
 const (
 	ErrCantOpenDB = "cant open db"
 	ErrCantPingDB = "cant ping db"
+
+	ErrUnknownDriver = "unknown db driver: %q"
+)
+
+var (
+	ErrTaskAlreadyRunning = errors.New("user already has a running task")
+	ErrTaskNotRunning     = errors.New("task is not running")
+	ErrTaskStopped        = errors.New("task is already stopped")
+
+	ErrInvalidInterval = errors.New("end_time must be after start_time")
+	ErrFutureTime      = errors.New("time entries cannot end in the future")
+	ErrOverlap         = errors.New("time entry overlaps another entry of the user")
+
+	ErrClientNotFound  = errors.New("client not found")
+	ErrProjectNotFound = errors.New("project not found")
+	ErrAlreadyExists   = errors.New("already exists")
+	ErrInUse           = errors.New("still referenced by other records")
+
+	ErrForbidden      = errors.New("forbidden")
+	ErrInvalidManager = errors.New("manager must be another existing user")
+	ErrLeadNotFound   = errors.New("team lead not found")
+
+	ErrNoOrganization = errors.New("storage call is not scoped to an organization")
 )
@@ -6,12 +6,14 @@ import (
 
 	"github.com/golang-migrate/migrate"
 	_ "github.com/golang-migrate/migrate/database/postgres"
+	_ "github.com/golang-migrate/migrate/database/sqlite3"
 	_ "github.com/golang-migrate/migrate/source/file"
 )
 
-func Up(url string) {
+// Up applies the migrations from ./migrations/<dir>/ to the database at url.
+func Up(dir, url string) {
 	m, err := migrate.New(
-		"file://./migrations/",
+		"file://./migrations/"+dir+"/",
 		url)
 	if err != nil {
 		log.Fatalln("failed to create migration: ", err)
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS tasks_one_running_per_user;
@@ -0,0 +1,21 @@
+-- a task paused and resumed has several segments, folding them into one
+-- row would count the pauses as work
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM task_segments GROUP BY task_id HAVING COUNT(*) > 1) THEN
+        RAISE EXCEPTION 'cannot migrate below 3: tasks with several segments would lose their pauses, delete or edit them first';
+    END IF;
+END $$;
+
+-- every task takes the times of its only segment, so a paused task ends
+-- where its segment ended and leaves the running slot free
+UPDATE tasks
+SET start_time = (SELECT s.start_time FROM task_segments s WHERE s.task_id = tasks.id),
+    end_time = (SELECT s.end_time FROM task_segments s WHERE s.task_id = tasks.id)
+WHERE id IN (SELECT task_id FROM task_segments);
+
+DROP TABLE IF EXISTS task_segments;
+
+CREATE UNIQUE INDEX IF NOT EXISTS tasks_one_running_per_user
+    ON tasks (user_id)
+    WHERE end_time IS NULL;
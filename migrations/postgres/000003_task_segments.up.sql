@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS task_segments (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    start_time TIMESTAMPTZ NOT NULL,
+    end_time TIMESTAMPTZ,
+    FOREIGN KEY (task_id) REFERENCES tasks(id),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS task_segments_task_id ON task_segments (task_id);
+
+-- every existing task becomes a single segment
+INSERT INTO task_segments (task_id, user_id, start_time, end_time)
+SELECT id, user_id, start_time, end_time
+FROM tasks
+WHERE start_time IS NOT NULL;
+
+-- a paused task has no open segment but is not stopped either,
+-- so the running slot moves from tasks to segments
+DROP INDEX IF EXISTS tasks_one_running_per_user;
+
+CREATE UNIQUE INDEX IF NOT EXISTS task_segments_one_running_per_user
+    ON task_segments (user_id)
+    WHERE end_time IS NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE task_segments DROP CONSTRAINT IF EXISTS task_segments_no_overlap;
+
+ALTER TABLE task_segments DROP COLUMN IF EXISTS legacy_overlap;
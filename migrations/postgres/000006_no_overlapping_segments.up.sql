@@ -0,0 +1,21 @@
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+ALTER TABLE task_segments
+    ADD COLUMN IF NOT EXISTS legacy_overlap BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- segments that already overlap are kept as they are and listed by
+-- GET /users/:id/conflicts, the constraint only covers the rest
+UPDATE task_segments a
+SET legacy_overlap = TRUE
+WHERE EXISTS (
+    SELECT 1
+    FROM task_segments b
+    WHERE b.user_id = a.user_id
+        AND b.id <> a.id
+        AND tstzrange(b.start_time, b.end_time) && tstzrange(a.start_time, a.end_time)
+);
+
+ALTER TABLE task_segments
+    ADD CONSTRAINT task_segments_no_overlap
+    EXCLUDE USING gist (user_id WITH =, tstzrange(start_time, end_time) WITH &&)
+    WHERE (NOT legacy_overlap);
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS calendar_tokens (
+    user_id INTEGER PRIMARY KEY,
+    token_hash VARCHAR(64) NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
@@ -0,0 +1,9 @@
+ALTER TABLE users
+    ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'employee'
+        CHECK (role IN ('admin', 'manager', 'employee'));
+
+-- the manager whose team the user is in
+ALTER TABLE users
+    ADD COLUMN manager_id INTEGER REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS users_manager_id_idx ON users (manager_id);
@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS projects_client_name;
+CREATE UNIQUE INDEX IF NOT EXISTS projects_client_name
+    ON projects (COALESCE(client_id, 0), name);
+ALTER TABLE teams DROP CONSTRAINT IF EXISTS teams_org_name;
+ALTER TABLE teams ADD CONSTRAINT teams_name_key UNIQUE (name);
+ALTER TABLE clients DROP CONSTRAINT IF EXISTS clients_org_name;
+ALTER TABLE clients ADD CONSTRAINT clients_name_key UNIQUE (name);
+
+DROP INDEX IF EXISTS users_org_id;
+ALTER TABLE teams DROP COLUMN IF EXISTS org_id;
+ALTER TABLE projects DROP COLUMN IF EXISTS org_id;
+ALTER TABLE clients DROP COLUMN IF EXISTS org_id;
+ALTER TABLE users DROP COLUMN IF EXISTS org_id;
+DROP TABLE IF EXISTS organizations;
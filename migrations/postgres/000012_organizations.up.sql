@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS organizations (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) UNIQUE NOT NULL
+);
+
+-- everything created before organizations belongs to the first one
+INSERT INTO organizations (id, name) VALUES (1, 'Default');
+SELECT setval(pg_get_serial_sequence('organizations', 'id'), 1);
+
+ALTER TABLE users ADD COLUMN org_id INTEGER NOT NULL DEFAULT 1 REFERENCES organizations(id);
+ALTER TABLE clients ADD COLUMN org_id INTEGER NOT NULL DEFAULT 1 REFERENCES organizations(id);
+ALTER TABLE projects ADD COLUMN org_id INTEGER NOT NULL DEFAULT 1 REFERENCES organizations(id);
+ALTER TABLE teams ADD COLUMN org_id INTEGER NOT NULL DEFAULT 1 REFERENCES organizations(id);
+
+-- new rows must name their organization
+ALTER TABLE users ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE clients ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE projects ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE teams ALTER COLUMN org_id DROP DEFAULT;
+
+CREATE INDEX IF NOT EXISTS users_org_id ON users (org_id);
+
+-- names are unique per organization
+ALTER TABLE clients DROP CONSTRAINT IF EXISTS clients_name_key;
+ALTER TABLE clients ADD CONSTRAINT clients_org_name UNIQUE (org_id, name);
+ALTER TABLE teams DROP CONSTRAINT IF EXISTS teams_name_key;
+ALTER TABLE teams ADD CONSTRAINT teams_org_name UNIQUE (org_id, name);
+DROP INDEX IF EXISTS projects_client_name;
+CREATE UNIQUE INDEX IF NOT EXISTS projects_client_name
+    ON projects (org_id, COALESCE(client_id, 0), name);
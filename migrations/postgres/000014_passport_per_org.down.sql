@@ -0,0 +1,10 @@
+-- a passport used in several organizations cannot become unique again
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM users GROUP BY passport_number HAVING COUNT(*) > 1) THEN
+        RAISE EXCEPTION 'cannot migrate below 14: passports are used in several organizations';
+    END IF;
+END $$;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_org_passport;
+ALTER TABLE users ADD CONSTRAINT users_passport_number_key UNIQUE (passport_number);
@@ -0,0 +1,4 @@
+-- passports are unique per organization, a clash with another one would
+-- tell that the person is known there
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_passport_number_key;
+ALTER TABLE users ADD CONSTRAINT users_org_passport UNIQUE (org_id, passport_number);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    passport_number VARCHAR(6) UNIQUE NOT NULL,
+    pass_serie VARCHAR(4) NOT NULL,
+    surname VARCHAR(15),
+    name VARCHAR(15),
+    patronymic VARCHAR(20),
+    address VARCHAR(255)
+);
+
+CREATE TABLE IF NOT EXISTS tasks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    description VARCHAR(1023),
+    start_time TIMESTAMP,
+    end_time TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
@@ -0,0 +1,14 @@
+-- close duplicate running tasks at the start of the user's next task,
+-- the same result the stop_running policy would have produced
+UPDATE tasks
+SET end_time = COALESCE((
+    SELECT MIN(next.start_time)
+    FROM tasks next
+    WHERE next.user_id = tasks.user_id AND next.start_time > tasks.start_time
+), start_time)
+WHERE end_time IS NULL
+  AND id NOT IN (SELECT MAX(id) FROM tasks WHERE end_time IS NULL GROUP BY user_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS tasks_one_running_per_user
+    ON tasks (user_id)
+    WHERE end_time IS NULL;
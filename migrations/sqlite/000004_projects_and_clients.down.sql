@@ -0,0 +1,5 @@
+-- sqlite cannot drop a column used by a foreign key, project_id is only emptied
+DROP INDEX IF EXISTS tasks_project_id;
+UPDATE tasks SET project_id = NULL;
+DROP TABLE IF EXISTS projects;
+DROP TABLE IF EXISTS clients;
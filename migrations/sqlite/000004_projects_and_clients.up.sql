@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS clients (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) UNIQUE NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS projects (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    client_id INTEGER,
+    name VARCHAR(255) NOT NULL,
+    FOREIGN KEY (client_id) REFERENCES clients(id)
+);
+
+-- project names are unique per client, internal projects have no client
+CREATE UNIQUE INDEX IF NOT EXISTS projects_client_name
+    ON projects (COALESCE(client_id, 0), name);
+
+ALTER TABLE tasks ADD COLUMN project_id INTEGER REFERENCES projects(id);
+
+CREATE INDEX IF NOT EXISTS tasks_project_id ON tasks (project_id);
@@ -0,0 +1,6 @@
+-- Intentionally empty. The postgres migration 000006 adds an exclusion
+-- constraint against overlapping segments, SQLite has none. Writes go
+-- through a single connection, so the overlap check done before each write
+-- is enough. The file only keeps the version numbers of both databases in
+-- step, removing it would shift every later SQLite migration.
+SELECT 1;
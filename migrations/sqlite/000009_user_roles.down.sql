@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS users_manager_id_idx;
+ALTER TABLE users DROP COLUMN manager_id;
+ALTER TABLE users DROP COLUMN role;
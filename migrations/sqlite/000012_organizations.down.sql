@@ -0,0 +1,25 @@
+DROP INDEX IF EXISTS projects_client_name;
+CREATE UNIQUE INDEX IF NOT EXISTS projects_client_name
+    ON projects (COALESCE(client_id, 0), name);
+
+CREATE TABLE teams_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) UNIQUE NOT NULL,
+    lead_id INTEGER REFERENCES users(id) ON DELETE SET NULL
+);
+INSERT INTO teams_old (id, name, lead_id) SELECT id, name, lead_id FROM teams;
+DROP TABLE teams;
+ALTER TABLE teams_old RENAME TO teams;
+
+CREATE TABLE clients_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) UNIQUE NOT NULL
+);
+INSERT INTO clients_old (id, name) SELECT id, name FROM clients;
+DROP TABLE clients;
+ALTER TABLE clients_old RENAME TO clients;
+
+DROP INDEX IF EXISTS users_org_id;
+ALTER TABLE projects DROP COLUMN org_id;
+ALTER TABLE users DROP COLUMN org_id;
+DROP TABLE IF EXISTS organizations;
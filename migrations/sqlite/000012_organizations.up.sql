@@ -0,0 +1,40 @@
+CREATE TABLE IF NOT EXISTS organizations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) UNIQUE NOT NULL
+);
+
+-- everything created before organizations belongs to the first one
+INSERT INTO organizations (id, name) VALUES (1, 'Default');
+
+ALTER TABLE users ADD COLUMN org_id INTEGER NOT NULL DEFAULT 1 REFERENCES organizations(id);
+ALTER TABLE projects ADD COLUMN org_id INTEGER NOT NULL DEFAULT 1 REFERENCES organizations(id);
+
+CREATE INDEX IF NOT EXISTS users_org_id ON users (org_id);
+
+-- names are unique per organization, SQLite cannot drop the old UNIQUE
+-- constraints so clients and teams are rebuilt. Migrations run without
+-- foreign key enforcement, the references to both tables stay valid.
+CREATE TABLE clients_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) NOT NULL,
+    org_id INTEGER NOT NULL REFERENCES organizations(id),
+    UNIQUE (org_id, name)
+);
+INSERT INTO clients_new (id, name, org_id) SELECT id, name, 1 FROM clients;
+DROP TABLE clients;
+ALTER TABLE clients_new RENAME TO clients;
+
+CREATE TABLE teams_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) NOT NULL,
+    lead_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    org_id INTEGER NOT NULL REFERENCES organizations(id),
+    UNIQUE (org_id, name)
+);
+INSERT INTO teams_new (id, name, lead_id, org_id) SELECT id, name, lead_id, 1 FROM teams;
+DROP TABLE teams;
+ALTER TABLE teams_new RENAME TO teams;
+
+DROP INDEX IF EXISTS projects_client_name;
+CREATE UNIQUE INDEX IF NOT EXISTS projects_client_name
+    ON projects (org_id, COALESCE(client_id, 0), name);
@@ -0,0 +1,14 @@
+ALTER TABLE users ADD COLUMN enrichment_status VARCHAR(32) NOT NULL DEFAULT 'enriched';
+
+-- lookups of the people info service still to be done, a row is removed
+-- once its user is enriched or the lookup has failed for good
+CREATE TABLE IF NOT EXISTS enrichment_jobs (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id),
+    -- lookups started so far, a worker claims a job by raising it
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS enrichment_jobs_next_attempt_at ON enrichment_jobs (next_attempt_at);
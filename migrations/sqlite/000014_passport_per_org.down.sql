@@ -0,0 +1,41 @@
+-- a passport used in several organizations cannot become unique again.
+-- SQLite raises errors only from triggers, so a guard table fails the
+-- migration when such a passport exists.
+CREATE TEMP TABLE users_down_guard (passport_number VARCHAR(6));
+
+CREATE TEMP TRIGGER users_down_guard
+BEFORE INSERT ON users_down_guard
+BEGIN
+    SELECT RAISE(ABORT, 'cannot migrate below 14: passports are used in several organizations');
+END;
+
+INSERT INTO users_down_guard
+SELECT passport_number FROM users GROUP BY passport_number HAVING COUNT(*) > 1 LIMIT 1;
+
+DROP TABLE users_down_guard;
+
+CREATE TABLE users_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    passport_number VARCHAR(6) UNIQUE NOT NULL,
+    pass_serie VARCHAR(4) NOT NULL,
+    surname VARCHAR(15),
+    name VARCHAR(15),
+    patronymic VARCHAR(20),
+    address VARCHAR(255),
+    password_hash VARCHAR(255),
+    role VARCHAR(16) NOT NULL DEFAULT 'employee'
+        CHECK (role IN ('admin', 'manager', 'employee')),
+    manager_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    org_id INTEGER NOT NULL DEFAULT 1 REFERENCES organizations(id),
+    enrichment_status VARCHAR(32) NOT NULL DEFAULT 'enriched'
+);
+INSERT INTO users_old (id, passport_number, pass_serie, surname, name, patronymic, address,
+    password_hash, role, manager_id, org_id, enrichment_status)
+SELECT id, passport_number, pass_serie, surname, name, patronymic, address,
+    password_hash, role, manager_id, org_id, enrichment_status
+FROM users;
+DROP TABLE users;
+ALTER TABLE users_old RENAME TO users;
+
+CREATE INDEX IF NOT EXISTS users_manager_id_idx ON users (manager_id);
+CREATE INDEX IF NOT EXISTS users_org_id ON users (org_id);
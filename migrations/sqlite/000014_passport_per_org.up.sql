@@ -0,0 +1,30 @@
+-- passports are unique per organization, a clash with another one would
+-- tell that the person is known there. SQLite cannot drop the old UNIQUE
+-- constraint so users are rebuilt. Migrations run without foreign key
+-- enforcement, the references to users stay valid.
+CREATE TABLE users_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    passport_number VARCHAR(6) NOT NULL,
+    pass_serie VARCHAR(4) NOT NULL,
+    surname VARCHAR(15),
+    name VARCHAR(15),
+    patronymic VARCHAR(20),
+    address VARCHAR(255),
+    password_hash VARCHAR(255),
+    role VARCHAR(16) NOT NULL DEFAULT 'employee'
+        CHECK (role IN ('admin', 'manager', 'employee')),
+    manager_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    org_id INTEGER NOT NULL DEFAULT 1 REFERENCES organizations(id),
+    enrichment_status VARCHAR(32) NOT NULL DEFAULT 'enriched',
+    UNIQUE (org_id, passport_number)
+);
+INSERT INTO users_new (id, passport_number, pass_serie, surname, name, patronymic, address,
+    password_hash, role, manager_id, org_id, enrichment_status)
+SELECT id, passport_number, pass_serie, surname, name, patronymic, address,
+    password_hash, role, manager_id, org_id, enrichment_status
+FROM users;
+DROP TABLE users;
+ALTER TABLE users_new RENAME TO users;
+
+CREATE INDEX IF NOT EXISTS users_manager_id_idx ON users (manager_id);
+CREATE INDEX IF NOT EXISTS users_org_id ON users (org_id);